@@ -2,10 +2,18 @@ package main
 
 import (
 	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"log/slog"
+	"os"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/rating"
+	providersmaster "mytonprovider-backend/pkg/workers/providersMaster"
 )
 
 var logLevels = map[uint8]slog.Level{
@@ -22,6 +30,17 @@ type System struct {
 	Key              ed25519.PrivateKey `env:"SYSTEM_KEY" required:"false"`
 	LogLevel         uint8              `env:"SYSTEM_LOG_LEVEL" envDefault:"1"` // 0 - debug, 1 - info, 2 - warn, 3 - error
 	StoreHistoryDays int                `env:"SYSTEM_STORE_HISTORY_DAYS" envDefault:"90"`
+	// Accept telemetry and benchmarks without X-Signature header, until all providers are updated
+	AllowUnsignedTelemetry   bool          `env:"SYSTEM_ALLOW_UNSIGNED_TELEMETRY" envDefault:"true"`
+	TelemetrySignatureMaxAge time.Duration `env:"SYSTEM_TELEMETRY_SIGNATURE_MAX_AGE" envDefault:"5m"`
+	RatingUptimeWindow       string        `env:"SYSTEM_RATING_UPTIME_WINDOW" envDefault:"all"` // all, 24h, 7d or 30d
+	// Jobs intervals overrides, e.g. "StoreProof:30m,UpdateUptime:10m"
+	JobsIntervals map[string]time.Duration `env:"SYSTEM_JOBS_INTERVALS" envDefault:""`
+	// Only one instance runs jobs, others take over if it doesn't renew the lease for LeaderLeaseTTL
+	InstanceID     string        `env:"SYSTEM_INSTANCE_ID" envDefault:""` // hostname with random suffix if empty
+	LeaderLeaseTTL time.Duration `env:"SYSTEM_LEADER_LEASE_TTL" envDefault:"30s"`
+	// Apply pending migrations on start, otherwise only check that db schema is up to date
+	AutoMigrate bool `env:"SYSTEM_AUTO_MIGRATE" envDefault:"true"`
 }
 
 type Metrics struct {
@@ -35,6 +54,10 @@ type TON struct {
 	MasterAddress string `env:"MASTER_ADDRESS" required:"true" envDefault:"UQB3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d0x0"`
 	ConfigURL     string `env:"TON_CONFIG_URL" required:"true" envDefault:"https://ton-blockchain.github.io/global.config.json"`
 	BatchSize     uint32 `env:"BATCH_SIZE" required:"true" envDefault:"100"`
+	// Save lite servers responses to fixtures, replaying them is for tests only as DHT and
+	// storage clients still need the network
+	FixturesMode string `env:"TON_FIXTURES_MODE" envDefault:""` // empty or record
+	FixturesDir  string `env:"TON_FIXTURES_DIR" envDefault:"fixtures"`
 }
 
 type Postgress struct {
@@ -45,11 +68,22 @@ type Postgress struct {
 	Name     string `env:"DB_NAME" required:"true"`
 }
 
+type Webhooks struct {
+	Timeout        time.Duration `env:"WEBHOOKS_TIMEOUT" envDefault:"10s"`
+	MaxAttempts    int           `env:"WEBHOOKS_MAX_ATTEMPTS" envDefault:"10"`
+	RetryBaseDelay time.Duration `env:"WEBHOOKS_RETRY_BASE_DELAY" envDefault:"30s"`
+	RetryMaxDelay  time.Duration `env:"WEBHOOKS_RETRY_MAX_DELAY" envDefault:"6h"`
+}
+
 type Config struct {
-	System  System
-	Metrics Metrics
-	TON     TON
-	DB      Postgress
+	System        System
+	Metrics       Metrics
+	TON           TON
+	DB            Postgress
+	Rating        rating.Coefficients
+	Webhooks      Webhooks
+	ProofSampling providersmaster.Sampling
+	Audit         providersmaster.Audit
 }
 
 func loadConfig() *Config {
@@ -66,6 +100,56 @@ func loadConfig() *Config {
 	if err := env.Parse(&cfg.TON); err != nil {
 		log.Fatalf("Failed to parse TON config: %v", err)
 	}
+	if err := env.Parse(&cfg.Rating); err != nil {
+		log.Fatalf("Failed to parse rating config: %v", err)
+	}
+	if err := env.Parse(&cfg.Webhooks); err != nil {
+		log.Fatalf("Failed to parse webhooks config: %v", err)
+	}
+	if err := env.Parse(&cfg.ProofSampling); err != nil {
+		log.Fatalf("Failed to parse proof sampling config: %v", err)
+	}
+	if err := env.Parse(&cfg.Audit); err != nil {
+		log.Fatalf("Failed to parse audit config: %v", err)
+	}
+
+	if _, ok := constants.UptimeWindowsMap[cfg.System.RatingUptimeWindow]; !ok {
+		log.Fatalf("Unknown rating uptime window: %s", cfg.System.RatingUptimeWindow)
+	}
+
+	for name, interval := range cfg.System.JobsIntervals {
+		if interval <= 0 {
+			log.Fatalf("Invalid interval for job %s: %s", name, interval)
+		}
+	}
+
+	switch cfg.TON.FixturesMode {
+	case "", "record":
+	case "replay":
+		log.Fatalf("TON fixtures replay is only supported in tests, DHT and storage clients need the network")
+	default:
+		log.Fatalf("Unknown TON fixtures mode: %s", cfg.TON.FixturesMode)
+	}
+
+	if cfg.System.TelemetrySignatureMaxAge <= 0 {
+		log.Fatalf("Invalid telemetry signature max age: %s", cfg.System.TelemetrySignatureMaxAge)
+	}
+
+	if cfg.ProofSampling.MinPieces == 0 || cfg.ProofSampling.MaxPieces < cfg.ProofSampling.MinPieces {
+		log.Fatalf("Invalid proof sampling pieces range: %d..%d", cfg.ProofSampling.MinPieces, cfg.ProofSampling.MaxPieces)
+	}
+
+	if cfg.Audit.MaxDuration <= 0 {
+		log.Fatalf("Invalid audit max duration: %s", cfg.Audit.MaxDuration)
+	}
+
+	if cfg.System.LeaderLeaseTTL < time.Second {
+		log.Fatalf("Leader lease ttl is too short: %s", cfg.System.LeaderLeaseTTL)
+	}
+
+	if cfg.System.InstanceID == "" {
+		cfg.System.InstanceID = defaultInstanceID()
+	}
 
 	if cfg.System.Key == nil {
 		_, priv, _ := ed25519.GenerateKey(nil)
@@ -75,3 +159,15 @@ func loadConfig() *Config {
 
 	return cfg
 }
+
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "instance"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return hostname + "-" + hex.EncodeToString(suffix)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -14,8 +15,21 @@ import (
 	"github.com/xssnick/tonutils-go/adnl/dht"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-storage-provider/pkg/transport"
+
+	providersmaster "mytonprovider-backend/pkg/workers/providersMaster"
 )
 
+func newLogger(config *Config) *slog.Logger {
+	logLevel := slog.LevelInfo
+	if level, ok := logLevels[config.System.LogLevel]; ok {
+		logLevel = level
+	}
+
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+}
+
 func connectPostgres(ctx context.Context, config *Config, logger *slog.Logger) (connPool *pgxpool.Pool, err error) {
 	cfg, err := newPostgresConfig(config, logger)
 	if err != nil {
@@ -124,7 +138,7 @@ func newProviderClient(ctx context.Context, configURL, ADNLPort string, privateK
 		return
 	}
 
-	tc = transport.NewClient(gateProvider, dc)
+	tc = transport.NewClient(gateProvider, providersmaster.NewProvidersDHT(dc))
 
 	return
 }
@@ -17,21 +17,63 @@ import (
 	"mytonprovider-backend/pkg/clients/ifconfig"
 	tonclient "mytonprovider-backend/pkg/clients/ton"
 	"mytonprovider-backend/pkg/httpServer"
+	"mytonprovider-backend/pkg/migrations"
+	"mytonprovider-backend/pkg/rating"
 	providersRepository "mytonprovider-backend/pkg/repositories/providers"
 	systemRepository "mytonprovider-backend/pkg/repositories/system"
+	webhooksRepository "mytonprovider-backend/pkg/repositories/webhooks"
+	"mytonprovider-backend/pkg/services/audits"
 	"mytonprovider-backend/pkg/services/providers"
+	"mytonprovider-backend/pkg/services/system"
+	"mytonprovider-backend/pkg/services/webhooks"
 	"mytonprovider-backend/pkg/workers"
 	"mytonprovider-backend/pkg/workers/cleaner"
 	providersmaster "mytonprovider-backend/pkg/workers/providersMaster"
+	statsWorker "mytonprovider-backend/pkg/workers/stats"
 	"mytonprovider-backend/pkg/workers/telemetry"
+	webhooksWorker "mytonprovider-backend/pkg/workers/webhooks"
 )
 
 func main() {
-	if err := run(); err != nil {
+	f := run
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		f = migrate
+	}
+
+	if err := f(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// migrate applies pending migrations and exits
+func migrate() (err error) {
+	config := loadConfig()
+	logger := newLogger(config)
+
+	connPool, err := connectPostgres(context.Background(), config, logger)
+	if err != nil {
+		logger.Error("failed to connect to Postgres", slog.String("error", err.Error()))
+		return
+	}
+	defer connPool.Close()
+
+	migrator, err := migrations.New(connPool, logger)
+	if err != nil {
+		logger.Error("failed to init migrations", slog.String("error", err.Error()))
+		return
+	}
+
+	err = migrator.Up(context.Background())
+	if err != nil {
+		logger.Error("failed to apply migrations", slog.String("error", err.Error()))
+		return
+	}
+
+	logger.Info("migrations applied")
+
+	return
+}
+
 func run() (err error) {
 	// Tools
 	config := loadConfig()
@@ -40,14 +82,7 @@ func run() (err error) {
 		return
 	}
 
-	logLevel := slog.LevelInfo
-	if level, ok := logLevels[config.System.LogLevel]; ok {
-		logLevel = level
-	}
-
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	logger := newLogger(config)
 	telemetryCache := simpleCache.NewSimpleCache(2 * time.Minute)
 	benchmarksCache := simpleCache.NewSimpleCache(2 * time.Minute)
 
@@ -102,16 +137,64 @@ func run() (err error) {
 		[]string{"provider_pubkey", "type"},
 	)
 
+	telemetryRejectedCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: config.Metrics.Namespace,
+			Subsystem: config.Metrics.ServerSubsystem,
+			Name:      "telemetry_rejected_count",
+			Help:      "Rejected telemetry and benchmarks submissions count",
+		},
+		[]string{"payload", "reason"},
+	)
+
+	workersIsLeader := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: config.Metrics.Namespace,
+			Subsystem: config.Metrics.WorkersSubsystem,
+			Name:      "is_leader",
+			Help:      "1 if the instance runs background jobs",
+		},
+		[]string{"instance"},
+	)
+
+	ratesPollDuration := prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: config.Metrics.Namespace,
+			Subsystem: config.Metrics.WorkersSubsystem,
+			Name:      "rates_poll_duration",
+			Help:      "Duration of providers storage rates polling pass",
+		},
+	)
+
+	ratesTimeouts := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: config.Metrics.Namespace,
+			Subsystem: config.Metrics.WorkersSubsystem,
+			Name:      "rates_timeouts_count",
+			Help:      "Providers storage rates queries ended by timeout",
+		},
+	)
+
 	prometheus.MustRegister(
 		dbRequestsCount,
 		dbRequestsDuration,
 		workersRunCount,
 		workersRunDuration,
 		providersNetLoad,
+		telemetryRejectedCount,
+		workersIsLeader,
+		ratesPollDuration,
+		ratesTimeouts,
 	)
 
 	// Clients
-	ton, err := tonclient.NewClient(context.Background(), config.TON.ConfigURL, logger)
+	var ton tonclient.Client
+	switch config.TON.FixturesMode {
+	case "record":
+		ton, err = tonclient.NewRecordingClient(context.Background(), config.TON.ConfigURL, config.TON.FixturesDir, logger)
+	default:
+		ton, err = tonclient.NewClient(context.Background(), config.TON.ConfigURL, logger)
+	}
 	if err != nil {
 		logger.Error("failed to create TON client", slog.String("error", err.Error()))
 		return
@@ -132,6 +215,24 @@ func run() (err error) {
 		return
 	}
 
+	migrator, err := migrations.New(connPool, logger)
+	if err != nil {
+		logger.Error("failed to init migrations", slog.String("error", err.Error()))
+		return
+	}
+
+	if config.System.AutoMigrate {
+		err = migrator.Up(context.Background())
+	} else {
+		err = migrator.Check(context.Background())
+	}
+	if err != nil {
+		logger.Error("database schema is not ready", slog.String("error", err.Error()))
+		return
+	}
+
+	scorer := rating.NewScorer(config.Rating)
+
 	// Database
 	providersRepo := providersRepository.NewRepository(connPool)
 	providersRepo = providersRepository.NewMetrics(dbRequestsCount, dbRequestsDuration, providersRepo)
@@ -139,6 +240,9 @@ func run() (err error) {
 	systemRepo := systemRepository.NewRepository(connPool)
 	systemRepo = systemRepository.NewMetrics(dbRequestsCount, dbRequestsDuration, systemRepo)
 
+	webhooksRepo := webhooksRepository.NewRepository(connPool)
+	webhooksRepo = webhooksRepository.NewMetrics(dbRequestsCount, dbRequestsDuration, webhooksRepo)
+
 	// Workers
 	telemetryWorker := telemetry.NewWorker(providersRepo, telemetryCache, benchmarksCache, providersNetLoad, logger)
 	telemetryWorker = telemetry.NewMetrics(workersRunCount, workersRunDuration, telemetryWorker)
@@ -150,17 +254,49 @@ func run() (err error) {
 		providerClient,
 		dhtClient,
 		ipinfo,
+		webhooksRepo,
 		config.TON.MasterAddress,
 		config.TON.BatchSize,
+		config.ProofSampling,
+		config.Audit,
+		scorer,
+		config.System.RatingUptimeWindow,
+		ratesPollDuration,
+		ratesTimeouts,
 		logger,
 	)
 	providersMasterWorker = providersmaster.NewMetrics(workersRunCount, workersRunDuration, providersMasterWorker)
 
-	cleanerWorker := cleaner.NewWorker(providersRepo, config.System.StoreHistoryDays, logger)
+	cleanerWorker := cleaner.NewWorker(providersRepo, webhooksRepo, systemRepo, config.System.StoreHistoryDays, logger)
 	cleanerWorker = cleaner.NewMetrics(workersRunCount, workersRunDuration, cleanerWorker)
 
+	deliveryWorker := webhooksWorker.NewWorker(
+		webhooksRepo,
+		config.Webhooks.Timeout,
+		config.Webhooks.MaxAttempts,
+		config.Webhooks.RetryBaseDelay,
+		config.Webhooks.RetryMaxDelay,
+		logger,
+	)
+	deliveryWorker = webhooksWorker.NewMetrics(workersRunCount, workersRunDuration, deliveryWorker)
+
+	snapshotsWorker := statsWorker.NewWorker(providersRepo, systemRepo, logger)
+	snapshotsWorker = statsWorker.NewMetrics(workersRunCount, workersRunDuration, snapshotsWorker)
+
 	cancelCtx, cancel := context.WithCancel(context.Background())
-	workers := workers.NewWorkers(telemetryWorker, providersMasterWorker, cleanerWorker, logger)
+	workers := workers.NewWorkers(
+		telemetryWorker,
+		providersMasterWorker,
+		cleanerWorker,
+		deliveryWorker,
+		snapshotsWorker,
+		systemRepo,
+		config.System.JobsIntervals,
+		config.System.InstanceID,
+		config.System.LeaderLeaseTTL,
+		workersIsLeader,
+		logger,
+	)
 	go func() {
 		if wErr := workers.Start(cancelCtx); wErr != nil {
 			logger.Error("failed to start workers", slog.String("error", wErr.Error()))
@@ -170,15 +306,32 @@ func run() (err error) {
 	}()
 
 	// Services
-	providersService := providers.NewService(providersRepo, logger)
+	providersService := providers.NewService(
+		providersRepo,
+		config.System.AllowUnsignedTelemetry,
+		config.System.TelemetrySignatureMaxAge,
+		telemetryRejectedCount,
+		scorer,
+		config.System.RatingUptimeWindow,
+		logger,
+	)
 	providersService = providers.NewCacheMiddleware(providersService, telemetryCache, benchmarksCache)
 
+	webhooksService := webhooks.NewService(webhooksRepo, logger)
+
+	systemService := system.NewService(systemRepo, systemRepo, workers, config.System.InstanceID, logger)
+
+	auditsService := audits.NewService(providersRepo, workers, logger)
+
 	// HTTP Server
 	accessTokens := strings.Split(config.System.AccessTokens, ",")
 	app := fiber.New()
 	server := httpServer.New(
 		app,
 		providersService,
+		webhooksService,
+		systemService,
+		auditsService,
 		accessTokens,
 		config.Metrics.Namespace,
 		config.Metrics.ServerSubsystem,
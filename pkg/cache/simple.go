@@ -48,6 +48,21 @@ func (c *SimpleCache) Set(key string, value interface{}) {
 	}
 }
 
+// SetIfAbsent stores a value if the key is not present or expired, it returns false if the key is present.
+func (c *SimpleCache) SetIfAbsent(key string, value interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if it, ok := c.items[key]; ok && !now.After(it.expiryTime) {
+		return false
+	}
+	c.items[key] = item{
+		value:      value,
+		expiryTime: now.Add(c.ttl),
+	}
+	return true
+}
+
 // Gets the value for the given key if present and not expired.
 func (c *SimpleCache) Get(key string) (interface{}, bool) {
 	c.mu.Lock()
@@ -0,0 +1,71 @@
+package tonclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	pContract "github.com/xssnick/tonutils-storage-provider/pkg/contract"
+)
+
+// backend is the source of blockchain data: lite servers or recorded fixtures
+type backend interface {
+	// CurrentBlock returns masterchain block all next queries of the call are made on
+	CurrentBlock(ctx context.Context) (block *ton.BlockIDExt, err error)
+	// LastTransaction returns id of the last account transaction
+	LastTransaction(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (lt uint64, hash []byte, err error)
+	// ListTransactions returns up to limit transactions ending with the given one, oldest first
+	ListTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, hash []byte) (txs []*tlb.Transaction, err error)
+	GetStorageInfo(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (info *pContract.StorageDataV1, err error)
+	GetProviders(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (providers []pContract.ProviderDataV1, balance tlb.Coins, err error)
+}
+
+type liteBackend struct {
+	api ton.APIClientWrapped
+}
+
+func (b *liteBackend) CurrentBlock(ctx context.Context) (block *ton.BlockIDExt, err error) {
+	return b.api.GetMasterchainInfo(ctx)
+}
+
+func (b *liteBackend) LastTransaction(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (lt uint64, hash []byte, err error) {
+	account, err := b.api.GetAccount(ctx, block, addr)
+	if err != nil {
+		return
+	}
+
+	lt, hash = account.LastTxLT, account.LastTxHash
+
+	return
+}
+
+func (b *liteBackend) ListTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, hash []byte) (txs []*tlb.Transaction, err error) {
+	return b.api.ListTransactions(ctx, addr, limit, lt, hash)
+}
+
+func (b *liteBackend) GetStorageInfo(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (info *pContract.StorageDataV1, err error) {
+	return pContract.GetStorageInfoV1(ctx, b.api, block, addr)
+}
+
+func (b *liteBackend) GetProviders(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (providers []pContract.ProviderDataV1, balance tlb.Coins, err error) {
+	return pContract.GetProvidersV1(ctx, b.api, block, addr)
+}
+
+func newLiteBackend(ctx context.Context, configUrl string) (b *liteBackend, err error) {
+	clientPool := liteclient.NewConnectionPool()
+
+	err = clientPool.AddConnectionsFromConfigUrl(ctx, configUrl)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to lite servers: %w", err)
+		return
+	}
+
+	b = &liteBackend{
+		api: ton.NewAPIClient(clientPool).WithTimeout(singleQueryTimeout).WithRetry(retries),
+	}
+
+	return
+}
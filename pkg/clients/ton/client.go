@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/xssnick/tonutils-go/address"
-	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	pContract "github.com/xssnick/tonutils-storage-provider/pkg/contract"
@@ -26,8 +25,8 @@ const (
 )
 
 type client struct {
-	clientPool *liteclient.ConnectionPool
-	logger     *slog.Logger
+	chain  backend
+	logger *slog.Logger
 }
 
 type Client interface {
@@ -40,25 +39,22 @@ type Client interface {
 // Not ordered by LT or other fileds, it gets them by batches from lastProcessedLT and newest(or deadline exceeded)
 func (c *client) GetTransactions(ctx context.Context, addr string, lastProcessedLT uint64) (txs []*Transaction, err error) {
 	log := c.logger.With("method", "GetTransactions")
-	api := ton.NewAPIClient(c.clientPool).WithTimeout(singleQueryTimeout).WithRetry(retries)
 	a, _ := address.ParseAddr(addr)
-	block, err := api.GetMasterchainInfo(ctx)
+	block, err := c.chain.CurrentBlock(ctx)
 	if err != nil {
 		err = fmt.Errorf("get masterchain info err: %w", err)
 		return
 	}
 
-	account, err := api.GetAccount(ctx, block, a)
+	lastLT, lastHash, err := c.chain.LastTransaction(ctx, block, a)
 	if err != nil {
 		err = fmt.Errorf("get account err: %w", err)
 		return
 	}
-
-	lastLT, lastHash := account.LastTxLT, account.LastTxHash
 	var transactions []*tlb.Transaction
 list:
 	for {
-		res, errTx := api.ListTransactions(ctx, a, batch, lastLT, lastHash)
+		res, errTx := c.chain.ListTransactions(ctx, a, batch, lastLT, lastHash)
 		if errTx != nil {
 			if errors.Is(errTx, ton.ErrNoTransactionsWereFound) && (len(transactions) > 0) {
 				break
@@ -111,8 +107,7 @@ list:
 // GetStorageContractsInfo interacts with storage contracts to get their info
 func (c *client) GetStorageContractsInfo(ctx context.Context, addrs []string) (contracts []StorageContract, err error) {
 	log := c.logger.With("method", "GetStorageContractsInfo")
-	api := ton.NewAPIClient(c.clientPool).WithTimeout(singleQueryTimeout).WithRetry(retries)
-	block, err := api.GetMasterchainInfo(ctx)
+	block, err := c.chain.CurrentBlock(ctx)
 	if err != nil {
 		err = fmt.Errorf("get masterchain info err: %w", err)
 		return
@@ -126,7 +121,7 @@ func (c *client) GetStorageContractsInfo(ctx context.Context, addrs []string) (c
 			continue
 		}
 
-		info, err := pContract.GetStorageInfoV1(ctx, api, block, addr)
+		info, err := c.chain.GetStorageInfo(ctx, block, addr)
 		if err != nil {
 			log.Error("get storage info", slog.String("address", a), slog.String("error", err.Error()))
 			continue
@@ -151,8 +146,7 @@ func (c *client) GetStorageContractsInfo(ctx context.Context, addrs []string) (c
 
 func (c *client) GetProvidersInfo(ctx context.Context, addrs []string) (contractsProviders []StorageContractProviders, err error) {
 	log := c.logger.With("method", "GetProvidersInfo")
-	api := ton.NewAPIClient(c.clientPool).WithTimeout(singleQueryTimeout).WithRetry(retries)
-	block, err := api.GetMasterchainInfo(ctx)
+	block, err := c.chain.CurrentBlock(ctx)
 	if err != nil {
 		err = fmt.Errorf("get masterchain info err: %w", err)
 		return
@@ -181,7 +175,7 @@ func (c *client) GetProvidersInfo(ctx context.Context, addrs []string) (contract
 			var coins tlb.Coins
 			callErr := utils.TryNTimes(func() error {
 				var cErr error
-				info, coins, cErr = pContract.GetProvidersV1(ctx, api, block, addr)
+				info, coins, cErr = c.chain.GetProviders(ctx, block, addr)
 				return cErr
 			}, getProvidersRetries)
 			if callErr != nil {
@@ -220,6 +214,10 @@ func parseTx(tx *tlb.Transaction) (res *Transaction, ok bool) {
 	var createdAt int64
 
 	in := tx.IO.In
+	if in == nil {
+		return
+	}
+
 	switch in.MsgType {
 	case tlb.MsgTypeInternal:
 		{
@@ -264,16 +262,46 @@ func parseTx(tx *tlb.Transaction) (res *Transaction, ok bool) {
 	return
 }
 
+// NewClient creates client working with lite servers from the global config
 func NewClient(ctx context.Context, configUrl string, logger *slog.Logger) (Client, error) {
-	clientPool := liteclient.NewConnectionPool()
+	chain, err := newLiteBackend(ctx, configUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		chain:  chain,
+		logger: logger,
+	}, nil
+}
+
+// NewRecordingClient creates client working with lite servers, it saves all responses to fixtures in dir
+func NewRecordingClient(ctx context.Context, configUrl string, dir string, logger *slog.Logger) (Client, error) {
+	chain, err := newLiteBackend(ctx, configUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := newRecorder(chain, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		chain:  rec,
+		logger: logger,
+	}, nil
+}
 
-	err := clientPool.AddConnectionsFromConfigUrl(ctx, configUrl)
+// NewReplayClient creates client answering from fixtures in dir without lite servers, it is used in tests
+func NewReplayClient(dir string, logger *slog.Logger) (Client, error) {
+	chain, err := newReplay(dir)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	return &client{
-		clientPool: clientPool,
-		logger:     logger,
+		chain:  chain,
+		logger: logger,
 	}, nil
 }
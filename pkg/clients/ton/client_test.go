@@ -2,22 +2,219 @@ package tonclient
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	pContract "github.com/xssnick/tonutils-storage-provider/pkg/contract"
 )
 
-// Test example
-func Test_GetTransactions(t *testing.T) {
+const masterAddr = "UQB3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d0x0"
+
+// memoryChain is backend keeping accounts data in memory
+type memoryChain struct {
+	// newest first
+	txs       map[string][]*tlb.Transaction
+	storage   map[string]*pContract.StorageDataV1
+	providers map[string][]pContract.ProviderDataV1
+	balances  map[string]tlb.Coins
+}
+
+func newMemoryChain() *memoryChain {
+	return &memoryChain{
+		txs:       map[string][]*tlb.Transaction{},
+		storage:   map[string]*pContract.StorageDataV1{},
+		providers: map[string][]pContract.ProviderDataV1{},
+		balances:  map[string]tlb.Coins{},
+	}
+}
+
+func txHash(lt uint64) []byte {
+	h := sha256.Sum256(binary.BigEndian.AppendUint64(nil, lt))
+	return h[:]
+}
+
+// addTx adds internal message transaction, transactions are added from the oldest one
+func (m *memoryChain) addTx(to *address.Address, lt uint64, from *address.Address, body *cell.Cell) {
+	key := fixtureKey(to)
+	tx := &tlb.Transaction{LT: lt, Hash: txHash(lt)}
+	if prev := m.txs[key]; len(prev) > 0 {
+		tx.PrevTxLT, tx.PrevTxHash = prev[0].LT, prev[0].Hash
+	}
+
+	tx.IO.In = &tlb.Message{
+		MsgType: tlb.MsgTypeInternal,
+		Msg: &tlb.InternalMessage{
+			SrcAddr:   from,
+			DstAddr:   to,
+			CreatedAt: uint32(1_700_000_000 + lt),
+			Body:      body,
+		},
+	}
+
+	m.txs[key] = append([]*tlb.Transaction{tx}, m.txs[key]...)
+}
+
+func (m *memoryChain) CurrentBlock(context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{}, nil
+}
+
+func (m *memoryChain) LastTransaction(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (uint64, []byte, error) {
+	txs := m.txs[fixtureKey(addr)]
+	if len(txs) == 0 {
+		return 0, nil, nil
+	}
+
+	return txs[0].LT, txs[0].Hash, nil
+}
+
+func (m *memoryChain) ListTransactions(_ context.Context, addr *address.Address, limit uint32, lt uint64, _ []byte) ([]*tlb.Transaction, error) {
+	var res []*tlb.Transaction
+	for _, tx := range m.txs[fixtureKey(addr)] {
+		if tx.LT <= lt && uint32(len(res)) < limit {
+			res = append([]*tlb.Transaction{tx}, res...)
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, ton.ErrNoTransactionsWereFound
+	}
+
+	return res, nil
+}
+
+func (m *memoryChain) GetStorageInfo(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (*pContract.StorageDataV1, error) {
+	return m.storage[fixtureKey(addr)], nil
+}
+
+func (m *memoryChain) GetProviders(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) ([]pContract.ProviderDataV1, tlb.Coins, error) {
+	key := fixtureKey(addr)
+	if _, ok := m.balances[key]; !ok {
+		return nil, tlb.ZeroCoins, pContract.ErrNotDeployed
+	}
+
+	return m.providers[key], m.balances[key], nil
+}
+
+func testAddress(n byte) *address.Address {
+	data := make([]byte, 32)
+	data[0] = n
+
+	return address.NewAddress(0, 0, data)
+}
+
+func comment(text string) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(0, 32).MustStoreStringSnake(text).EndCell()
+}
+
+func Test_RecordReplay(t *testing.T) {
 	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	master := address.MustParseAddr(masterAddr)
+	contract := testAddress(1)
+	undeployed := testAddress(2)
+
+	chain := newMemoryChain()
+	for lt := uint64(1); lt <= 250; lt++ {
+		chain.addTx(master, lt*10, testAddress(byte(lt)), comment("tsp-"+string(rune('a'+lt%26))))
+	}
+	chain.storage[fixtureKey(contract)] = &pContract.StorageDataV1{
+		TorrentHash: txHash(1),
+		Size:        1 << 20,
+		ChunkSize:   128 << 10,
+		OwnerAddr:   testAddress(3),
+		MerkleHash:  txHash(2),
+	}
+	chain.balances[fixtureKey(contract)] = tlb.MustFromTON("1.5")
+	chain.providers[fixtureKey(contract)] = []pContract.ProviderDataV1{{
+		Key:         txHash(3),
+		LastProofAt: time.Unix(1_700_000_000, 0),
+		ByteToProof: 12345,
+		MaxSpan:     86400,
+		RatePerMB:   tlb.MustFromTON("0.001"),
+		Nonce:       7,
+	}}
+
+	dir := t.TempDir()
+	rec, err := newRecorder(chain, dir)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+
+	recorded := &client{chain: rec, logger: logger}
+
+	wantTxs, err := recorded.GetTransactions(ctx, masterAddr, 5)
+	if err != nil {
+		t.Fatalf("failed to get transactions: %v", err)
+	}
+	if len(wantTxs) != 250 {
+		t.Fatalf("got %d transactions, want 250", len(wantTxs))
+	}
+
+	addrs := []string{contract.String(), undeployed.String()}
+	wantInfo, err := recorded.GetStorageContractsInfo(ctx, addrs)
+	if err != nil {
+		t.Fatalf("failed to get storage contracts info: %v", err)
+	}
+
+	wantProviders, err := recorded.GetProvidersInfo(ctx, addrs[:1])
+	if err != nil {
+		t.Fatalf("failed to get providers info: %v", err)
+	}
 
-	logger := slog.Default()
+	replayed, err := NewReplayClient(dir, logger)
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+
+	gotTxs, err := replayed.GetTransactions(ctx, masterAddr, 5)
+	if err != nil {
+		t.Fatalf("failed to replay transactions: %v", err)
+	}
+	if !reflect.DeepEqual(gotTxs, wantTxs) {
+		t.Fatalf("replayed transactions differ from recorded ones")
+	}
+
+	gotInfo, err := replayed.GetStorageContractsInfo(ctx, addrs)
+	if err != nil {
+		t.Fatalf("failed to replay storage contracts info: %v", err)
+	}
+	if !reflect.DeepEqual(gotInfo, wantInfo) {
+		t.Fatalf("storage info = %+v, want %+v", gotInfo, wantInfo)
+	}
+
+	gotProviders, err := replayed.GetProvidersInfo(ctx, addrs[:1])
+	if err != nil {
+		t.Fatalf("failed to replay providers info: %v", err)
+	}
+
+	if !reflect.DeepEqual(gotProviders, wantProviders) {
+		t.Fatalf("providers = %+v, want %+v", gotProviders, wantProviders)
+	}
+}
+
+func Test_GetTransactions(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	client, err := NewClient(ctx, "https://ton-blockchain.github.io/testnet-global.config.json", logger)
+	client, err := NewReplayClient("testdata/synthetic", logger)
 	if err != nil {
 		t.Fatalf("failed to create client: %v", err)
 	}
 
-	tx, err := client.GetTransactions(ctx, "UQB3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d0x0", 5)
+	tx, err := client.GetTransactions(ctx, masterAddr, 5)
 	if err != nil {
 		t.Fatalf("GetTransactions failed: %v", err)
 	}
@@ -25,4 +222,96 @@ func Test_GetTransactions(t *testing.T) {
 	if len(tx) == 0 {
 		t.Fatal("expected non-empty transaction list")
 	}
+
+	// addresses read from chain are bounceable whatever form the wallet is configured in
+	for _, tx := range tx {
+		if tx.To != address.MustParseAddr(masterAddr).Bounce(true).String() {
+			t.Fatalf("unexpected transaction destination: %s", tx.To)
+		}
+	}
+}
+
+// chainMessage serializes message and loads it back, as lite servers responses are parsed
+func chainMessage(t *testing.T, msg any) *tlb.Message {
+	t.Helper()
+
+	c, err := tlb.ToCell(msg)
+	if err != nil {
+		t.Fatalf("failed to serialize message: %v", err)
+	}
+
+	m := &tlb.Message{}
+	if err = m.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatalf("failed to load message: %v", err)
+	}
+
+	return m
+}
+
+func Test_TxFixtureRoundTrip(t *testing.T) {
+	// non-bounceable testnet form, flags are not stored in messages
+	provider := address.MustParseAddr(masterAddr).Testnet(true)
+	master := address.MustParseAddr(masterAddr)
+
+	registration := comment("tsp-" + hex.EncodeToString(txHash(1)))
+	long := comment(strings.Repeat("storage provider ", 20))
+	withdrawal := cell.BeginCell().MustStoreUInt(0xa91baf56, 32).MustStoreUInt(0, 64).EndCell()
+
+	internal := func(body *cell.Cell) *tlb.InternalMessage {
+		return &tlb.InternalMessage{
+			Bounce:    true,
+			SrcAddr:   provider,
+			DstAddr:   master,
+			Amount:    tlb.MustFromTON("0.05"),
+			CreatedLT: 100,
+			CreatedAt: 1_700_000_000,
+			Body:      body,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		in     *tlb.Message
+		parsed bool
+	}{
+		{name: "registration comment", in: chainMessage(t, internal(registration)), parsed: true},
+		{name: "comment in cells chain", in: chainMessage(t, internal(long)), parsed: true},
+		{name: "reward withdrawal", in: chainMessage(t, internal(withdrawal)), parsed: true},
+		{name: "empty body", in: chainMessage(t, internal(nil)), parsed: true},
+		{name: "external", in: chainMessage(t, &tlb.ExternalMessage{DstAddr: master, Body: registration})},
+		{name: "no inbound message"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lt := uint64(i + 1)
+			tx := &tlb.Transaction{LT: lt, Hash: txHash(lt), PrevTxLT: lt - 1, PrevTxHash: txHash(lt - 1)}
+			tx.IO.In = tt.in
+
+			data, err := json.Marshal(newTxFixture(tx))
+			if err != nil {
+				t.Fatalf("failed to marshal fixture: %v", err)
+			}
+
+			var f txFixture
+			if err = json.Unmarshal(data, &f); err != nil {
+				t.Fatalf("failed to unmarshal fixture: %v", err)
+			}
+
+			replayed, err := f.transaction()
+			if err != nil {
+				t.Fatalf("failed to restore transaction: %v", err)
+			}
+
+			want, wantOK := parseTx(tx)
+			got, gotOK := parseTx(replayed)
+			if wantOK != tt.parsed || gotOK != wantOK || !reflect.DeepEqual(got, want) {
+				t.Fatalf("replayed = %+v (%t), want %+v (%t)", got, gotOK, want, wantOK)
+			}
+
+			if tt.parsed && got.From != provider.Bounce(true).Testnet(false).String() {
+				t.Fatalf("source = %s, want bounceable mainnet form", got.From)
+			}
+		})
+	}
 }
@@ -0,0 +1,478 @@
+package tonclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	pContract "github.com/xssnick/tonutils-storage-provider/pkg/contract"
+)
+
+// ErrNoFixture is returned by replay client for data that was never recorded
+var ErrNoFixture = errors.New("no recorded response")
+
+// fixture is recorded blockchain data of one account, stored as <workchain>_<hex address>.json
+type fixture struct {
+	Address    string `json:"address"`
+	LastTxLT   uint64 `json:"last_tx_lt,omitempty"`
+	LastTxHash []byte `json:"last_tx_hash,omitempty"`
+	// newest first
+	Transactions []txFixture       `json:"transactions,omitempty"`
+	StorageInfo  *storageFixture   `json:"storage_info,omitempty"`
+	Providers    *providersFixture `json:"providers,omitempty"`
+}
+
+// txFixture keeps transaction fields the client reads
+type txFixture struct {
+	LT       uint64      `json:"lt"`
+	Hash     []byte      `json:"hash"`
+	PrevLT   uint64      `json:"prev_lt"`
+	PrevHash []byte      `json:"prev_hash"`
+	In       *msgFixture `json:"in,omitempty"`
+}
+
+type msgFixture struct {
+	Type      tlb.MsgType `json:"type"`
+	From      string      `json:"from,omitempty"`
+	To        string      `json:"to,omitempty"`
+	CreatedAt uint32      `json:"created_at,omitempty"`
+	// BOC of the message body
+	Body []byte `json:"body,omitempty"`
+}
+
+type storageFixture struct {
+	TorrentHash []byte `json:"torrent_hash"`
+	Size        uint64 `json:"size"`
+	ChunkSize   uint64 `json:"chunk_size"`
+	OwnerAddr   string `json:"owner_addr"`
+	MerkleHash  []byte `json:"merkle_hash"`
+}
+
+type providersFixture struct {
+	// nanotons
+	Balance string            `json:"balance"`
+	List    []providerFixture `json:"list"`
+}
+
+type providerFixture struct {
+	Key         []byte `json:"key"`
+	LastProofAt int64  `json:"last_proof_at"`
+	ByteToProof uint64 `json:"byte_to_proof"`
+	MaxSpan     uint32 `json:"max_span"`
+	// nanotons
+	RatePerMB string `json:"rate_per_mb"`
+	Nonce     uint64 `json:"nonce"`
+}
+
+func fixtureKey(addr *address.Address) string {
+	return fmt.Sprintf("%d_%x", addr.Workchain(), addr.Data())
+}
+
+func newTxFixture(tx *tlb.Transaction) (f txFixture) {
+	f = txFixture{
+		LT:       tx.LT,
+		Hash:     tx.Hash,
+		PrevLT:   tx.PrevTxLT,
+		PrevHash: tx.PrevTxHash,
+	}
+
+	in := tx.IO.In
+	if in == nil {
+		return
+	}
+
+	f.In = &msgFixture{Type: in.MsgType}
+	if msg, ok := in.Msg.(*tlb.InternalMessage); ok {
+		f.In.From = msg.SrcAddr.String()
+		f.In.To = msg.DstAddr.String()
+		f.In.CreatedAt = msg.CreatedAt
+		if msg.Body != nil {
+			f.In.Body = msg.Body.ToBOC()
+		}
+	}
+
+	return
+}
+
+func (f txFixture) transaction() (tx *tlb.Transaction, err error) {
+	tx = &tlb.Transaction{
+		LT:         f.LT,
+		Hash:       f.Hash,
+		PrevTxLT:   f.PrevLT,
+		PrevTxHash: f.PrevHash,
+	}
+
+	if f.In == nil {
+		return
+	}
+
+	tx.IO.In = &tlb.Message{MsgType: f.In.Type}
+	if f.In.Type != tlb.MsgTypeInternal {
+		return
+	}
+
+	msg := &tlb.InternalMessage{CreatedAt: f.In.CreatedAt}
+	if msg.SrcAddr, err = address.ParseAddr(f.In.From); err != nil {
+		err = fmt.Errorf("invalid source address of tx %d: %w", f.LT, err)
+		return
+	}
+	if msg.DstAddr, err = address.ParseAddr(f.In.To); err != nil {
+		err = fmt.Errorf("invalid destination address of tx %d: %w", f.LT, err)
+		return
+	}
+	if len(f.In.Body) > 0 {
+		if msg.Body, err = cell.FromBOC(f.In.Body); err != nil {
+			err = fmt.Errorf("invalid body of tx %d: %w", f.LT, err)
+			return
+		}
+	}
+	tx.IO.In.Msg = msg
+
+	return
+}
+
+func newStorageFixture(info *pContract.StorageDataV1) *storageFixture {
+	f := &storageFixture{
+		TorrentHash: info.TorrentHash,
+		Size:        info.Size,
+		ChunkSize:   info.ChunkSize,
+		MerkleHash:  info.MerkleHash,
+	}
+	if info.OwnerAddr != nil {
+		f.OwnerAddr = info.OwnerAddr.String()
+	}
+
+	return f
+}
+
+func (f *storageFixture) storageInfo() (info *pContract.StorageDataV1, err error) {
+	owner, err := address.ParseAddr(f.OwnerAddr)
+	if err != nil {
+		err = fmt.Errorf("invalid owner address: %w", err)
+		return
+	}
+
+	info = &pContract.StorageDataV1{
+		TorrentHash: f.TorrentHash,
+		Size:        f.Size,
+		ChunkSize:   f.ChunkSize,
+		OwnerAddr:   owner,
+		MerkleHash:  f.MerkleHash,
+	}
+
+	return
+}
+
+func newProvidersFixture(providers []pContract.ProviderDataV1, balance tlb.Coins) *providersFixture {
+	f := &providersFixture{
+		Balance: balance.Nano().String(),
+		List:    make([]providerFixture, 0, len(providers)),
+	}
+
+	for _, p := range providers {
+		f.List = append(f.List, providerFixture{
+			Key:         p.Key,
+			LastProofAt: p.LastProofAt.Unix(),
+			ByteToProof: p.ByteToProof,
+			MaxSpan:     p.MaxSpan,
+			RatePerMB:   p.RatePerMB.Nano().String(),
+			Nonce:       p.Nonce,
+		})
+	}
+
+	return f
+}
+
+func (f *providersFixture) providers() (providers []pContract.ProviderDataV1, balance tlb.Coins, err error) {
+	nano, ok := new(big.Int).SetString(f.Balance, 10)
+	if !ok {
+		err = fmt.Errorf("invalid balance: %s", f.Balance)
+		return
+	}
+	balance = tlb.FromNanoTON(nano)
+
+	providers = make([]pContract.ProviderDataV1, 0, len(f.List))
+	for _, p := range f.List {
+		rate, ok := new(big.Int).SetString(p.RatePerMB, 10)
+		if !ok {
+			err = fmt.Errorf("invalid rate per mb: %s", p.RatePerMB)
+			return
+		}
+
+		providers = append(providers, pContract.ProviderDataV1{
+			Key:         p.Key,
+			LastProofAt: time.Unix(p.LastProofAt, 0),
+			ByteToProof: p.ByteToProof,
+			MaxSpan:     p.MaxSpan,
+			RatePerMB:   tlb.FromNanoTON(rate),
+			Nonce:       p.Nonce,
+		})
+	}
+
+	return
+}
+
+// replay answers from fixtures loaded on start
+type replay struct {
+	fixtures map[string]*fixture
+}
+
+func (r *replay) get(addr *address.Address) (f *fixture, err error) {
+	f, ok := r.fixtures[fixtureKey(addr)]
+	if !ok {
+		err = fmt.Errorf("%w for %s", ErrNoFixture, addr.String())
+	}
+
+	return
+}
+
+func (r *replay) CurrentBlock(context.Context) (block *ton.BlockIDExt, err error) {
+	return &ton.BlockIDExt{Workchain: address.MasterchainID}, nil
+}
+
+func (r *replay) LastTransaction(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (lt uint64, hash []byte, err error) {
+	f, err := r.get(addr)
+	if err != nil {
+		return
+	}
+
+	if f.LastTxLT == 0 {
+		err = fmt.Errorf("%w: account state of %s", ErrNoFixture, addr.String())
+		return
+	}
+
+	lt, hash = f.LastTxLT, f.LastTxHash
+
+	return
+}
+
+func (r *replay) ListTransactions(_ context.Context, addr *address.Address, limit uint32, lt uint64, hash []byte) (txs []*tlb.Transaction, err error) {
+	f, err := r.get(addr)
+	if err != nil {
+		return
+	}
+
+	start := -1
+	for i, tx := range f.Transactions {
+		if tx.LT == lt && bytes.Equal(tx.Hash, hash) {
+			start = i
+			break
+		}
+	}
+
+	if start < 0 {
+		err = ton.ErrNoTransactionsWereFound
+		return
+	}
+
+	end := min(start+int(limit), len(f.Transactions))
+	txs = make([]*tlb.Transaction, 0, end-start)
+	for i := end - 1; i >= start; i-- {
+		tx, tErr := f.Transactions[i].transaction()
+		if tErr != nil {
+			err = tErr
+			return
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return
+}
+
+func (r *replay) GetStorageInfo(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (info *pContract.StorageDataV1, err error) {
+	f, err := r.get(addr)
+	if err != nil {
+		return
+	}
+
+	if f.StorageInfo == nil {
+		err = fmt.Errorf("%w: storage info of %s", ErrNoFixture, addr.String())
+		return
+	}
+
+	return f.StorageInfo.storageInfo()
+}
+
+func (r *replay) GetProviders(_ context.Context, _ *ton.BlockIDExt, addr *address.Address) (providers []pContract.ProviderDataV1, balance tlb.Coins, err error) {
+	f, err := r.get(addr)
+	if err != nil {
+		return
+	}
+
+	if f.Providers == nil {
+		err = fmt.Errorf("%w: providers of %s", ErrNoFixture, addr.String())
+		return
+	}
+
+	return f.Providers.providers()
+}
+
+func newReplay(dir string) (r *replay, err error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return
+	}
+
+	r = &replay{fixtures: make(map[string]*fixture, len(files))}
+	for _, name := range files {
+		data, rErr := os.ReadFile(name)
+		if rErr != nil {
+			err = fmt.Errorf("failed to read fixture: %w", rErr)
+			return
+		}
+
+		f := &fixture{}
+		if uErr := json.Unmarshal(data, f); uErr != nil {
+			err = fmt.Errorf("failed to parse fixture %s: %w", name, uErr)
+			return
+		}
+
+		addr, pErr := address.ParseAddr(f.Address)
+		if pErr != nil {
+			err = fmt.Errorf("invalid address in fixture %s: %w", name, pErr)
+			return
+		}
+
+		r.fixtures[fixtureKey(addr)] = f
+	}
+
+	return
+}
+
+// recorder passes queries to the backend and saves successful responses to fixtures
+type recorder struct {
+	backend
+	dir string
+
+	mu       sync.Mutex
+	fixtures map[string]*fixture
+}
+
+func (r *recorder) LastTransaction(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (lt uint64, hash []byte, err error) {
+	lt, hash, err = r.backend.LastTransaction(ctx, block, addr)
+	if err != nil {
+		return
+	}
+
+	err = r.save(addr, func(f *fixture) {
+		f.LastTxLT, f.LastTxHash = lt, hash
+	})
+
+	return
+}
+
+func (r *recorder) ListTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, hash []byte) (txs []*tlb.Transaction, err error) {
+	txs, err = r.backend.ListTransactions(ctx, addr, limit, lt, hash)
+	if err != nil {
+		return
+	}
+
+	err = r.save(addr, func(f *fixture) {
+		known := make(map[uint64]struct{}, len(f.Transactions))
+		for _, tx := range f.Transactions {
+			known[tx.LT] = struct{}{}
+		}
+
+		for _, tx := range txs {
+			if _, ok := known[tx.LT]; !ok {
+				f.Transactions = append(f.Transactions, newTxFixture(tx))
+			}
+		}
+
+		sort.Slice(f.Transactions, func(i, j int) bool {
+			return f.Transactions[i].LT > f.Transactions[j].LT
+		})
+	})
+
+	return
+}
+
+func (r *recorder) GetStorageInfo(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (info *pContract.StorageDataV1, err error) {
+	info, err = r.backend.GetStorageInfo(ctx, block, addr)
+	if err != nil || info == nil {
+		return
+	}
+
+	err = r.save(addr, func(f *fixture) {
+		f.StorageInfo = newStorageFixture(info)
+	})
+
+	return
+}
+
+func (r *recorder) GetProviders(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (providers []pContract.ProviderDataV1, balance tlb.Coins, err error) {
+	providers, balance, err = r.backend.GetProviders(ctx, block, addr)
+	if err != nil {
+		return
+	}
+
+	err = r.save(addr, func(f *fixture) {
+		f.Providers = newProvidersFixture(providers, balance)
+	})
+
+	return
+}
+
+// save applies update to the account fixture and rewrites its file
+func (r *recorder) save(addr *address.Address, update func(f *fixture)) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fixtureKey(addr)
+	f, ok := r.fixtures[key]
+	if !ok {
+		f = &fixture{Address: addr.String()}
+		r.fixtures[key] = f
+	}
+
+	update(f)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal fixture: %w", err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(r.dir, key+".json"), data, 0o644)
+	if err != nil {
+		err = fmt.Errorf("failed to write fixture: %w", err)
+		return
+	}
+
+	return
+}
+
+// newRecorder continues recording over fixtures already saved in dir
+func newRecorder(b backend, dir string) (r *recorder, err error) {
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		err = fmt.Errorf("failed to create fixtures dir: %w", err)
+		return
+	}
+
+	saved, err := newReplay(dir)
+	if err != nil {
+		return
+	}
+
+	r = &recorder{
+		backend:  b,
+		dir:      dir,
+		fixtures: saved.fixtures,
+	}
+
+	return
+}
@@ -0,0 +1,208 @@
+package tonclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// mainnetFixtures are accounts recorded from mainnet lite servers by Test_RecordMainnetSnapshot,
+// mainnetSnapshot is what the client returned from them while recording
+const (
+	mainnetFixtures = "testdata/mainnet"
+	mainnetSnapshot = "testdata/mainnet.json"
+
+	// accounts of snapshot are limited to keep fixtures small
+	mainnetProviders = 2
+	mainnetContracts = 2
+
+	// the same as providers master worker looks for
+	registrationPrefix            = "tsp-"
+	storageRewardWithdrawalOpCode = 0xa91baf56
+)
+
+type chainSnapshot struct {
+	FromLT    uint64            `json:"from_lt"`
+	Master    []snapshotTx      `json:"master"`
+	Providers []snapshotWallet  `json:"providers"`
+	Contracts []StorageContract `json:"contracts"`
+	Balances  []snapshotBalance `json:"balances"`
+}
+
+type snapshotTx struct {
+	LT        uint64 `json:"lt"`
+	Op        uint64 `json:"op"`
+	From      string `json:"from"`
+	Message   string `json:"message"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type snapshotWallet struct {
+	Address      string       `json:"address"`
+	Transactions []snapshotTx `json:"transactions"`
+}
+
+type snapshotBalance struct {
+	Address         string   `json:"address"`
+	Balance         uint64   `json:"balance"`
+	Providers       []string `json:"providers"`
+	LiteServerError bool     `json:"lite_server_error"`
+}
+
+func newSnapshotTxs(txs []*Transaction) []snapshotTx {
+	res := make([]snapshotTx, 0, len(txs))
+	for _, tx := range txs {
+		res = append(res, snapshotTx{LT: tx.LT, Op: tx.Op, From: tx.From, Message: tx.Message, CreatedAt: tx.CreatedAt.Unix()})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].LT < res[j].LT })
+
+	return res
+}
+
+// collectSnapshot reads master wallet transactions after fromLT, transactions of first registered
+// providers after fromLT and storage info and balances of first contracts paying them rewards
+func collectSnapshot(t *testing.T, c Client, fromLT uint64) (s chainSnapshot) {
+	t.Helper()
+
+	ctx := context.Background()
+	s.FromLT = fromLT
+
+	txs, err := c.GetTransactions(ctx, masterAddr, fromLT)
+	if err != nil {
+		t.Fatalf("failed to get master transactions: %v", err)
+	}
+	s.Master = newSnapshotTxs(txs)
+
+	var wallets []string
+	for _, tx := range s.Master {
+		if len(wallets) == mainnetProviders {
+			break
+		}
+
+		if tx.LT > fromLT && strings.Contains(tx.Message, registrationPrefix) && !slices.Contains(wallets, tx.From) {
+			wallets = append(wallets, tx.From)
+		}
+	}
+
+	var contracts []string
+	s.Providers = make([]snapshotWallet, 0, len(wallets))
+	for _, addr := range wallets {
+		txs, err := c.GetTransactions(ctx, addr, fromLT)
+		if err != nil {
+			t.Fatalf("failed to get provider %s transactions: %v", addr, err)
+		}
+
+		w := snapshotWallet{Address: addr, Transactions: newSnapshotTxs(txs)}
+		for _, tx := range w.Transactions {
+			if len(contracts) < mainnetContracts && tx.Op == storageRewardWithdrawalOpCode && !slices.Contains(contracts, tx.From) {
+				contracts = append(contracts, tx.From)
+			}
+		}
+		s.Providers = append(s.Providers, w)
+	}
+
+	s.Contracts, err = c.GetStorageContractsInfo(ctx, contracts)
+	if err != nil {
+		t.Fatalf("failed to get storage contracts info: %v", err)
+	}
+
+	balances, err := c.GetProvidersInfo(ctx, contracts)
+	if err != nil {
+		t.Fatalf("failed to get providers info: %v", err)
+	}
+
+	s.Balances = make([]snapshotBalance, 0, len(balances))
+	for _, b := range balances {
+		sb := snapshotBalance{Address: b.Address, Balance: b.Balance, Providers: []string{}, LiteServerError: b.LiteServerError}
+		for _, p := range b.Providers {
+			sb.Providers = append(sb.Providers, hex.EncodeToString([]byte(p.Key)))
+		}
+		s.Balances = append(s.Balances, sb)
+	}
+
+	sort.Slice(s.Balances, func(i, j int) bool { return s.Balances[i].Address < s.Balances[j].Address })
+
+	return
+}
+
+// Test_RecordMainnetSnapshot records mainnet fixtures and the snapshot, run it with
+// TON_FIXTURES_RECORD=<global config url> and TON_FIXTURES_FROM_LT of the master wallet
+// a few registrations before its last transaction
+func Test_RecordMainnetSnapshot(t *testing.T) {
+	configURL := os.Getenv("TON_FIXTURES_RECORD")
+	if configURL == "" {
+		t.Skip("TON_FIXTURES_RECORD is not set")
+	}
+
+	fromLT, err := strconv.ParseUint(os.Getenv("TON_FIXTURES_FROM_LT"), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid TON_FIXTURES_FROM_LT: %v", err)
+	}
+
+	if err = os.RemoveAll(mainnetFixtures); err != nil {
+		t.Fatalf("failed to remove old fixtures: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c, err := NewRecordingClient(context.Background(), configURL, mainnetFixtures, logger)
+	if err != nil {
+		t.Fatalf("failed to create recording client: %v", err)
+	}
+
+	s := collectSnapshot(t, c, fromLT)
+	if len(s.Providers) == 0 || len(s.Contracts) == 0 {
+		t.Fatalf("no providers or contracts after lt %d, pick earlier TON_FIXTURES_FROM_LT", fromLT)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal snapshot: %v", err)
+	}
+
+	if err = os.WriteFile(mainnetSnapshot, data, 0o644); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+}
+
+// Test_MainnetSnapshot is skipped until the snapshot is recorded with Test_RecordMainnetSnapshot
+func Test_MainnetSnapshot(t *testing.T) {
+	data, err := os.ReadFile(mainnetSnapshot)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skip("mainnet snapshot is not recorded, see Test_RecordMainnetSnapshot")
+	}
+	if err != nil {
+		t.Fatalf("failed to read snapshot: %v", err)
+	}
+
+	var want chainSnapshot
+	if err = json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("failed to parse snapshot: %v", err)
+	}
+
+	c, err := NewReplayClient(mainnetFixtures, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+
+	got := collectSnapshot(t, c, want.FromLT)
+
+	gotData, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal snapshot: %v", err)
+	}
+
+	if !bytes.Equal(bytes.TrimSpace(data), gotData) {
+		t.Fatalf("replayed snapshot differs from recorded one:\n%s", gotData)
+	}
+}
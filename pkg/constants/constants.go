@@ -2,21 +2,43 @@ package constants
 
 // Sorting constants
 const (
-	PubKeyColumn      = "p.public_key"
-	UptimeColumn      = "p.uptime"
-	WorkingTimeColumn = "p.registered_at"
-	RatingColumn      = "p.rating"
-	PriceColumn       = "p.rate_per_mb_per_day"
-	LocationColumn    = "(p.ip_info->>'country' || ' (' || COALESCE(p.ip_info->>'country_iso', '') || ')', p.rating)"
+	PubKeyColumn       = "p.public_key"
+	UptimeColumn       = "p.uptime"
+	Uptime24hColumn    = "p.uptime_24h"
+	Uptime7dColumn     = "p.uptime_7d"
+	Uptime30dColumn    = "p.uptime_30d"
+	WorkingTimeColumn  = "p.registered_at"
+	RatingColumn       = "p.rating"
+	PriceColumn        = "p.rate_per_mb_per_day"
+	RetrievalP50Column = "p.retrieval_p50_ms"
+	RetrievalP95Column = "p.retrieval_p95_ms"
+	RTTP50Column       = "p.rtt_p50_ms"
+	RTTP95Column       = "p.rtt_p95_ms"
+	LocationColumn     = "(p.ip_info->>'country' || ' (' || COALESCE(p.ip_info->>'country_iso', '') || ')', p.rating)"
 )
 
 var SortingMap = map[string]string{
-	"pubkey":      PubKeyColumn,
-	"uptime":      UptimeColumn,
-	"workingtime": WorkingTimeColumn,
-	"rating":      RatingColumn,
-	"price":       PriceColumn,
-	"location":    LocationColumn,
+	"pubkey":       PubKeyColumn,
+	"uptime":       UptimeColumn,
+	"uptime24h":    Uptime24hColumn,
+	"uptime7d":     Uptime7dColumn,
+	"uptime30d":    Uptime30dColumn,
+	"workingtime":  WorkingTimeColumn,
+	"rating":       RatingColumn,
+	"price":        PriceColumn,
+	"retrievalp50": RetrievalP50Column,
+	"retrievalp95": RetrievalP95Column,
+	"rttp50":       RTTP50Column,
+	"rttp95":       RTTP95Column,
+	"location":     LocationColumn,
+}
+
+// Uptime window used by rating, "all" is uptime over the whole statuses history
+var UptimeWindowsMap = map[string]string{
+	"all": "uptime",
+	"24h": "uptime_24h",
+	"7d":  "uptime_7d",
+	"30d": "uptime_30d",
 }
 
 // Order constants
@@ -30,6 +52,32 @@ var OrderMap = map[string]string{
 	"desc": Desc,
 }
 
+// Webhook event types
+const (
+	ProviderOnlineEvent        = "provider.online"
+	ProviderOfflineEvent       = "provider.offline"
+	ProviderStatusChangedEvent = "provider.status_changed"
+	ProviderPriceChangedEvent  = "provider.price_changed"
+	ContractRejectedEvent      = "contract.rejected"
+)
+
+// Audit statuses
+const (
+	AuditPending = "pending"
+	AuditRunning = "running"
+	AuditDone    = "done"
+	AuditFailed  = "failed"
+)
+
+// Storage rates query error classes saved with provider status
+const (
+	RatesTimeout         = "timeout"
+	RatesNotFound        = "not_found" // provider is not in DHT
+	RatesRefused         = "refused"   // provider is in DHT but connection failed
+	RatesInvalidResponse = "invalid_response"
+	RatesUnknownError    = "unknown"
+)
+
 type ReasonCode uint32
 
 const (
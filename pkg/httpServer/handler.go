@@ -13,9 +13,36 @@ type providers interface {
 	SearchProviders(ctx context.Context, req v1.SearchProvidersRequest) ([]v1.Provider, error)
 	GetLatestTelemetry(ctx context.Context) (providers []interface{}, err error)
 	GetFiltersRange(ctx context.Context) (filtersRange v1.FiltersRangeResp, err error)
-	UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte) (err error)
-	UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest) (err error)
+	UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte, sign v1.PayloadSignature) (err error)
+	UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest, rawBody []byte, sign v1.PayloadSignature) (err error)
 	GetStorageContractsChecks(ctx context.Context, req v1.ContractsStatusesRequest) ([]v1.ContractCheck, error)
+	GetStorageContractsHistory(ctx context.Context, req v1.ContractsHistoryRequest) (resp v1.ContractsHistoryResponse, err error)
+	GetBag(ctx context.Context, bagID string) (resp v1.BagResponse, err error)
+	GetOwnerContracts(ctx context.Context, owner string, req v1.OwnerContractsRequest) (resp v1.OwnerContractsResponse, err error)
+	GetProviderContracts(ctx context.Context, pubkey string, req v1.ProviderContractsRequest) (resp v1.ProviderContractsResponse, err error)
+	GetProvider(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderResponse, err error)
+	GetProviderRating(ctx context.Context, pubkey string) (resp v1.ProviderRatingResponse, err error)
+	GetProviderPrices(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderPricesResponse, err error)
+	GetNetworkPrices(ctx context.Context, req v1.ProviderHistoryRequest) (resp v1.NetworkPricesResponse, err error)
+	GetQuote(ctx context.Context, req v1.QuoteRequest) (resp v1.QuoteResponse, err error)
+	GetStats(ctx context.Context) (resp v1.StatsResponse, err error)
+}
+
+type webhooks interface {
+	AddSubscription(ctx context.Context, req v1.WebhookSubscriptionRequest) (resp v1.WebhookSubscription, err error)
+	GetSubscriptions(ctx context.Context) (resp v1.WebhookSubscriptionsResponse, err error)
+	DeleteSubscription(ctx context.Context, id int64) (err error)
+}
+
+type system interface {
+	GetJobs(ctx context.Context) (resp v1.JobsResponse, err error)
+	RunJob(ctx context.Context, name string) (err error)
+	GetStatsHistory(ctx context.Context, req v1.StatsHistoryRequest) (resp v1.StatsHistoryResponse, err error)
+}
+
+type audits interface {
+	AddAudit(ctx context.Context, req v1.AuditRequest) (resp v1.Audit, err error)
+	GetAudit(ctx context.Context, id int64) (resp v1.Audit, err error)
 }
 
 type errorResponse struct {
@@ -26,6 +53,9 @@ type handler struct {
 	server       *fiber.App
 	logger       *slog.Logger
 	providers    providers
+	webhooks     webhooks
+	system       system
+	audits       audits
 	namespace    string
 	subsystem    string
 	accessTokens map[string]struct{}
@@ -34,6 +64,9 @@ type handler struct {
 func New(
 	server *fiber.App,
 	providers providers,
+	webhooks webhooks,
+	system system,
+	audits audits,
 	accessTokens []string,
 	namespace string,
 	subsystem string,
@@ -47,6 +80,9 @@ func New(
 	h := &handler{
 		server:       server,
 		providers:    providers,
+		webhooks:     webhooks,
+		system:       system,
+		audits:       audits,
 		namespace:    namespace,
 		subsystem:    subsystem,
 		accessTokens: accessTokensMap,
@@ -1,9 +1,17 @@
 package httpServer
 
 import (
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 
 	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+)
+
+const (
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
 )
 
 func okHandler(c *fiber.Ctx) error {
@@ -36,3 +44,19 @@ func errorHandler(c *fiber.Ctx, err error) error {
 
 	return c.Status(fiber.StatusInternalServerError).JSON(errorResponse)
 }
+
+// payloadSignature reads provider signature of the request body from headers
+func payloadSignature(c *fiber.Ctx) (sign v1.PayloadSignature, err error) {
+	sign.Signature = c.Get(signatureHeader)
+	if sign.Signature == "" {
+		return
+	}
+
+	sign.Timestamp, err = strconv.ParseInt(c.Get(signatureTimestampHeader), 10, 64)
+	if err != nil {
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid signature timestamp")
+		return
+	}
+
+	return
+}
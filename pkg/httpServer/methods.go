@@ -3,6 +3,7 @@ package httpServer
 import (
 	"encoding/json"
 	"log/slog"
+	"strconv"
 
 	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
@@ -52,6 +53,204 @@ func (h *handler) searchProviders(c *fiber.Ctx) (err error) {
 	})
 }
 
+func (h *handler) getProvider(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getProvider"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.ProviderHistoryRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse provider history query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetProvider(c.Context(), c.Params("pubkey"), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getProviderPrices(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getProviderPrices"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.ProviderHistoryRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse provider prices query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetProviderPrices(c.Context(), c.Params("pubkey"), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getNetworkPrices(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getNetworkPrices"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.ProviderHistoryRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse network prices query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetNetworkPrices(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getQuote(c *fiber.Ctx) (err error) {
+	body := c.Body()
+	log := h.logger.With(
+		slog.String("method", "getQuote"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+		slog.Int("body_length", len(body)),
+		slog.String("body", string(body)),
+	)
+
+	var req v1.QuoteRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Error("failed to parse quote body", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetQuote(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getStats(c *fiber.Ctx) (err error) {
+	resp, err := h.providers.GetStats(c.Context())
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getStatsHistory(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getStatsHistory"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.StatsHistoryRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse stats history query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.system.GetStatsHistory(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getProviderRating(c *fiber.Ctx) (err error) {
+	resp, err := h.providers.GetProviderRating(c.Context(), c.Params("pubkey"))
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getBag(c *fiber.Ctx) (err error) {
+	resp, err := h.providers.GetBag(c.Context(), c.Params("bag_id"))
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getProviderContracts(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getProviderContracts"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.ProviderContractsRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse provider contracts query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetProviderContracts(c.Context(), c.Params("pubkey"), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getOwnerContracts(c *fiber.Ctx) (err error) {
+	log := h.logger.With(
+		slog.String("method", "getOwnerContracts"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+	)
+
+	var req v1.OwnerContractsRequest
+	err = c.QueryParser(&req)
+	if err != nil {
+		log.Error("failed to parse owner contracts query", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid query params")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetOwnerContracts(c.Context(), c.Params("address"), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
 func (h *handler) filtersRange(c *fiber.Ctx) (err error) {
 	filters, err := h.providers.GetFiltersRange(c.Context())
 	if err != nil {
@@ -89,7 +288,12 @@ func (h *handler) updateTelemetry(c *fiber.Ctx) (err error) {
 
 	req.XRealIP = c.Get("X-Real-IP")
 
-	err = h.providers.UpdateTelemetry(c.Context(), req, body)
+	sign, err := payloadSignature(c)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	err = h.providers.UpdateTelemetry(c.Context(), req, body, sign)
 	if err != nil {
 		return errorHandler(c, err)
 	}
@@ -121,7 +325,12 @@ func (h *handler) updateBenchmarks(c *fiber.Ctx) (err error) {
 		return errorHandler(c, err)
 	}
 
-	err = h.providers.UpdateBenchmarks(c.Context(), req)
+	sign, err := payloadSignature(c)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	err = h.providers.UpdateBenchmarks(c.Context(), req, body, sign)
 	if err != nil {
 		return errorHandler(c, err)
 	}
@@ -169,6 +378,140 @@ func (h *handler) getStorageContractsStatuses(c *fiber.Ctx) (err error) {
 	})
 }
 
+func (h *handler) getStorageContractsHistory(c *fiber.Ctx) (err error) {
+	body := c.Body()
+	log := h.logger.With(
+		slog.String("method", "getStorageContractsHistory"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Any("headers", c.GetReqHeaders()),
+		slog.Int("body_length", len(body)),
+		slog.String("body", string(body)),
+	)
+
+	var req v1.ContractsHistoryRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Error("failed to parse contracts history body", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.providers.GetStorageContractsHistory(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) addWebhook(c *fiber.Ctx) (err error) {
+	body := c.Body()
+	log := h.logger.With(
+		slog.String("method", "addWebhook"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Int("body_length", len(body)),
+	)
+
+	var req v1.WebhookSubscriptionRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Error("failed to parse webhook subscription body", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.webhooks.AddSubscription(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *handler) getWebhooks(c *fiber.Ctx) (err error) {
+	resp, err := h.webhooks.GetSubscriptions(c.Context())
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) deleteWebhook(c *fiber.Ctx) (err error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid webhook id")
+		return errorHandler(c, err)
+	}
+
+	err = h.webhooks.DeleteSubscription(c.Context(), id)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return okHandler(c)
+}
+
+func (h *handler) addAudit(c *fiber.Ctx) (err error) {
+	body := c.Body()
+	log := h.logger.With(
+		slog.String("method", "addAudit"),
+		slog.String("method", c.Method()),
+		slog.String("url", c.OriginalURL()),
+		slog.Int("body_length", len(body)),
+	)
+
+	var req v1.AuditRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		log.Error("failed to parse audit request body", slog.String("error", err.Error()))
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.audits.AddAudit(c.Context(), req)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *handler) getAudit(c *fiber.Ctx) (err error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		err = fiber.NewError(fiber.StatusBadRequest, "invalid audit id")
+		return errorHandler(c, err)
+	}
+
+	resp, err := h.audits.GetAudit(c.Context(), id)
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) getJobs(c *fiber.Ctx) (err error) {
+	resp, err := h.system.GetJobs(c.Context())
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *handler) runJob(c *fiber.Ctx) (err error) {
+	err = h.system.RunJob(c.Context(), c.Params("name"))
+	if err != nil {
+		return errorHandler(c, err)
+	}
+
+	return okHandler(c)
+}
+
 func (h *handler) health(c *fiber.Ctx) error {
 	return okHandler(c)
 }
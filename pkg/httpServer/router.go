@@ -37,6 +37,11 @@ func (h *handler) RegisterRoutes() {
 			providers := apiv1.Group("/providers")
 			providers.Post("/search", h.searchProviders)
 			providers.Get("/filters", h.filtersRange)
+			providers.Get("/prices", h.getNetworkPrices)
+			providers.Get("/:pubkey", h.getProvider)
+			providers.Get("/:pubkey/rating", h.getProviderRating)
+			providers.Get("/:pubkey/prices", h.getProviderPrices)
+			providers.Get("/:pubkey/contracts", h.getProviderContracts)
 			providers.Post("", h.updateTelemetry)
 			providers.Get("", h.authorizationMiddleware, h.getLatestTelemetry)
 		}
@@ -44,8 +49,41 @@ func (h *handler) RegisterRoutes() {
 		{
 			contracts := apiv1.Group("/contracts")
 			contracts.Post("/statuses", h.getStorageContractsStatuses)
+			contracts.Post("/history", h.getStorageContractsHistory)
+		}
+
+		{
+			bags := apiv1.Group("/bags")
+			bags.Get("/:bag_id", h.getBag)
+		}
+
+		{
+			owners := apiv1.Group("/owners")
+			owners.Get("/:address/contracts", h.getOwnerContracts)
+		}
+
+		{
+			webhooks := apiv1.Group("/webhooks", h.authorizationMiddleware)
+			webhooks.Post("", h.addWebhook)
+			webhooks.Get("", h.getWebhooks)
+			webhooks.Delete("/:id", h.deleteWebhook)
+		}
+
+		{
+			audits := apiv1.Group("/audits", h.authorizationMiddleware)
+			audits.Post("", h.addAudit)
+			audits.Get("/:id", h.getAudit)
+		}
+
+		{
+			system := apiv1.Group("/system")
+			system.Get("/jobs", h.getJobs)
+			system.Post("/jobs/:name/run", h.authorizationMiddleware, h.runJob)
 		}
 
 		apiv1.Post("/benchmarks", h.updateBenchmarks)
+		apiv1.Post("/quote", h.getQuote)
+		apiv1.Get("/stats", h.getStats)
+		apiv1.Get("/stats/history", h.getStatsHistory)
 	}
 }
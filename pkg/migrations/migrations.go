@@ -0,0 +1,270 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockKey is the advisory lock taken while migrating, so only one instance applies migrations
+const lockKey = 7_270_514_220_951_180
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var (
+	ErrDatabaseNewer     = errors.New("database schema is newer than the application")
+	ErrPendingMigrations = errors.New("database has pending migrations")
+)
+
+type migration struct {
+	version int
+	name    string
+	query   string
+}
+
+type migrator struct {
+	db         *pgxpool.Pool
+	migrations []migration
+	logger     *slog.Logger
+}
+
+type Migrator interface {
+	// Up applies all pending migrations
+	Up(ctx context.Context) (err error)
+	// Check returns error if the database schema differs from the application one
+	Check(ctx context.Context) (err error)
+}
+
+func (m *migrator) Up(ctx context.Context) (err error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to acquire connection: %w", err)
+		return
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", int64(lockKey))
+	if err != nil {
+		err = fmt.Errorf("failed to take migrations lock: %w", err)
+		return
+	}
+	defer func() {
+		// connection may be broken, lock is released with session anyway
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", int64(lockKey))
+	}()
+
+	err = createVersionsTable(ctx, conn.Conn())
+	if err != nil {
+		return
+	}
+
+	err = baseline(ctx, conn.Conn(), m.logger)
+	if err != nil {
+		return
+	}
+
+	current, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return
+	}
+
+	if latest := m.latest(); current > latest {
+		err = fmt.Errorf("%w: database version %d, application version %d", ErrDatabaseNewer, current, latest)
+		return
+	}
+
+	for _, mg := range m.migrations {
+		if mg.version <= current {
+			continue
+		}
+
+		m.logger.Info("applying migration", slog.Int("version", mg.version), slog.String("name", mg.name))
+
+		err = pgx.BeginFunc(ctx, conn.Conn(), func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, mg.query); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(ctx, `
+				INSERT INTO system.schema_migrations (version, name)
+				VALUES ($1, $2)
+			`, mg.version, mg.name)
+
+			return err
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to apply migration %d_%s: %w", mg.version, mg.name, err)
+			return
+		}
+	}
+
+	return
+}
+
+func (m *migrator) Check(ctx context.Context) (err error) {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to acquire connection: %w", err)
+		return
+	}
+	defer conn.Release()
+
+	var exists bool
+	err = conn.QueryRow(ctx, "SELECT to_regclass('system.schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil {
+		err = fmt.Errorf("failed to check migrations table: %w", err)
+		return
+	}
+
+	if !exists {
+		err = fmt.Errorf("%w: migrations were never applied", ErrPendingMigrations)
+		return
+	}
+
+	current, err := currentVersion(ctx, conn.Conn())
+	if err != nil {
+		return
+	}
+
+	latest := m.latest()
+	switch {
+	case current > latest:
+		err = fmt.Errorf("%w: database version %d, application version %d", ErrDatabaseNewer, current, latest)
+	case current < latest:
+		err = fmt.Errorf("%w: database version %d, application version %d", ErrPendingMigrations, current, latest)
+	}
+
+	return
+}
+
+func (m *migrator) latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].version
+}
+
+func createVersionsTable(ctx context.Context, conn *pgx.Conn) (err error) {
+	query := `
+		CREATE SCHEMA IF NOT EXISTS system;
+
+		CREATE TABLE IF NOT EXISTS system.schema_migrations
+		(
+			version integer NOT NULL,
+			name character varying(256) COLLATE pg_catalog."default" NOT NULL,
+			applied_at timestamp with time zone NOT NULL DEFAULT now(),
+			CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+		);
+	`
+
+	_, err = conn.Exec(ctx, query)
+	if err != nil {
+		err = fmt.Errorf("failed to create migrations table: %w", err)
+		return
+	}
+
+	return
+}
+
+// baseline marks the initial migration as applied for databases created from init.sql before migrations
+func baseline(ctx context.Context, conn *pgx.Conn, logger *slog.Logger) (err error) {
+	query := `
+		INSERT INTO system.schema_migrations (version, name)
+		SELECT 1, 'init'
+		WHERE to_regclass('providers.providers') IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM system.schema_migrations)
+	`
+
+	resp, err := conn.Exec(ctx, query)
+	if err != nil {
+		err = fmt.Errorf("failed to baseline database: %w", err)
+		return
+	}
+
+	if resp.RowsAffected() > 0 {
+		logger.Info("existing database is marked as having initial schema")
+	}
+
+	return
+}
+
+func currentVersion(ctx context.Context, conn *pgx.Conn) (version int, err error) {
+	err = conn.QueryRow(ctx, "SELECT COALESCE(max(version), 0) FROM system.schema_migrations").Scan(&version)
+	if err != nil {
+		err = fmt.Errorf("failed to get database version: %w", err)
+		return
+	}
+
+	return
+}
+
+// load reads embedded migrations, file names are "<version>_<name>.sql"
+func load(fsys fs.FS) (migrations []migration, err error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return
+	}
+
+	versions := make(map[int]string, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+
+		v, name, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		version, cErr := strconv.Atoi(v)
+		if !ok || cErr != nil || version <= 0 || name == "" {
+			err = fmt.Errorf("invalid migration file name: %s", e.Name())
+			return
+		}
+
+		if prev, exists := versions[version]; exists {
+			err = fmt.Errorf("duplicate migration version %d: %s and %s", version, prev, e.Name())
+			return
+		}
+		versions[version] = e.Name()
+
+		query, rErr := fs.ReadFile(fsys, path.Join("sql", e.Name()))
+		if rErr != nil {
+			err = rErr
+			return
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    name,
+			query:   string(query),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return
+}
+
+func New(db *pgxpool.Pool, logger *slog.Logger) (Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func Test_LoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatalf("no migrations found")
+	}
+
+	// versions go one by one, so a skipped migration is noticed on review
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Fatalf("migration %s has version %d, want %d", m.name, m.version, i+1)
+		}
+
+		if m.query == "" {
+			t.Fatalf("migration %d_%s is empty", m.version, m.name)
+		}
+	}
+}
+
+func Test_LoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name: "no version",
+			files: fstest.MapFS{
+				"sql/init.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "no name",
+			files: fstest.MapFS{
+				"sql/0001.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"sql/0001_init.sql": {Data: []byte("SELECT 1;")},
+				"sql/1_another.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(tt.files); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
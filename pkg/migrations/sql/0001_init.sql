@@ -1,11 +1,11 @@
 
--- SCHEMAS
+-- Baseline schema, databases created before migrations are marked as already having it
 
-CREATE SCHEMA providers AUTHORIZATION pguser;
+-- SCHEMAS
 
-CREATE SCHEMA public AUTHORIZATION pg_database_owner;
+CREATE SCHEMA IF NOT EXISTS providers;
 
-CREATE SCHEMA system AUTHORIZATION pguser;
+CREATE SCHEMA IF NOT EXISTS system;
 
 -- TABLES
 
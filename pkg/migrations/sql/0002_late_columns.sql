@@ -0,0 +1,26 @@
+-- Columns which were added to existing databases by hand
+
+ALTER TABLE providers.providers
+    ADD COLUMN IF NOT EXISTS status_ratio real NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS ip_info jsonb DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS storage_ip character varying(16) COLLATE pg_catalog."default",
+    ADD COLUMN IF NOT EXISTS storage_port integer,
+    ADD COLUMN IF NOT EXISTS statuses_reason_stats JSONB DEFAULT '[]'::JSONB;
+
+ALTER TABLE providers.telemetry
+    ADD COLUMN IF NOT EXISTS disks_load jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS disks_load_percent jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS iops jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS net_load double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS net_recv double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS net_sent double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS pps double precision[][] NOT NULL DEFAULT '{}'::double precision[];
+
+ALTER TABLE providers.telemetry_history
+    ADD COLUMN IF NOT EXISTS pps double precision[][],
+    ADD COLUMN IF NOT EXISTS iops jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS net_sent double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS net_recv double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS net_load double precision[][] NOT NULL DEFAULT '{}'::double precision[],
+    ADD COLUMN IF NOT EXISTS disks_load jsonb NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN IF NOT EXISTS disks_load_percent jsonb NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,5 @@
+ALTER TABLE providers.benchmarks
+    ADD COLUMN IF NOT EXISTS is_signed boolean NOT NULL DEFAULT false;
+
+ALTER TABLE providers.telemetry
+    ADD COLUMN IF NOT EXISTS is_signed boolean NOT NULL DEFAULT false;
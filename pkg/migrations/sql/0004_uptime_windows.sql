@@ -0,0 +1,4 @@
+ALTER TABLE providers.providers
+    ADD COLUMN IF NOT EXISTS uptime_24h double precision NOT NULL DEFAULT 0.0,
+    ADD COLUMN IF NOT EXISTS uptime_7d double precision NOT NULL DEFAULT 0.0,
+    ADD COLUMN IF NOT EXISTS uptime_30d double precision NOT NULL DEFAULT 0.0;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS system.webhook_subscriptions
+(
+    id bigserial,
+    url text COLLATE pg_catalog."default" NOT NULL,
+    secret character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    providers text[] NOT NULL DEFAULT '{}'::text[],
+    contracts text[] NOT NULL DEFAULT '{}'::text[],
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT webhook_subscriptions_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS system.webhook_deliveries
+(
+    id bigserial,
+    subscription_id bigint NOT NULL,
+    event_type character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    payload jsonb NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone NOT NULL DEFAULT now(),
+    last_error text COLLATE pg_catalog."default",
+    delivered_at timestamp with time zone,
+    failed_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT webhook_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT webhook_deliveries_subscription_fkey FOREIGN KEY (subscription_id)
+        REFERENCES system.webhook_subscriptions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending
+    ON system.webhook_deliveries USING btree
+    (next_attempt_at)
+    WHERE delivered_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS system.job_runs
+(
+    id bigserial,
+    name character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    manual boolean NOT NULL DEFAULT false,
+    started_at timestamp with time zone NOT NULL DEFAULT now(),
+    finished_at timestamp with time zone,
+    error text COLLATE pg_catalog."default",
+    next_run_at timestamp with time zone,
+    CONSTRAINT job_runs_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_name_started_at
+    ON system.job_runs USING btree
+    (name, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS system.job_triggers
+(
+    name character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    requested_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT job_triggers_pkey PRIMARY KEY (name)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS providers.storage_proofs_history
+(
+    id bigserial,
+    contract_address character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    provider_address character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    reason integer NOT NULL,
+    piece_id integer,
+    latency_ms integer,
+    checked_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT storage_proofs_history_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_storage_proofs_history_contract_checked_at
+    ON providers.storage_proofs_history USING btree
+    (contract_address, checked_at);
+
+CREATE INDEX IF NOT EXISTS idx_storage_proofs_history_checked_at
+    ON providers.storage_proofs_history USING btree
+    (checked_at);
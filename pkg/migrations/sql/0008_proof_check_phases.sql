@@ -0,0 +1,14 @@
+ALTER TABLE providers.storage_proofs_history
+    ADD COLUMN IF NOT EXISTS ping_ms integer,
+    ADD COLUMN IF NOT EXISTS info_ms integer,
+    ADD COLUMN IF NOT EXISTS piece_ms integer,
+    ADD COLUMN IF NOT EXISTS piece_size integer,
+    ADD COLUMN IF NOT EXISTS bytes_received bigint;
+
+CREATE INDEX IF NOT EXISTS idx_storage_proofs_history_provider_checked_at
+    ON providers.storage_proofs_history USING btree
+    (provider_address, checked_at);
+
+ALTER TABLE providers.providers
+    ADD COLUMN IF NOT EXISTS retrieval_p50_ms double precision,
+    ADD COLUMN IF NOT EXISTS retrieval_p95_ms double precision;
@@ -0,0 +1,8 @@
+ALTER TABLE providers.storage_contracts
+    ADD COLUMN IF NOT EXISTS confidence double precision;
+
+ALTER TABLE providers.storage_proofs_history
+    ADD COLUMN IF NOT EXISTS pieces_checked integer,
+    ADD COLUMN IF NOT EXISTS pieces_valid integer,
+    ADD COLUMN IF NOT EXISTS confidence double precision,
+    ADD COLUMN IF NOT EXISTS sample_seed bigint;
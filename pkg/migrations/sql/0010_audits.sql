@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS providers.audits
+(
+    id bigserial,
+    contract_address character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    provider_address character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    provider_public_key character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    bag_id character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    status character varying(16) COLLATE pg_catalog."default" NOT NULL DEFAULT 'pending',
+    error text COLLATE pg_catalog."default",
+    pieces_total integer,
+    pieces_checked integer NOT NULL DEFAULT 0,
+    missing_pieces integer[] NOT NULL DEFAULT '{}',
+    invalid_pieces integer[] NOT NULL DEFAULT '{}',
+    bytes_received bigint NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    CONSTRAINT audits_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audits_status_created_at
+    ON providers.audits USING btree
+    (status, created_at);
@@ -0,0 +1,39 @@
+ALTER TABLE providers.statuses
+    ADD COLUMN IF NOT EXISTS rtt_ms integer,
+    ADD COLUMN IF NOT EXISTS error_class character varying(32) COLLATE pg_catalog."default";
+
+ALTER TABLE providers.statuses_history
+    ADD COLUMN IF NOT EXISTS rtt_ms integer,
+    ADD COLUMN IF NOT EXISTS error_class character varying(32) COLLATE pg_catalog."default";
+
+CREATE INDEX IF NOT EXISTS idx_statuses_history_check_time
+    ON providers.statuses_history USING btree
+    (check_time);
+
+CREATE OR REPLACE FUNCTION providers.log_status_history()
+    RETURNS trigger
+    LANGUAGE plpgsql
+    COST 100
+    VOLATILE NOT LEAKPROOF
+AS $BODY$
+begin
+    insert into providers.statuses_history (
+        public_key,
+        check_time,
+        is_online,
+        rtt_ms,
+        error_class
+    ) values (
+        new.public_key,
+        new.check_time,
+        new.is_online,
+        new.rtt_ms,
+        new.error_class
+    );
+    return new;
+end;
+$BODY$;
+
+ALTER TABLE providers.providers
+    ADD COLUMN IF NOT EXISTS rtt_p50_ms double precision,
+    ADD COLUMN IF NOT EXISTS rtt_p95_ms double precision;
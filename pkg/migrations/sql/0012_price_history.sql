@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_providers_history_public_key_archived_at
+    ON providers.providers_history USING btree
+    (public_key, archived_at);
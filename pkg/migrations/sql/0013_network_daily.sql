@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS system.network_daily
+(
+    day date NOT NULL,
+    providers_registered integer NOT NULL,
+    providers_initialized integer NOT NULL,
+    providers_online integer NOT NULL,
+    total_provider_space double precision NOT NULL,
+    used_provider_space double precision NOT NULL,
+    contracts bigint NOT NULL,
+    contracts_size bigint NOT NULL,
+    bags bigint NOT NULL,
+    owners bigint NOT NULL,
+    median_price double precision,
+    median_rating double precision,
+    proofs_checked bigint NOT NULL,
+    proofs_valid bigint NOT NULL,
+    countries integer NOT NULL,
+    storage_versions jsonb NOT NULL DEFAULT '{}'::jsonb,
+    provider_versions jsonb NOT NULL DEFAULT '{}'::jsonb,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT network_daily_pkey PRIMARY KEY (day)
+);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_storage_contracts_bag_id
+    ON providers.storage_contracts USING btree
+    (bag_id COLLATE pg_catalog."default");
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_storage_contracts_owner_address
+    ON providers.storage_contracts USING btree
+    (owner_address COLLATE pg_catalog."default");
+
+CREATE INDEX IF NOT EXISTS idx_storage_contracts_history_owner_address
+    ON providers.storage_contracts_history USING btree
+    (owner_address COLLATE pg_catalog."default");
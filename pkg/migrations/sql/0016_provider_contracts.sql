@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_storage_contracts_provider_address
+    ON providers.storage_contracts USING btree
+    (provider_address COLLATE pg_catalog."default", address COLLATE pg_catalog."default");
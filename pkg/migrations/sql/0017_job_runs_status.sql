@@ -0,0 +1,7 @@
+ALTER TABLE system.job_runs
+    ADD COLUMN IF NOT EXISTS instance_id text COLLATE pg_catalog."default";
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_name_success
+    ON system.job_runs USING btree
+    (name, finished_at DESC)
+    WHERE finished_at IS NOT NULL AND error IS NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS providers.storage_contracts_balances
+(
+    address character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    balance bigint NOT NULL,
+    days_left double precision,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT storage_contracts_balances_pkey PRIMARY KEY (address)
+);
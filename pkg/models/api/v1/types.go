@@ -11,7 +11,7 @@ type SearchProvidersRequest struct {
 }
 
 type Sort struct {
-	Column string `json:"column,omitempty"` // "workingtime", "rating", "price", "uptime" or "maxSpan", "location"
+	Column string `json:"column,omitempty"` // "workingtime", "rating", "price", "uptime", "uptime24h", "uptime7d", "uptime30d" or "maxSpan", "location"
 	Order  string `json:"order,omitempty"`  // "asc" or "desc"
 }
 
@@ -48,10 +48,17 @@ type Filters struct {
 	Location                  *string  `json:"location,omitempty"`
 	RatingGt                  *float64 `json:"rating_gt,omitempty"`
 	RatingLt                  *float64 `json:"rating_lt,omitempty"`
+	RTTP50MsLt                *float64 `json:"rtt_p50_ms_lt,omitempty"`
 	RegTimeDaysGt             *int64   `json:"reg_time_days_gt,omitempty"`
 	RegTimeDaysLt             *int64   `json:"reg_time_days_lt,omitempty"`
 	UpTimeGtPercent           *float64 `json:"uptime_gt_percent,omitempty"`
 	UpTimeLtPercent           *float64 `json:"uptime_lt_percent,omitempty"`
+	UpTime24hGtPercent        *float64 `json:"uptime_24h_gt_percent,omitempty"`
+	UpTime24hLtPercent        *float64 `json:"uptime_24h_lt_percent,omitempty"`
+	UpTime7dGtPercent         *float64 `json:"uptime_7d_gt_percent,omitempty"`
+	UpTime7dLtPercent         *float64 `json:"uptime_7d_lt_percent,omitempty"`
+	UpTime30dGtPercent        *float64 `json:"uptime_30d_gt_percent,omitempty"`
+	UpTime30dLtPercent        *float64 `json:"uptime_30d_lt_percent,omitempty"`
 	WorkingTimeGtSec          *int64   `json:"working_time_gt_sec,omitempty"`
 	WorkingTimeLtSec          *int64   `json:"working_time_lt_sec,omitempty"`
 	PriceGt                   *float64 `json:"price_gt,omitempty"`
@@ -110,6 +117,7 @@ type TelemetryRequest struct {
 	Benchmark        interface{}        `json:"benchmark"`
 	XRealIP          string             `json:"x_real_ip"`
 	Timestamp        uint64             `json:"timestamp"`
+	IsSigned         bool               `json:"is_signed"`
 }
 
 type DiskBenchmark struct {
@@ -164,6 +172,14 @@ type BenchmarksRequest struct {
 	Disk      map[string]DiskBenchmark `json:"disk"`
 	Network   NetworkBenchmark         `json:"network"`
 	Timestamp int64                    `json:"timestamp"` // Unix timestamp in seconds
+	IsSigned  bool                     `json:"is_signed"`
+}
+
+// PayloadSignature is ed25519 signature of "<timestamp>:<raw body>" made with provider key.
+// Comes in X-Signature (hex) and X-Signature-Timestamp (unix seconds) headers.
+type PayloadSignature struct {
+	Signature string
+	Timestamp int64
 }
 
 type ProviderInfo struct {
@@ -236,6 +252,7 @@ type Telemetry struct {
 	SpeedtestPing           *float32 `json:"speedtest_ping"`
 	CPUNumber               *uint16  `json:"cpu_number"`
 	CPUIsVirtual            *bool    `json:"cpu_is_virtual"`
+	IsSigned                *bool    `json:"is_signed"`
 }
 
 type StatusesReasonStats struct {
@@ -249,6 +266,9 @@ type Provider struct {
 	PubKey              string                `json:"pubkey"`
 	Address             string                `json:"address"`
 	UpTime              float32               `json:"uptime"`
+	UpTime24h           float32               `json:"uptime_24h"`
+	UpTime7d            float32               `json:"uptime_7d"`
+	UpTime30d           float32               `json:"uptime_30d"`
 	StatusRatio         float32               `json:"status_ratio"`
 	StatusesReasonStats []StatusesReasonStats `json:"statuses_reason_stats"`
 	WorkingTime         uint64                `json:"working_time"`
@@ -258,6 +278,10 @@ type Provider struct {
 
 	MinSpan             uint32    `json:"min_span"`
 	MaxBagSizeBytes     uint64    `json:"max_bag_size_bytes"`
+	RetrievalP50Ms      *float64  `json:"retrieval_p50_ms"` // over valid storage proof checks of the last 7 days
+	RetrievalP95Ms      *float64  `json:"retrieval_p95_ms"`
+	RTTP50Ms            *float64  `json:"rtt_p50_ms"` // of storage rates queries of the last 24 hours
+	RTTP95Ms            *float64  `json:"rtt_p95_ms"`
 	RegTime             uint64    `json:"reg_time"`
 	LastOnlineCheckTime *uint64   `json:"last_online_check_time"`
 	IsSendTelemetry     bool      `json:"is_send_telemetry"`
@@ -275,11 +299,262 @@ type ProvidersResponse struct {
 	Providers []Provider `json:"providers"`
 }
 
+type ProviderHistoryRequest struct {
+	From   int64 `query:"from"`   // Unix timestamp in seconds, default is 7 days before "to"
+	To     int64 `query:"to"`     // Unix timestamp in seconds, default is now
+	Bucket int64 `query:"bucket"` // Bucket size in seconds, picked by range if not set
+}
+
+// ProviderHistoryPoint is averaged provider state in [time, time + bucket).
+// Fields are null if nothing was recorded in the bucket.
+type ProviderHistoryPoint struct {
+	Time                    int64    `json:"time"` // Unix timestamp of bucket start
+	Rating                  *float64 `json:"rating"`
+	UpTime                  *float64 `json:"uptime"`
+	Price                   *float64 `json:"price"`
+	ChecksTotal             *uint32  `json:"checks_total"`
+	ChecksOnline            *uint32  `json:"checks_online"`
+	TotalProviderSpace      *float64 `json:"total_provider_space"`
+	UsedProviderSpace       *float64 `json:"used_provider_space"`
+	FreeSpace               *float64 `json:"free_space"`
+	BenchmarkDiskReadSpeed  *float64 `json:"qd64_disk_read_speed"`  // bytes/s
+	BenchmarkDiskWriteSpeed *float64 `json:"qd64_disk_write_speed"` // bytes/s
+	SpeedtestDownload       *float64 `json:"speedtest_download"`
+	SpeedtestUpload         *float64 `json:"speedtest_upload"`
+	SpeedtestPing           *float64 `json:"speedtest_ping"`
+}
+
+type ProviderHistory struct {
+	From   int64                  `json:"from"`
+	To     int64                  `json:"to"`
+	Bucket int64                  `json:"bucket"`
+	Points []ProviderHistoryPoint `json:"points"`
+}
+
+type ProviderResponse struct {
+	Provider Provider        `json:"provider"`
+	History  ProviderHistory `json:"history"`
+}
+
+// ProviderPricePoint is provider rates set at time, they are in effect until the next point
+type ProviderPricePoint struct {
+	Time         int64  `json:"time"`                // Unix timestamp in seconds
+	RatePerMBDay int64  `json:"rate_per_mb_per_day"` // NanoTON
+	MinBounty    int64  `json:"min_bounty"`          // NanoTON
+	MinSpan      uint32 `json:"min_span"`
+	MaxSpan      uint32 `json:"max_span"`
+}
+
+// ProviderPricesResponse lists rates changes in the range,
+// the first point is rates which were in effect at "from"
+type ProviderPricesResponse struct {
+	PubKey string               `json:"pubkey"`
+	From   int64                `json:"from"`
+	To     int64                `json:"to"`
+	Points []ProviderPricePoint `json:"points"`
+}
+
+// NetworkPricePoint is rate per MB per day over providers with rates at time, NanoTON
+type NetworkPricePoint struct {
+	Time      int64   `json:"time"` // Unix timestamp of bucket start
+	Providers uint32  `json:"providers"`
+	Median    float64 `json:"median"`
+	Min       int64   `json:"min"`
+	Max       int64   `json:"max"`
+}
+
+type NetworkPricesResponse struct {
+	From   int64               `json:"from"`
+	To     int64               `json:"to"`
+	Bucket int64               `json:"bucket"`
+	Points []NetworkPricePoint `json:"points"`
+}
+
+type QuoteRequest struct {
+	BagSize   uint64 `json:"bag_size"`       // bytes
+	Duration  uint64 `json:"duration"`       // seconds
+	Providers int    `json:"providers"`      // providers count, 1 if not set
+	Span      uint32 `json:"span,omitempty"` // proof span in seconds, picked by provider rates if not set
+}
+
+type QuoteProvider struct {
+	PubKey       string  `json:"pubkey"`
+	Address      string  `json:"address"`
+	Rating       float32 `json:"rating"`
+	RatePerMBDay uint64  `json:"rate_per_mb_per_day"` // NanoTON
+	Span         uint32  `json:"span"`
+	Proofs       uint64  `json:"proofs"`
+	Bounty       uint64  `json:"bounty"`   // NanoTON per proof
+	Cost         uint64  `json:"cost"`     // NanoTON, bounties of all proofs and storage fee
+	CostTON      string  `json:"cost_ton"` // the same cost in TON
+}
+
+// QuoteResponse lists the cheapest eligible providers, there may be less of them than requested
+type QuoteResponse struct {
+	BagSize      uint64          `json:"bag_size"`
+	Duration     uint64          `json:"duration"`
+	Providers    []QuoteProvider `json:"providers"`
+	TotalCost    uint64          `json:"total_cost"` // NanoTON
+	TotalCostTON string          `json:"total_cost_ton"`
+}
+
+// StatsResponse is network aggregates, it's cached for a minute
+type StatsResponse struct {
+	ProvidersRegistered  uint32   `json:"providers_registered"`
+	ProvidersInitialized uint32   `json:"providers_initialized"`
+	ProvidersOnline      uint32   `json:"providers_online"`
+	TotalProviderSpace   float64  `json:"total_provider_space"` // GB, by telemetry
+	UsedProviderSpace    float64  `json:"used_provider_space"`  // GB, by telemetry
+	Contracts            uint64   `json:"contracts"`            // active storage contracts
+	ContractsSize        uint64   `json:"contracts_size"`       // bytes, each contract is counted once
+	Bags                 uint64   `json:"bags"`
+	Owners               uint64   `json:"owners"`
+	MedianPrice          *float64 `json:"median_price"`     // NanoTON per 200GB per month of initialized providers
+	ProofsRatio24h       *float64 `json:"proofs_ratio_24h"` // share of valid storage proof checks, null if there were no checks
+	Countries            uint32   `json:"countries"`
+}
+
+type StatsHistoryRequest struct {
+	From int64 `query:"from"` // Unix timestamp in seconds, default is a year before "to"
+	To   int64 `query:"to"`   // Unix timestamp in seconds, default is now
+}
+
+// StatsHistoryPoint is network stats snapshot taken at the start of the UTC day
+type StatsHistoryPoint struct {
+	Day                  int64             `json:"day"` // Unix timestamp of the day start
+	ProvidersRegistered  uint32            `json:"providers_registered"`
+	ProvidersInitialized uint32            `json:"providers_initialized"`
+	ProvidersOnline      uint32            `json:"providers_online"`
+	TotalProviderSpace   float64           `json:"total_provider_space"` // GB
+	UsedProviderSpace    float64           `json:"used_provider_space"`  // GB
+	Contracts            uint64            `json:"contracts"`
+	ContractsSize        uint64            `json:"contracts_size"` // bytes
+	Bags                 uint64            `json:"bags"`
+	Owners               uint64            `json:"owners"`
+	MedianPrice          *float64          `json:"median_price"` // NanoTON per 200GB per month
+	MedianRating         *float64          `json:"median_rating"`
+	ProofsRatio24h       *float64          `json:"proofs_ratio_24h"`
+	Countries            uint32            `json:"countries"`
+	StorageVersions      map[string]uint32 `json:"storage_versions"`  // providers count by storage git hash
+	ProviderVersions     map[string]uint32 `json:"provider_versions"` // providers count by provider git hash
+}
+
+type StatsHistoryResponse struct {
+	From   int64               `json:"from"`
+	To     int64               `json:"to"`
+	Points []StatsHistoryPoint `json:"points"`
+}
+
 type TelemetryResponse struct {
 	PubKey    string    `json:"pubkey"`
 	Telemetry Telemetry `json:"telemetry"`
 }
 
+type RatingComponent struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`
+	Coefficient  float64 `json:"coefficient"`
+	Contribution float64 `json:"contribution"`       // value * coefficient, part of the base
+	ScoreShare   float64 `json:"score_contribution"` // part of the final rating
+}
+
+// ProviderRatingResponse explains the rating:
+// score = base * uptime_factor / price_divisor / divider, where base is sum of components contributions
+// and uptime_factor = uptime ^ uptime_exponent.
+type ProviderRatingResponse struct {
+	PubKey         string            `json:"pubkey"`
+	Rating         float32           `json:"rating"` // rating saved on the last update
+	Score          float64           `json:"score"`  // rating calculated right now
+	Components     []RatingComponent `json:"components"`
+	Base           float64           `json:"base"`
+	UptimeWindow   string            `json:"uptime_window"`
+	Uptime         float64           `json:"uptime"`
+	UptimeExponent float64           `json:"uptime_exponent"`
+	UptimeFactor   float64           `json:"uptime_factor"`
+	PriceDivisor   float64           `json:"price_divisor"`
+	Divider        float64           `json:"divider"`
+}
+
+type BagProvider struct {
+	PublicKey       *string `json:"pubkey"` // empty if provider is not registered
+	Address         string  `json:"address"`
+	Reason          *uint32 `json:"reason"`
+	ReasonTimestamp *int64  `json:"reason_timestamp"` // Unix timestamp
+	// 0..1, how sure the last check is that the whole bag is stored
+	Confidence *float64 `json:"confidence"`
+}
+
+type BagContract struct {
+	Address      string        `json:"address"`
+	OwnerAddress string        `json:"owner_address"`
+	Size         uint64        `json:"size"` // bytes
+	Providers    []BagProvider `json:"providers"`
+}
+
+type BagResponse struct {
+	BagID     string        `json:"bag_id"`
+	Contracts []BagContract `json:"contracts"`
+	// number of distinct providers with valid storage proof
+	Replication uint32 `json:"replication"`
+}
+
+type ProviderContractsRequest struct {
+	Limit   int      `query:"limit"`
+	Offset  int      `query:"offset"`
+	Reasons []uint32 `query:"reason"` // last proof check reason codes, all contracts if empty
+}
+
+type ProviderContract struct {
+	Address         string  `json:"address"`
+	BagID           string  `json:"bag_id"`
+	Size            uint64  `json:"size"` // bytes
+	OwnerAddress    string  `json:"owner_address"`
+	Reason          *uint32 `json:"reason"`
+	ReasonTimestamp *int64  `json:"reason_timestamp"` // Unix timestamp
+	// 0..1, how sure the last check is that the whole bag is stored
+	Confidence *float64 `json:"confidence"`
+}
+
+type ProviderContractsResponse struct {
+	PubKey    string             `json:"pubkey"`
+	Total     uint64             `json:"total"` // contracts matching filters
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
+	Contracts []ProviderContract `json:"contracts"`
+}
+
+type OwnerContractsRequest struct {
+	Limit   int  `query:"limit"`
+	Offset  int  `query:"offset"`
+	History bool `query:"history"` // include providers which left contracts and closed contracts
+}
+
+type OwnerContractProvider struct {
+	BagProvider
+	RemovedAt *int64 `json:"removed_at,omitempty"` // Unix timestamp, set if provider left the contract
+}
+
+type OwnerContract struct {
+	Address   string                  `json:"address"`
+	BagID     string                  `json:"bag_id"`
+	Size      uint64                  `json:"size"` // bytes
+	Active    bool                    `json:"active"`
+	Providers []OwnerContractProvider `json:"providers"`
+	// on-chain balance in NanoTON, empty if contract is closed or balance is unknown
+	Balance  *uint64  `json:"balance"`
+	DaysLeft *float64 `json:"days_left"` // estimated by providers rates, storage fee is kept on balance
+	// Unix timestamp of reading balance from chain, balances are refreshed by storage proofs checks
+	BalanceUpdatedAt *int64 `json:"balance_updated_at"`
+}
+
+type OwnerContractsResponse struct {
+	Owner     string          `json:"owner"`
+	Total     uint64          `json:"total"` // contracts of the owner, closed ones are counted with history
+	Limit     int             `json:"limit"`
+	Offset    int             `json:"offset"`
+	Contracts []OwnerContract `json:"contracts"`
+}
+
 type ContractsStatusesRequest struct {
 	Contracts []string `json:"contracts"`
 }
@@ -289,8 +564,100 @@ type ContractCheck struct {
 	ProviderPublicKey string  `json:"provider_pubkey"`
 	Reason            *uint32 `json:"reason"`
 	ReasonTimestamp   *int64  `json:"reason_timestamp"` // Unix timestamp
+	// 0..1, how sure the last check is that the whole bag is stored
+	Confidence *float64 `json:"confidence"`
 }
 
 type ContractsStatusesResponse struct {
 	Contracts []ContractCheck `json:"contracts"`
 }
+
+type ContractsHistoryRequest struct {
+	Contracts []string `json:"contracts"`
+	From      int64    `json:"from"` // Unix timestamp, 7 days before to by default
+	To        int64    `json:"to"`   // Unix timestamp, now by default
+}
+
+type StorageProofCheck struct {
+	ProviderPublicKey string   `json:"provider_pubkey"`
+	Reason            uint32   `json:"reason"`
+	PieceID           *int32   `json:"piece_id"`   // empty if piece was not requested
+	LatencyMs         *int32   `json:"latency_ms"` // empty if provider was not checked
+	PiecesChecked     *int32   `json:"pieces_checked"`
+	PiecesValid       *int32   `json:"pieces_valid"`
+	Confidence        *float64 `json:"confidence"`
+	Timestamp         int64    `json:"timestamp"` // Unix timestamp
+}
+
+type ContractHistory struct {
+	Address string              `json:"address"`
+	Checks  []StorageProofCheck `json:"checks"`
+}
+
+type ContractsHistoryResponse struct {
+	Contracts []ContractHistory `json:"contracts"`
+}
+
+type WebhookSubscriptionRequest struct {
+	URL       string   `json:"url"`
+	Providers []string `json:"providers"` // providers public keys
+	Contracts []string `json:"contracts"` // storage contracts addresses
+}
+
+// WebhookSubscription describes a webhook.
+// Every request has X-Webhook-Signature header: "sha256=" + hex(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body)).
+type WebhookSubscription struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"` // returned only on creation
+	Providers []string `json:"providers"`
+	Contracts []string `json:"contracts"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+type WebhookSubscriptionsResponse struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+// JobStatus describes background job state, all times are Unix timestamps in seconds
+type JobStatus struct {
+	Name           string `json:"name"`
+	Running        bool   `json:"running"`
+	Manual         bool   `json:"manual"`             // last run was triggered manually
+	Instance       string `json:"instance,omitempty"` // instance of the last run
+	LastStartedAt  int64  `json:"last_started_at,omitempty"`
+	LastFinishedAt int64  `json:"last_finished_at,omitempty"`
+	LastDurationMs int64  `json:"last_duration_ms,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	LastSuccessAt  int64  `json:"last_success_at,omitempty"`
+	NextRunAt      int64  `json:"next_run_at,omitempty"`
+}
+
+type JobsResponse struct {
+	Leader   string      `json:"leader"`   // instance which runs jobs, empty if there is no leader
+	Instance string      `json:"instance"` // instance which served the request
+	Jobs     []JobStatus `json:"jobs"`
+}
+
+type AuditRequest struct {
+	ProviderPubKey string `json:"provider_pubkey"`
+	Contract       string `json:"contract"`
+}
+
+// Audit is the report of the full bag download from the provider
+type Audit struct {
+	ID             int64   `json:"id"`
+	ProviderPubKey string  `json:"provider_pubkey"`
+	Contract       string  `json:"contract"`
+	BagID          string  `json:"bag_id"`
+	Status         string  `json:"status"` // pending, running, done or failed
+	Error          string  `json:"error,omitempty"`
+	PiecesTotal    *int32  `json:"pieces_total"`
+	PiecesChecked  int32   `json:"pieces_checked"`
+	MissingPieces  []int32 `json:"missing_pieces"` // provider didn't return the piece
+	InvalidPieces  []int32 `json:"invalid_pieces"` // piece proof is not valid
+	BytesReceived  int64   `json:"bytes_received"`
+	CreatedAt      int64   `json:"created_at"`  // Unix timestamp
+	StartedAt      *int64  `json:"started_at"`  // Unix timestamp
+	FinishedAt     *int64  `json:"finished_at"` // Unix timestamp
+}
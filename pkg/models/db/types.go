@@ -15,6 +15,30 @@ type ProviderUpdate struct {
 	MaxSpan      uint32 `json:"max_span"`
 }
 
+type ProviderRates struct {
+	RatePerMBDay int64  `json:"rate_per_mb_per_day"`
+	MinBounty    int64  `json:"min_bounty"`
+	MinSpan      uint32 `json:"min_span"`
+	MaxSpan      uint32 `json:"max_span"`
+}
+
+type ProviderPriceChange struct {
+	Pubkey string        `json:"public_key"`
+	Old    ProviderRates `json:"old"`
+	New    ProviderRates `json:"new"`
+}
+
+// QuoteCandidate is online provider which can store a bag of requested size
+type QuoteCandidate struct {
+	PubKey       string  `json:"public_key"`
+	Address      string  `json:"address"`
+	RatePerMBDay uint64  `json:"rate_per_mb_per_day"`
+	MinBounty    uint64  `json:"min_bounty"`
+	MinSpan      uint32  `json:"min_span"`
+	MaxSpan      uint32  `json:"max_span"`
+	Rating       float32 `json:"rating"`
+}
+
 type ProviderCreate struct {
 	Pubkey       string    `json:"public_key"`
 	Address      string    `json:"address"`
@@ -50,8 +74,15 @@ type ProviderFilters struct {
 	SpeedtestPingLt              *int64   `json:"speedtest_ping_lt,omitempty"`
 	RatingGt                     *float64 `json:"rating_gt,omitempty"`
 	RatingLt                     *float64 `json:"rating_lt,omitempty"`
+	RTTP50MsLt                   *float64 `json:"rtt_p50_ms_lt,omitempty"`
 	UpTimeGtPercent              *float64 `json:"uptime_gt_percent,omitempty"`
 	UpTimeLtPercent              *float64 `json:"uptime_lt_percent,omitempty"`
+	UpTime24hGtPercent           *float64 `json:"uptime_24h_gt_percent,omitempty"`
+	UpTime24hLtPercent           *float64 `json:"uptime_24h_lt_percent,omitempty"`
+	UpTime7dGtPercent            *float64 `json:"uptime_7d_gt_percent,omitempty"`
+	UpTime7dLtPercent            *float64 `json:"uptime_7d_lt_percent,omitempty"`
+	UpTime30dGtPercent           *float64 `json:"uptime_30d_gt_percent,omitempty"`
+	UpTime30dLtPercent           *float64 `json:"uptime_30d_lt_percent,omitempty"`
 	PriceGt                      *float64 `json:"price_gt,omitempty"`
 	PriceLt                      *float64 `json:"price_lt,omitempty"`
 	TotalProviderSpaceGt         *float64 `json:"total_provider_space_gt,omitempty"`
@@ -75,8 +106,10 @@ type ProviderSort struct {
 }
 
 type ProviderStatusUpdate struct {
-	Pubkey   string `json:"public_key"`
-	IsOnline bool   `json:"is_online"`
+	Pubkey     string  `json:"public_key"`
+	IsOnline   bool    `json:"is_online"`
+	RTTMs      *int64  `json:"rtt_ms"`      // storage rates query round trip, nil if provider didn't respond
+	ErrorClass *string `json:"error_class"` // one of constants.Rates*, nil on valid response
 }
 
 type BenchmarkUpdate struct {
@@ -91,6 +124,7 @@ type BenchmarkUpdate struct {
 	SpeedtestPing      float32 `json:"speedtest_ping" db:"speedtest_ping"` // ms
 	Country            string  `json:"country" db:"country"`
 	ISP                string  `json:"isp" db:"isp"`
+	IsSigned           bool    `json:"is_signed" db:"is_signed"`
 }
 
 type TelemetryUpdate struct {
@@ -128,6 +162,7 @@ type TelemetryUpdate struct {
 	DisksLoadPercent   interface{} `json:"disks_load_percent"`
 	IOPS               interface{} `json:"iops"`
 	PPS                []float32   `json:"pps"`
+	IsSigned           bool        `json:"is_signed" db:"is_signed"`
 }
 
 type TelemetryDB struct {
@@ -149,6 +184,7 @@ type TelemetryDB struct {
 	SpeedtestPing           *float32 `json:"speedtest_ping"`
 	CPUNumber               *uint16  `json:"cpu_number"`
 	CPUIsVirtual            *bool    `json:"cpu_is_virtual"`
+	IsSigned                *bool    `json:"is_signed"`
 }
 
 type FiltersRange struct {
@@ -180,6 +216,32 @@ type FiltersRange struct {
 	TotalRAMMax                float32
 }
 
+type NetworkStats struct {
+	ProvidersRegistered  uint32   `json:"providers_registered"`
+	ProvidersInitialized uint32   `json:"providers_initialized"`
+	ProvidersOnline      uint32   `json:"providers_online"`
+	TotalProviderSpace   float64  `json:"total_provider_space"` // GB, by telemetry
+	UsedProviderSpace    float64  `json:"used_provider_space"`  // GB, by telemetry
+	Contracts            uint64   `json:"contracts"`
+	ContractsSize        uint64   `json:"contracts_size"` // bytes of distinct contracts
+	Bags                 uint64   `json:"bags"`
+	Owners               uint64   `json:"owners"`
+	MedianPrice          *float64 `json:"median_price"`
+	ProofsChecked24h     uint64   `json:"proofs_checked_24h"`
+	ProofsValid24h       uint64   `json:"proofs_valid_24h"`
+	Countries            uint32   `json:"countries"`
+	MedianRating         *float64 `json:"median_rating"`
+	// providers count by git hash of software, by telemetry
+	StorageVersions  map[string]uint32 `json:"storage_versions"`
+	ProviderVersions map[string]uint32 `json:"provider_versions"`
+}
+
+// NetworkDailySnapshot is network stats saved once a day
+type NetworkDailySnapshot struct {
+	Day time.Time `json:"day"`
+	NetworkStats
+}
+
 type ReasonStat struct {
 	Reason uint32 `json:"reason"`
 	Count  uint32 `json:"cnt"`
@@ -191,6 +253,9 @@ type ProviderDB struct {
 	PubKey              string       `json:"public_key"`
 	Address             string       `json:"address"`
 	UpTime              float32      `json:"uptime"`
+	UpTime24h           float32      `json:"uptime_24h"`
+	UpTime7d            float32      `json:"uptime_7d"`
+	UpTime30d           float32      `json:"uptime_30d"`
 	Rating              float32      `json:"rating"`
 	StatusRatio         float32      `json:"status_ratio"`
 	StatusesReasonStats []ReasonStat `json:"statuses_reason_stats"`
@@ -199,6 +264,10 @@ type ProviderDB struct {
 
 	MinSpan             uint32      `json:"min_span"`
 	MaxBagSizeBytes     uint64      `json:"max_bag_size_bytes"`
+	RetrievalP50Ms      *float64    `json:"retrieval_p50_ms"`
+	RetrievalP95Ms      *float64    `json:"retrieval_p95_ms"`
+	RTTP50Ms            *float64    `json:"rtt_p50_ms"`
+	RTTP95Ms            *float64    `json:"rtt_p95_ms"`
 	RegTime             uint64      `json:"registered_at"`
 	LastOnlineCheckTime *uint64     `json:"last_online_check_time"`
 	IsSendTelemetry     bool        `json:"is_send_telemetry"`
@@ -262,6 +331,75 @@ type ContractProofsCheck struct {
 	ContractAddress string               `json:"contract_address"`
 	ProviderAddress string               `json:"provider_address"`
 	Reason          constants.ReasonCode `json:"reason"`
+	PieceID         *int32               `json:"piece_id"`   // empty if piece was not requested
+	LatencyMs       *int64               `json:"latency_ms"` // empty if provider was not checked
+	// phases durations, empty if phase was not reached
+	PingMs        *int64 `json:"ping_ms"`
+	InfoMs        *int64 `json:"info_ms"`
+	PieceMs       *int64 `json:"piece_ms"`
+	PieceSize     *int32 `json:"piece_size"`
+	BytesReceived int64  `json:"bytes_received"`
+	// sampled pieces, confidence is 0..1
+	PiecesChecked int32   `json:"pieces_checked"`
+	PiecesValid   int32   `json:"pieces_valid"`
+	Confidence    float64 `json:"confidence"`
+	SampleSeed    int64   `json:"sample_seed"`
+}
+
+type StorageProofCheck struct {
+	ContractAddress   string
+	ProviderPublicKey string
+	Reason            uint32
+	PieceID           *int32
+	LatencyMs         *int32
+	PiecesChecked     *int32
+	PiecesValid       *int32
+	Confidence        *float64
+	CheckedAt         time.Time
+}
+
+// BagContract is a provider of storage contract of the bag, provider public key is empty if it's not registered
+type BagContract struct {
+	Address           string
+	OwnerAddress      string
+	Size              uint64
+	ProviderAddress   string
+	ProviderPublicKey *string
+	ReasonTimestamp   *time.Time
+	Reason            *uint32
+	Confidence        *float64
+}
+
+// OwnerContract is a provider of owner's storage contract, RemovedAt is set if provider left the contract
+type OwnerContract struct {
+	Address           string
+	BagID             string
+	Size              uint64
+	ProviderAddress   string
+	ProviderPublicKey *string
+	ReasonTimestamp   *time.Time
+	Reason            *uint32
+	Confidence        *float64
+	RemovedAt         *time.Time
+	Balance           *uint64
+	DaysLeft          *float64
+	BalanceUpdatedAt  *time.Time
+}
+
+type ContractBalance struct {
+	Address  string   `json:"address"`
+	Balance  uint64   `json:"balance"`
+	DaysLeft *float64 `json:"days_left"`
+}
+
+type ProviderContract struct {
+	Address         string
+	BagID           string
+	Size            uint64
+	OwnerAddress    string
+	ReasonTimestamp *time.Time
+	Reason          *uint32
+	Confidence      *float64
 }
 
 type ContractCheck struct {
@@ -269,4 +407,172 @@ type ContractCheck struct {
 	ProviderPublicKey string
 	ReasonTimestamp   *time.Time
 	Reason            *uint32
+	Confidence        *float64
+}
+
+type ProviderHistoryPoint struct {
+	Time   time.Time `json:"time"`
+	Rating *float64  `json:"rating"`
+	UpTime *float64  `json:"uptime"`
+	Price  *float64  `json:"price"`
+}
+
+// ProviderPricePoint is provider rates which were set at Time
+type ProviderPricePoint struct {
+	Time time.Time `json:"time"`
+	ProviderRates
+}
+
+// NetworkPricePoint is rate per MB per day stats over providers rates at Time
+type NetworkPricePoint struct {
+	Time      time.Time `json:"time"`
+	Providers uint32    `json:"providers"`
+	Median    float64   `json:"median"`
+	Min       int64     `json:"min"`
+	Max       int64     `json:"max"`
+}
+
+type StatusHistoryPoint struct {
+	Time   time.Time `json:"time"`
+	Total  uint32    `json:"total"`
+	Online uint32    `json:"online"`
+}
+
+type TelemetryHistoryPoint struct {
+	Time               time.Time `json:"time"`
+	TotalProviderSpace *float64  `json:"total_provider_space"`
+	UsedProviderSpace  *float64  `json:"used_provider_space"`
+	FreeSpace          *float64  `json:"free_space"`
+}
+
+type BenchmarkHistoryPoint struct {
+	Time              time.Time `json:"time"`
+	DiskReadSpeed     *float64  `json:"qd64_disk_read_speed"`  // bytes/s
+	DiskWriteSpeed    *float64  `json:"qd64_disk_write_speed"` // bytes/s
+	SpeedtestDownload *float64  `json:"speedtest_download"`
+	SpeedtestUpload   *float64  `json:"speedtest_upload"`
+	SpeedtestPing     *float64  `json:"speedtest_ping"`
+}
+
+// RatingInputs is the joined provider, telemetry and benchmarks row used to calculate rating
+type RatingInputs struct {
+	PubKey             string    `json:"public_key"`
+	RegisteredAt       time.Time `json:"registered_at"`
+	Uptime             float64   `json:"uptime"` // 0..1 over configured window
+	MaxSpan            int64     `json:"max_span"`
+	MinSpan            int64     `json:"min_span"`
+	MaxBagSizeBytes    int64     `json:"max_bag_size_bytes"`
+	RatePerMBDay       *int64    `json:"rate_per_mb_per_day"`
+	TotalProviderSpace *float64  `json:"total_provider_space"`
+	CPUNumber          *int32    `json:"cpu_number"`
+	TotalRAM           *float64  `json:"total_ram"`
+	DiskWriteSpeed     *int64    `json:"qd64_disk_write_speed"` // bytes/s
+	DiskReadSpeed      *int64    `json:"qd64_disk_read_speed"`  // bytes/s
+	SpeedtestDownload  *float64  `json:"speedtest_download"`
+	SpeedtestUpload    *float64  `json:"speedtest_upload"`
+	SpeedtestPing      *float64  `json:"speedtest_ping"`
+	RetrievalP50Ms     *float64  `json:"retrieval_p50_ms"` // median time to get a valid piece from provider
+}
+
+type ProviderRating struct {
+	PubKey string  `json:"public_key"`
+	Rating float64 `json:"rating"`
+}
+
+type ProviderStatusChange struct {
+	PubKey    string  `json:"public_key"`
+	OldStatus *uint32 `json:"old_status"`
+	NewStatus *uint32 `json:"new_status"`
+}
+
+// WebhookEvent is sent as is in the webhook request body
+type WebhookEvent struct {
+	Type            string         `json:"type"`
+	ProviderPubKey  string         `json:"provider_pubkey"`
+	ContractAddress string         `json:"contract_address,omitempty"`
+	BagID           string         `json:"bag_id,omitempty"`
+	IsOnline        *bool          `json:"is_online,omitempty"`
+	OldStatus       *uint32        `json:"old_status,omitempty"` // empty if there were no failed checks
+	NewStatus       *uint32        `json:"new_status,omitempty"` // empty if there are no failed checks
+	OldRates        *ProviderRates `json:"old_rates,omitempty"`
+	NewRates        *ProviderRates `json:"new_rates,omitempty"`
+	Timestamp       int64          `json:"timestamp"` // Unix timestamp in seconds
+}
+
+type WebhookSubscription struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Providers []string  `json:"providers"`
+	Contracts []string  `json:"contracts"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	URL            string `json:"url"`
+	Secret         string `json:"secret"`
+	EventType      string `json:"event_type"`
+	Payload        []byte `json:"payload"`
+	Attempts       int    `json:"attempts"`
+}
+
+type WebhookDeliveryResult struct {
+	ID            int64     `json:"id"`
+	Delivered     bool      `json:"delivered"`
+	Failed        bool      `json:"failed"` // no attempts left
+	Error         string    `json:"error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+type JobRun struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Manual     bool       `json:"manual"`
+	InstanceID *string    `json:"instance_id"` // empty for runs saved before instances were recorded
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"` // empty while the job is running
+	Error      *string    `json:"error"`
+	NextRunAt  *time.Time `json:"next_run_at"`
+}
+
+type JobTrigger struct {
+	Name        string    `json:"name"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// JobStatus is the last run of the job
+type JobStatus struct {
+	JobRun
+	LastSuccessAt *time.Time `json:"last_success_at"`
+}
+
+type Audit struct {
+	ID                int64      `json:"id"`
+	ContractAddress   string     `json:"contract_address"`
+	ProviderAddress   string     `json:"provider_address"`
+	ProviderPublicKey string     `json:"provider_public_key"`
+	BagID             string     `json:"bag_id"`
+	Status            string     `json:"status"`
+	Error             *string    `json:"error"`
+	PiecesTotal       *int32     `json:"pieces_total"` // empty until bag info is received
+	PiecesChecked     int32      `json:"pieces_checked"`
+	MissingPieces     []int32    `json:"missing_pieces"` // provider didn't return the piece
+	InvalidPieces     []int32    `json:"invalid_pieces"` // piece proof is not valid
+	BytesReceived     int64      `json:"bytes_received"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StartedAt         *time.Time `json:"started_at"`
+	FinishedAt        *time.Time `json:"finished_at"`
+}
+
+type AuditResult struct {
+	ID            int64   `json:"id"`
+	Status        string  `json:"status"`
+	Error         string  `json:"error"`
+	PiecesTotal   *int32  `json:"pieces_total"`
+	PiecesChecked int32   `json:"pieces_checked"`
+	MissingPieces []int32 `json:"missing_pieces"`
+	InvalidPieces []int32 `json:"invalid_pieces"`
+	BytesReceived int64   `json:"bytes_received"`
 }
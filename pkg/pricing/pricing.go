@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"math/big"
+)
+
+const (
+	// StorageFee is kept on contract balance for proof transaction fees, 0.05 TON
+	StorageFee = 50_000_000
+	bytesPerMB = 1024 * 1024
+	secPerDay  = 24 * 60 * 60
+)
+
+// Bounty is provider reward in NanoTON for storing bag for span seconds, it's paid on each proof
+func Bounty(ratePerMBDay uint64, bagSize uint64, span uint32) *big.Int {
+	mul := new(big.Int).Mul(new(big.Int).SetUint64(ratePerMBDay), new(big.Int).SetUint64(bagSize))
+	mul = mul.Mul(mul, new(big.Int).SetUint64(uint64(span)))
+
+	return mul.Div(mul, big.NewInt(secPerDay*bytesPerMB))
+}
+
+// SpanBalance is contract balance required for provider to keep the bag for the next span
+func SpanBalance(ratePerMBDay uint64, bagSize uint64, span uint32) *big.Int {
+	b := Bounty(ratePerMBDay, bagSize, span)
+	return b.Add(b, big.NewInt(StorageFee))
+}
+
+// Rates are provider storage terms
+type Rates struct {
+	RatePerMBDay uint64
+	MinBounty    uint64
+	MinSpan      uint32
+	MaxSpan      uint32
+}
+
+// Quote is cost of storing bag by one provider
+type Quote struct {
+	Span   uint32
+	Proofs uint64
+	Bounty *big.Int // per proof
+	Cost   *big.Int // bounties of all proofs and storage fee
+}
+
+// QuoteStorage calculates cost of storing bag for duration seconds. Span is picked by provider
+// rates if it's zero. It returns false if provider doesn't accept such contract
+func QuoteStorage(r Rates, bagSize uint64, duration uint64, span uint32) (q Quote, ok bool) {
+	if bagSize == 0 || duration == 0 {
+		return
+	}
+
+	if span == 0 {
+		span = uint32(min(duration, uint64(r.MaxSpan)))
+		span = max(span, r.MinSpan)
+	}
+
+	if span == 0 || span < r.MinSpan || span > r.MaxSpan {
+		return
+	}
+
+	bounty := Bounty(r.RatePerMBDay, bagSize, span)
+	if bounty.Cmp(new(big.Int).SetUint64(r.MinBounty)) < 0 {
+		return
+	}
+
+	proofs := (duration + uint64(span) - 1) / uint64(span)
+	cost := new(big.Int).Mul(bounty, new(big.Int).SetUint64(proofs))
+	cost = cost.Add(cost, big.NewInt(StorageFee))
+
+	q = Quote{
+		Span:   span,
+		Proofs: proofs,
+		Bounty: bounty,
+		Cost:   cost,
+	}
+	ok = true
+
+	return
+}
+
+// DaysLeft is how many days contract balance pays providers with given rates, storage fee is kept
+// on balance. It returns false if providers don't charge anything
+func DaysLeft(balance uint64, bagSize uint64, ratesPerMBDay []uint64) (days float64, ok bool) {
+	daily := new(big.Int)
+	for _, rate := range ratesPerMBDay {
+		daily = daily.Add(daily, Bounty(rate, bagSize, secPerDay))
+	}
+
+	if daily.Sign() == 0 {
+		return
+	}
+
+	left := new(big.Int).Sub(new(big.Int).SetUint64(balance), big.NewInt(StorageFee))
+	if left.Sign() < 0 {
+		left.SetInt64(0)
+	}
+
+	days, _ = new(big.Rat).SetFrac(left, daily).Float64()
+	ok = true
+
+	return
+}
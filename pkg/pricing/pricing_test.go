@@ -0,0 +1,139 @@
+package pricing
+
+import (
+	"testing"
+)
+
+const (
+	mb  = 1024 * 1024
+	day = 24 * 60 * 60
+)
+
+func Test_SpanBalance(t *testing.T) {
+	// 100 MB for a day at 1000 NanoTON per MB per day
+	if got := SpanBalance(1000, 100*mb, day).Int64(); got != 100_000+StorageFee {
+		t.Fatalf("span balance = %d, want %d", got, 100_000+StorageFee)
+	}
+}
+
+func Test_QuoteStorage(t *testing.T) {
+	rates := Rates{
+		RatePerMBDay: 1_000_000,
+		MinBounty:    50_000_000,
+		MinSpan:      3600,
+		MaxSpan:      day,
+	}
+
+	tests := []struct {
+		name       string
+		rates      Rates
+		bagSize    uint64
+		duration   uint64
+		span       uint32
+		ok         bool
+		wantSpan   uint32
+		wantProofs uint64
+		wantCost   int64
+	}{
+		{
+			name:       "span is max span",
+			rates:      rates,
+			bagSize:    100 * mb,
+			duration:   30 * day,
+			ok:         true,
+			wantSpan:   day,
+			wantProofs: 30,
+			wantCost:   30*100_000_000 + StorageFee,
+		},
+		{
+			name:       "span is duration",
+			rates:      rates,
+			bagSize:    100 * mb,
+			duration:   day / 2,
+			ok:         true,
+			wantSpan:   day / 2,
+			wantProofs: 1,
+			wantCost:   50_000_000 + StorageFee,
+		},
+		{
+			name:       "last span is paid in full",
+			rates:      rates,
+			bagSize:    100 * mb,
+			duration:   day + 1,
+			ok:         true,
+			wantSpan:   day,
+			wantProofs: 2,
+			wantCost:   2*100_000_000 + StorageFee,
+		},
+		{
+			name:       "requested span",
+			rates:      rates,
+			bagSize:    100 * mb,
+			duration:   day,
+			span:       day / 2,
+			ok:         true,
+			wantSpan:   day / 2,
+			wantProofs: 2,
+			wantCost:   2*50_000_000 + StorageFee,
+		},
+		{
+			name:     "span is too short",
+			rates:    rates,
+			bagSize:  100 * mb,
+			duration: day,
+			span:     60,
+		},
+		{
+			name:     "span is too long",
+			rates:    rates,
+			bagSize:  100 * mb,
+			duration: 30 * day,
+			span:     2 * day,
+		},
+		{
+			name:     "bounty is too low",
+			rates:    rates,
+			bagSize:  mb,
+			duration: day,
+		},
+		{
+			name:     "empty bag",
+			rates:    rates,
+			duration: day,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, ok := QuoteStorage(tt.rates, tt.bagSize, tt.duration, tt.span)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if q.Span != tt.wantSpan || q.Proofs != tt.wantProofs || q.Cost.Int64() != tt.wantCost {
+				t.Fatalf("quote = span %d, proofs %d, cost %s, want span %d, proofs %d, cost %d",
+					q.Span, q.Proofs, q.Cost, tt.wantSpan, tt.wantProofs, tt.wantCost)
+			}
+		})
+	}
+}
+
+func Test_DaysLeft(t *testing.T) {
+	// two providers take 100_000 and 300_000 NanoTON per day for 100 MB
+	days, ok := DaysLeft(StorageFee+2_000_000, 100*mb, []uint64{1000, 3000})
+	if !ok || days != 5 {
+		t.Fatalf("days left = %f, %t, want 5", days, ok)
+	}
+
+	if days, ok = DaysLeft(StorageFee/2, 100*mb, []uint64{1000}); !ok || days != 0 {
+		t.Fatalf("days left of empty balance = %f, %t, want 0", days, ok)
+	}
+
+	if _, ok = DaysLeft(StorageFee, 100*mb, nil); ok {
+		t.Fatal("days left without providers must not be estimated")
+	}
+}
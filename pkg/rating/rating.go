@@ -0,0 +1,179 @@
+package rating
+
+import (
+	"math"
+	"time"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+// Rating components names
+const (
+	RegTimeComponent       = "reg_time"
+	SpanComponent          = "span"
+	MaxBagSizeComponent    = "max_bag_size"
+	ProviderSpaceComponent = "provider_space"
+	CPUComponent           = "cpu"
+	RAMComponent           = "ram"
+	DiskWriteComponent     = "disk_write_speed"
+	DiskReadComponent      = "disk_read_speed"
+	DownloadComponent      = "speedtest_download"
+	UploadComponent        = "speedtest_upload"
+	PingComponent          = "speedtest_ping"
+	RetrievalComponent     = "retrieval_latency"
+)
+
+// Coefficients of the rating formula, loaded from env.
+// Defaults give the same rating as the formula used before in SQL.
+type Coefficients struct {
+	RegTime       float64 `env:"RATING_REG_TIME" envDefault:"0.0001"`            // per second of registration unix time
+	Span          float64 `env:"RATING_SPAN" envDefault:"0.00002"`               // per second of max_span - min_span
+	MaxBagSize    float64 `env:"RATING_MAX_BAG_SIZE" envDefault:"0"`             // per byte, disabled for now
+	ProviderSpace float64 `env:"RATING_PROVIDER_SPACE" envDefault:"0.000000004"` // per total provider space unit
+	CPU           float64 `env:"RATING_CPU" envDefault:"1.9"`                    // per cpu
+	CPUMax        float64 `env:"RATING_CPU_MAX" envDefault:"128"`
+	RAM           float64 `env:"RATING_RAM" envDefault:"0.0000006"`
+	DiskWrite     float64 `env:"RATING_DISK_WRITE" envDefault:"0.00008"` // per byte/s
+	DiskRead      float64 `env:"RATING_DISK_READ" envDefault:"0.00008"`  // per byte/s
+	Download      float64 `env:"RATING_DOWNLOAD" envDefault:"0.00001"`
+	Upload        float64 `env:"RATING_UPLOAD" envDefault:"0.00004"`
+	// Ping component is PingNumerator / ping, or PingDefault if there is no ping
+	PingNumerator float64 `env:"RATING_PING_NUMERATOR" envDefault:"400"`
+	PingDefault   float64 `env:"RATING_PING_DEFAULT" envDefault:"1"`
+	// Retrieval component is RetrievalNumerator / p50 of measured piece retrieval time in ms,
+	// or RetrievalDefault if provider has no successful checks, disabled by default
+	RetrievalNumerator float64 `env:"RATING_RETRIEVAL_NUMERATOR" envDefault:"0"`
+	RetrievalDefault   float64 `env:"RATING_RETRIEVAL_DEFAULT" envDefault:"0"`
+
+	// Sum of components is multiplied by uptime^(UptimeExponent + min(age / UptimeAgePeriod, UptimeAgeExponentMax)),
+	// so the longer provider works, the more uptime matters
+	UptimeExponent       float64       `env:"RATING_UPTIME_EXPONENT" envDefault:"2"`
+	UptimeAgePeriod      time.Duration `env:"RATING_UPTIME_AGE_PERIOD" envDefault:"2160h"`
+	UptimeAgeExponentMax float64       `env:"RATING_UPTIME_AGE_EXPONENT_MAX" envDefault:"6"`
+
+	// and divided by max(log10(rate_per_mb_per_day / PriceRateDivider), PriceDivisorMin)
+	PriceRateDivider int64   `env:"RATING_PRICE_RATE_DIVIDER" envDefault:"100"`
+	PriceDivisorMin  float64 `env:"RATING_PRICE_DIVISOR_MIN" envDefault:"1"`
+
+	Divider float64 `env:"RATING_DIVIDER" envDefault:"10000"`
+}
+
+type Component struct {
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`
+	Coefficient  float64 `json:"coefficient"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Result is the rating with all intermediate values:
+// Score = Base * UptimeFactor / PriceDivisor / Divider
+type Result struct {
+	Components     []Component `json:"components"`
+	Base           float64     `json:"base"`
+	UptimeExponent float64     `json:"uptime_exponent"`
+	UptimeFactor   float64     `json:"uptime_factor"`
+	PriceDivisor   float64     `json:"price_divisor"`
+	Divider        float64     `json:"divider"`
+	Score          float64     `json:"score"`
+}
+
+type Scorer interface {
+	Score(in db.RatingInputs, now time.Time) Result
+}
+
+type scorer struct {
+	c Coefficients
+}
+
+func (s *scorer) Score(in db.RatingInputs, now time.Time) (r Result) {
+	r.Components = []Component{
+		linear(RegTimeComponent, float64(in.RegisteredAt.UnixMicro())/1e6, s.c.RegTime),
+		linear(SpanComponent, float64(in.MaxSpan-in.MinSpan), s.c.Span),
+		linear(MaxBagSizeComponent, float64(in.MaxBagSizeBytes), s.c.MaxBagSize),
+		linear(ProviderSpaceComponent, value(in.TotalProviderSpace), s.c.ProviderSpace),
+		linear(CPUComponent, math.Min(float64(value(in.CPUNumber)), s.c.CPUMax), s.c.CPU),
+		linear(RAMComponent, value(in.TotalRAM), s.c.RAM),
+		linear(DiskWriteComponent, float64(value(in.DiskWriteSpeed)), s.c.DiskWrite),
+		linear(DiskReadComponent, float64(value(in.DiskReadSpeed)), s.c.DiskRead),
+		linear(DownloadComponent, value(in.SpeedtestDownload), s.c.Download),
+		linear(UploadComponent, value(in.SpeedtestUpload), s.c.Upload),
+		s.ping(value(in.SpeedtestPing)),
+		inverse(RetrievalComponent, value(in.RetrievalP50Ms), s.c.RetrievalNumerator, s.c.RetrievalDefault),
+	}
+
+	for _, c := range r.Components {
+		r.Base += c.Contribution
+	}
+
+	age := now.Sub(in.RegisteredAt)
+	r.UptimeExponent = s.c.UptimeExponent
+	if s.c.UptimeAgePeriod > 0 {
+		r.UptimeExponent += math.Min(age.Seconds()/s.c.UptimeAgePeriod.Seconds(), s.c.UptimeAgeExponentMax)
+	}
+	r.UptimeFactor = math.Pow(in.Uptime, r.UptimeExponent)
+
+	r.PriceDivisor = s.priceDivisor(in.RatePerMBDay)
+	r.Divider = s.c.Divider
+
+	r.Score = r.Base * r.UptimeFactor / r.PriceDivisor / r.Divider
+	if math.IsNaN(r.Score) || math.IsInf(r.Score, 0) {
+		r.Score = 0
+	}
+
+	return
+}
+
+func (s *scorer) ping(ping float64) Component {
+	return inverse(PingComponent, ping, s.c.PingNumerator, s.c.PingDefault)
+}
+
+func (s *scorer) priceDivisor(ratePerMBDay *int64) float64 {
+	rate := int64(1)
+	if ratePerMBDay != nil && s.c.PriceRateDivider > 0 {
+		// integer division, as it was in SQL
+		if r := *ratePerMBDay / s.c.PriceRateDivider; r > 0 {
+			rate = r
+		}
+	}
+
+	return math.Max(math.Log10(float64(rate)), s.c.PriceDivisorMin)
+}
+
+// inverse rewards lower values, default is used when value is unknown
+func inverse(name string, v, numerator, def float64) Component {
+	c := Component{
+		Name:         name,
+		Value:        v,
+		Coefficient:  numerator,
+		Contribution: def,
+	}
+
+	if v > 0 {
+		c.Contribution = numerator / v
+	}
+
+	return c
+}
+
+func linear(name string, v, coefficient float64) Component {
+	return Component{
+		Name:         name,
+		Value:        v,
+		Coefficient:  coefficient,
+		Contribution: v * coefficient,
+	}
+}
+
+func value[T int32 | int64 | float64](v *T) T {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
+
+func NewScorer(c Coefficients) Scorer {
+	return &scorer{
+		c: c,
+	}
+}
@@ -0,0 +1,172 @@
+package rating
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+var registeredAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func defaultCoefficients(t *testing.T) Coefficients {
+	t.Helper()
+
+	var c Coefficients
+	if err := env.Parse(&c); err != nil {
+		t.Fatalf("failed to parse default coefficients: %v", err)
+	}
+
+	return c
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// Expected values are calculated with the SQL formula used before the rating package
+func Test_Score(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           db.RatingInputs
+		now          time.Time
+		wantBase     float64
+		wantExponent float64
+		wantDivisor  float64
+		wantScore    float64
+	}{
+		{
+			name: "full provider",
+			in: db.RatingInputs{
+				RegisteredAt:       registeredAt,
+				Uptime:             0.9,
+				MaxSpan:            2592000,
+				MinSpan:            3600,
+				MaxBagSizeBytes:    1 << 40,
+				RatePerMBDay:       ptr(int64(10000)),
+				TotalProviderSpace: ptr(1000.0),
+				CPUNumber:          ptr(int32(16)),
+				TotalRAM:           ptr(32.0),
+				DiskWriteSpeed:     ptr(int64(524288000)),
+				DiskReadSpeed:      ptr(int64(1073741824)),
+				SpeedtestDownload:  ptr(1e9),
+				SpeedtestUpload:    ptr(5e8),
+				SpeedtestPing:      ptr(20.0),
+			},
+			now:          registeredAt.Add(45 * 24 * time.Hour),
+			wantBase:     328351.2739432,
+			wantExponent: 2.5,
+			wantDivisor:  2,
+			wantScore:    12.615805464082671,
+		},
+		{
+			name: "no telemetry and benchmarks",
+			in: db.RatingInputs{
+				RegisteredAt: registeredAt,
+				Uptime:       1,
+			},
+			now:          registeredAt.Add(1000 * 24 * time.Hour),
+			wantBase:     170407.72,
+			wantExponent: 8,
+			wantDivisor:  1,
+			wantScore:    17.040772,
+		},
+		{
+			name: "cpu limit and low price",
+			in: db.RatingInputs{
+				RegisteredAt: registeredAt,
+				Uptime:       0.5,
+				RatePerMBDay: ptr(int64(50)),
+				CPUNumber:    ptr(int32(256)),
+			},
+			now:          registeredAt.Add(10 * 24 * time.Hour),
+			wantBase:     170650.92,
+			wantExponent: 2 + 10.0/90,
+			wantDivisor:  1,
+			wantScore:    3.950034286414035,
+		},
+		{
+			name: "zero uptime",
+			in: db.RatingInputs{
+				RegisteredAt: registeredAt,
+				Uptime:       0,
+				RatePerMBDay: ptr(int64(10000000)),
+			},
+			now:          registeredAt.Add(90 * 24 * time.Hour),
+			wantBase:     170407.72,
+			wantExponent: 3,
+			wantDivisor:  5,
+			wantScore:    0,
+		},
+	}
+
+	s := NewScorer(defaultCoefficients(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := s.Score(tt.in, tt.now)
+
+			if !almostEqual(r.Base, tt.wantBase) {
+				t.Errorf("base = %v, want %v", r.Base, tt.wantBase)
+			}
+			if !almostEqual(r.UptimeExponent, tt.wantExponent) {
+				t.Errorf("uptime exponent = %v, want %v", r.UptimeExponent, tt.wantExponent)
+			}
+			if !almostEqual(r.PriceDivisor, tt.wantDivisor) {
+				t.Errorf("price divisor = %v, want %v", r.PriceDivisor, tt.wantDivisor)
+			}
+			if !almostEqual(r.Score, tt.wantScore) {
+				t.Errorf("score = %v, want %v", r.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func Test_ScoreComponents(t *testing.T) {
+	c := defaultCoefficients(t)
+	c.MaxBagSize = 0.00000000008
+	c.RetrievalNumerator = 5000
+
+	in := db.RatingInputs{
+		RegisteredAt:    registeredAt,
+		Uptime:          1,
+		MaxBagSizeBytes: 1 << 30,
+		SpeedtestPing:   ptr(40.0),
+		RetrievalP50Ms:  ptr(250.0),
+	}
+
+	r := NewScorer(c).Score(in, registeredAt)
+
+	var sum float64
+	contributions := make(map[string]float64, len(r.Components))
+	for _, component := range r.Components {
+		sum += component.Contribution
+		contributions[component.Name] = component.Contribution
+	}
+
+	if !almostEqual(sum, r.Base) {
+		t.Fatalf("components sum = %v, base = %v", sum, r.Base)
+	}
+
+	if want := 0.00000000008 * (1 << 30); !almostEqual(contributions[MaxBagSizeComponent], want) {
+		t.Errorf("max bag size contribution = %v, want %v", contributions[MaxBagSizeComponent], want)
+	}
+
+	if want := 400.0 / 40; !almostEqual(contributions[PingComponent], want) {
+		t.Errorf("ping contribution = %v, want %v", contributions[PingComponent], want)
+	}
+
+	if want := 5000.0 / 250; !almostEqual(contributions[RetrievalComponent], want) {
+		t.Errorf("retrieval contribution = %v, want %v", contributions[RetrievalComponent], want)
+	}
+
+	if contributions[CPUComponent] != 0 {
+		t.Errorf("cpu contribution = %v, want 0", contributions[CPUComponent])
+	}
+}
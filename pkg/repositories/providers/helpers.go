@@ -18,11 +18,18 @@ const (
 			p.status_ratio,
 			p.statuses_reason_stats,
 			p.uptime * 100 as uptime,
+			p.uptime_24h * 100 as uptime_24h,
+			p.uptime_7d * 100 as uptime_7d,
+			p.uptime_30d * 100 as uptime_30d,
 			p.rating,
 			p.max_span,
 			p.rate_per_mb_per_day * 1024 * 200 * 30 as price, -- NanoTON per 200GB per month
 			p.min_span,
 			p.max_bag_size_bytes,
+			p.retrieval_p50_ms,
+			p.retrieval_p95_ms,
+			p.rtt_p50_ms,
+			p.rtt_p95_ms,
 			p.registered_at,
 			CASE
 				WHEN p.ip_info - 'ip' <> '{}'::jsonb THEN p.ip_info - 'ip'
@@ -40,6 +47,7 @@ const (
 			t.usage_ram,
 			t.ram_usage_percent,
 			t.updated_at,
+			t.is_signed,
 			b.qd64_disk_read_speed,
 			b.qd64_disk_write_speed,
 			b.speedtest_download,
@@ -94,6 +102,9 @@ func filtersToCondition(filters db.ProviderFilters, args []any) (condition strin
 	if filters.RatingLt != nil {
 		condition += fmt.Sprintf(" AND p.rating <= %f", *filters.RatingLt)
 	}
+	if filters.RTTP50MsLt != nil {
+		condition += fmt.Sprintf(" AND p.rtt_p50_ms <= %f", *filters.RTTP50MsLt)
+	}
 	if filters.RegTimeDaysGt != nil {
 		condition += fmt.Sprintf(" AND p.registered_at <= NOW() - INTERVAL '%d days'", *filters.RegTimeDaysGt)
 	}
@@ -108,6 +119,24 @@ func filtersToCondition(filters db.ProviderFilters, args []any) (condition strin
 		uptime := *filters.UpTimeLtPercent / 100.0
 		condition += fmt.Sprintf(" AND p.uptime <= %f", uptime)
 	}
+	if filters.UpTime24hGtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_24h >= %f", *filters.UpTime24hGtPercent/100.0)
+	}
+	if filters.UpTime24hLtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_24h <= %f", *filters.UpTime24hLtPercent/100.0)
+	}
+	if filters.UpTime7dGtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_7d >= %f", *filters.UpTime7dGtPercent/100.0)
+	}
+	if filters.UpTime7dLtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_7d <= %f", *filters.UpTime7dLtPercent/100.0)
+	}
+	if filters.UpTime30dGtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_30d >= %f", *filters.UpTime30dGtPercent/100.0)
+	}
+	if filters.UpTime30dLtPercent != nil {
+		condition += fmt.Sprintf(" AND p.uptime_30d <= %f", *filters.UpTime30dLtPercent/100.0)
+	}
 	if filters.WorkingTimeGtSec != nil {
 		condition += fmt.Sprintf(" AND p.working_time >= %d", *filters.WorkingTimeGtSec)
 	}
@@ -256,11 +285,18 @@ func scanProviderDBRows(rows pgx.Rows) (providers []db.ProviderDB, err error) {
 			&provider.StatusRatio,
 			&provider.StatusesReasonStats,
 			&provider.UpTime,
+			&provider.UpTime24h,
+			&provider.UpTime7d,
+			&provider.UpTime30d,
 			&provider.Rating,
 			&provider.MaxSpan,
 			&provider.Price,
 			&provider.MinSpan,
 			&provider.MaxBagSizeBytes,
+			&provider.RetrievalP50Ms,
+			&provider.RetrievalP95Ms,
+			&provider.RTTP50Ms,
+			&provider.RTTP95Ms,
 			&regTime,
 			&location,
 			&provider.IsSendTelemetry,
@@ -275,6 +311,7 @@ func scanProviderDBRows(rows pgx.Rows) (providers []db.ProviderDB, err error) {
 			&provider.Telemetry.UsageRAM,
 			&provider.Telemetry.UsageRAMPercent,
 			&updatedAt,
+			&provider.Telemetry.IsSigned,
 			&provider.Telemetry.BenchmarkDiskReadSpeed,
 			&provider.Telemetry.BenchmarkDiskWriteSpeed,
 			&provider.Telemetry.SpeedtestDownload,
@@ -309,3 +346,25 @@ func scanProviderDBRows(rows pgx.Rows) (providers []db.ProviderDB, err error) {
 
 	return
 }
+
+func scanAudit(row pgx.Row) (audit db.Audit, err error) {
+	err = row.Scan(
+		&audit.ID,
+		&audit.ContractAddress,
+		&audit.ProviderAddress,
+		&audit.ProviderPublicKey,
+		&audit.BagID,
+		&audit.Status,
+		&audit.Error,
+		&audit.PiecesTotal,
+		&audit.PiecesChecked,
+		&audit.MissingPieces,
+		&audit.InvalidPieces,
+		&audit.BytesReceived,
+		&audit.CreatedAt,
+		&audit.StartedAt,
+		&audit.FinishedAt,
+	)
+
+	return
+}
@@ -71,7 +71,7 @@ func (m *metricsMiddleware) UpdateBenchmarks(ctx context.Context, benchmarks []d
 	return m.repo.UpdateBenchmarks(ctx, benchmarks)
 }
 
-func (m *metricsMiddleware) AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (err error) {
+func (m *metricsMiddleware) AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (changed []db.ProviderStatusUpdate, err error) {
 	defer func(s time.Time) {
 		labels := []string{
 			"AddStatuses", strconv.FormatBool(err != nil),
@@ -93,6 +93,39 @@ func (m *metricsMiddleware) GetStorageContractsChecks(ctx context.Context, contr
 	return m.repo.GetStorageContractsChecks(ctx, contracts)
 }
 
+func (m *metricsMiddleware) GetBagContracts(ctx context.Context, bagID string) (contracts []db.BagContract, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetBagContracts", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetBagContracts(ctx, bagID)
+}
+
+func (m *metricsMiddleware) GetOwnerContracts(ctx context.Context, owner string, withHistory bool, limit, offset int) (contracts []db.OwnerContract, total uint64, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetOwnerContracts", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetOwnerContracts(ctx, owner, withHistory, limit, offset)
+}
+
+func (m *metricsMiddleware) GetProviderContracts(ctx context.Context, pubkey string, reasons []uint32, limit, offset int) (contracts []db.ProviderContract, total uint64, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderContracts", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderContracts(ctx, pubkey, reasons, limit, offset)
+}
+
 func (m *metricsMiddleware) UpdateContractProofsChecks(ctx context.Context, contractsProofs []db.ContractProofsCheck) (err error) {
 	defer func(s time.Time) {
 		labels := []string{
@@ -126,7 +159,40 @@ func (m *metricsMiddleware) UpdateUptime(ctx context.Context) (err error) {
 	return m.repo.UpdateUptime(ctx)
 }
 
-func (m *metricsMiddleware) UpdateRating(ctx context.Context) (err error) {
+func (m *metricsMiddleware) UpdateRetrievalLatency(ctx context.Context) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"UpdateRetrievalLatency", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.UpdateRetrievalLatency(ctx)
+}
+
+func (m *metricsMiddleware) UpdateStatusRTT(ctx context.Context) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"UpdateStatusRTT", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.UpdateStatusRTT(ctx)
+}
+
+func (m *metricsMiddleware) GetRatingInputs(ctx context.Context, uptimeWindow string, pubkeys []string) (inputs []db.RatingInputs, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetRatingInputs", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetRatingInputs(ctx, uptimeWindow, pubkeys)
+}
+
+func (m *metricsMiddleware) UpdateRating(ctx context.Context, ratings []db.ProviderRating) (err error) {
 	defer func(s time.Time) {
 		labels := []string{
 			"UpdateRating", strconv.FormatBool(err != nil),
@@ -134,7 +200,7 @@ func (m *metricsMiddleware) UpdateRating(ctx context.Context) (err error) {
 		m.reqCount.WithLabelValues(labels...).Add(1)
 		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
 	}(time.Now())
-	return m.repo.UpdateRating(ctx)
+	return m.repo.UpdateRating(ctx, ratings)
 }
 
 func (m *metricsMiddleware) GetAllProvidersPubkeys(ctx context.Context) (pubkeys []string, err error) {
@@ -181,7 +247,7 @@ func (m *metricsMiddleware) AddStorageContracts(ctx context.Context, contracts [
 	return m.repo.AddStorageContracts(ctx, contracts)
 }
 
-func (m *metricsMiddleware) UpdateStatuses(ctx context.Context) (err error) {
+func (m *metricsMiddleware) UpdateStatuses(ctx context.Context) (changed []db.ProviderStatusChange, err error) {
 	defer func(s time.Time) {
 		labels := []string{
 			"UpdateStatuses", strconv.FormatBool(err != nil),
@@ -214,7 +280,18 @@ func (m *metricsMiddleware) UpdateRejectedStorageContracts(ctx context.Context,
 	return m.repo.UpdateRejectedStorageContracts(ctx, storageContracts)
 }
 
-func (m *metricsMiddleware) UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (err error) {
+func (m *metricsMiddleware) UpdateContractsBalances(ctx context.Context, balances []db.ContractBalance) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"UpdateContractsBalances", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.UpdateContractsBalances(ctx, balances)
+}
+
+func (m *metricsMiddleware) UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (changed []db.ProviderPriceChange, err error) {
 	defer func(s time.Time) {
 		labels := []string{
 			"UpdateProviders", strconv.FormatBool(err != nil),
@@ -258,6 +335,50 @@ func (m *metricsMiddleware) UpdateProvidersIPInfo(ctx context.Context, ips []db.
 	return m.repo.UpdateProvidersIPInfo(ctx, ips)
 }
 
+func (m *metricsMiddleware) GetProviderHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.ProviderHistoryPoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderHistory(ctx, pubkey, from, to, bucket)
+}
+
+func (m *metricsMiddleware) GetProviderStatusesHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.StatusHistoryPoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderStatusesHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderStatusesHistory(ctx, pubkey, from, to, bucket)
+}
+
+func (m *metricsMiddleware) GetProviderTelemetryHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.TelemetryHistoryPoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderTelemetryHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderTelemetryHistory(ctx, pubkey, from, to, bucket)
+}
+
+func (m *metricsMiddleware) GetProviderBenchmarksHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.BenchmarkHistoryPoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderBenchmarksHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderBenchmarksHistory(ctx, pubkey, from, to, bucket)
+}
+
 func (m *metricsMiddleware) CleanOldProvidersHistory(ctx context.Context, days int) (removed int, err error) {
 	defer func(s time.Time) {
 		labels := []string{
@@ -303,6 +424,116 @@ func (m *metricsMiddleware) CleanOldTelemetryHistory(ctx context.Context, days i
 	return m.repo.CleanOldTelemetryHistory(ctx, days)
 }
 
+func (m *metricsMiddleware) GetStorageProofsHistory(ctx context.Context, contracts []string, from, to time.Time) (checks []db.StorageProofCheck, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetStorageProofsHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetStorageProofsHistory(ctx, contracts, from, to)
+}
+
+func (m *metricsMiddleware) CleanOldStorageProofsHistory(ctx context.Context, days int) (removed int, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"CleanOldStorageProofsHistory", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.CleanOldStorageProofsHistory(ctx, days)
+}
+
+func (m *metricsMiddleware) AddAudit(ctx context.Context, pubkey string, contract string) (audit db.Audit, found bool, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AddAudit", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AddAudit(ctx, pubkey, contract)
+}
+
+func (m *metricsMiddleware) GetAudit(ctx context.Context, id int64) (audit *db.Audit, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetAudit", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetAudit(ctx, id)
+}
+
+func (m *metricsMiddleware) TakeAudit(ctx context.Context, staleAfter time.Duration) (audit *db.Audit, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"TakeAudit", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.TakeAudit(ctx, staleAfter)
+}
+
+func (m *metricsMiddleware) FinishAudit(ctx context.Context, result db.AuditResult) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"FinishAudit", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.FinishAudit(ctx, result)
+}
+
+func (m *metricsMiddleware) GetProviderPrices(ctx context.Context, pubkey string, from, to time.Time) (points []db.ProviderPricePoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetProviderPrices", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetProviderPrices(ctx, pubkey, from, to)
+}
+
+func (m *metricsMiddleware) GetNetworkPrices(ctx context.Context, from, to time.Time, bucket time.Duration) (points []db.NetworkPricePoint, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetNetworkPrices", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetNetworkPrices(ctx, from, to, bucket)
+}
+
+func (m *metricsMiddleware) GetQuoteCandidates(ctx context.Context, bagSize uint64) (candidates []db.QuoteCandidate, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetQuoteCandidates", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetQuoteCandidates(ctx, bagSize)
+}
+
+func (m *metricsMiddleware) GetNetworkStats(ctx context.Context) (stats db.NetworkStats, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetNetworkStats", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetNetworkStats(ctx)
+}
+
 func NewMetrics(reqCount *prometheus.CounterVec, reqDuration *prometheus.HistogramVec, repo Repository) Repository {
 	return &metricsMiddleware{
 		reqCount:    reqCount,
@@ -3,10 +3,12 @@ package providers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"mytonprovider-backend/pkg/constants"
 	"mytonprovider-backend/pkg/models/db"
 )
 
@@ -20,29 +22,51 @@ type Repository interface {
 	GetFiltersRange(ctx context.Context) (filtersRange db.FiltersRange, err error)
 	UpdateTelemetry(ctx context.Context, telemetry []db.TelemetryUpdate) (err error)
 	UpdateBenchmarks(ctx context.Context, benchmarks []db.BenchmarkUpdate) (err error)
-	AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (err error)
+	AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (changed []db.ProviderStatusUpdate, err error)
 	UpdateProvidersIPs(ctx context.Context, ips []db.ProviderIP) (err error)
 	UpdateUptime(ctx context.Context) (err error)
-	UpdateRating(ctx context.Context) (err error)
+	UpdateRetrievalLatency(ctx context.Context) (err error)
+	UpdateStatusRTT(ctx context.Context) (err error)
+	GetRatingInputs(ctx context.Context, uptimeWindow string, pubkeys []string) (inputs []db.RatingInputs, err error)
+	UpdateRating(ctx context.Context, ratings []db.ProviderRating) (err error)
 	GetAllProvidersPubkeys(ctx context.Context) (pubkeys []string, err error)
 	GetAllProvidersWallets(ctx context.Context) (wallets []db.ProviderWallet, err error)
 	AddStorageContracts(ctx context.Context, contracts []db.StorageContract) (err error)
-	UpdateStatuses(ctx context.Context) (err error)
+	UpdateStatuses(ctx context.Context) (changed []db.ProviderStatusChange, err error)
 	GetStorageContractsChecks(ctx context.Context, contracts []string) (resp []db.ContractCheck, err error)
+	GetBagContracts(ctx context.Context, bagID string) (contracts []db.BagContract, err error)
+	GetOwnerContracts(ctx context.Context, owner string, withHistory bool, limit, offset int) (contracts []db.OwnerContract, total uint64, err error)
+	GetProviderContracts(ctx context.Context, pubkey string, reasons []uint32, limit, offset int) (contracts []db.ProviderContract, total uint64, err error)
 	UpdateContractProofsChecks(ctx context.Context, contractsProofs []db.ContractProofsCheck) (err error)
+	GetStorageProofsHistory(ctx context.Context, contracts []string, from, to time.Time) (checks []db.StorageProofCheck, err error)
+	AddAudit(ctx context.Context, pubkey string, contract string) (audit db.Audit, found bool, err error)
+	GetAudit(ctx context.Context, id int64) (audit *db.Audit, err error)
+	TakeAudit(ctx context.Context, staleAfter time.Duration) (audit *db.Audit, err error)
+	FinishAudit(ctx context.Context, result db.AuditResult) (err error)
 	GetStorageContracts(ctx context.Context) (contracts []db.ContractToProviderRelation, err error)
 	UpdateRejectedStorageContracts(ctx context.Context, storageContracts []db.ContractToProviderRelation) (err error)
+	UpdateContractsBalances(ctx context.Context, balances []db.ContractBalance) (err error)
 	UpdateProvidersLT(ctx context.Context, providers []db.ProviderWalletLT) (err error)
-	UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (err error)
+	UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (changed []db.ProviderPriceChange, err error)
 	AddProviders(ctx context.Context, providers []db.ProviderCreate) (err error)
 
 	GetProvidersIPs(ctx context.Context) (ips []db.ProviderIP, err error)
 	UpdateProvidersIPInfo(ctx context.Context, ips []db.ProviderIPInfo) (err error)
 
+	GetProviderHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.ProviderHistoryPoint, err error)
+	GetProviderPrices(ctx context.Context, pubkey string, from, to time.Time) (points []db.ProviderPricePoint, err error)
+	GetNetworkPrices(ctx context.Context, from, to time.Time, bucket time.Duration) (points []db.NetworkPricePoint, err error)
+	GetQuoteCandidates(ctx context.Context, bagSize uint64) (candidates []db.QuoteCandidate, err error)
+	GetNetworkStats(ctx context.Context) (stats db.NetworkStats, err error)
+	GetProviderStatusesHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.StatusHistoryPoint, err error)
+	GetProviderTelemetryHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.TelemetryHistoryPoint, err error)
+	GetProviderBenchmarksHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.BenchmarkHistoryPoint, err error)
+
 	CleanOldProvidersHistory(ctx context.Context, days int) (removed int, err error)
 	CleanOldStatusesHistory(ctx context.Context, days int) (removed int, err error)
 	CleanOldBenchmarksHistory(ctx context.Context, days int) (removed int, err error)
 	CleanOldTelemetryHistory(ctx context.Context, days int) (removed int, err error)
+	CleanOldStorageProofsHistory(ctx context.Context, days int) (removed int, err error)
 }
 
 func (r *repository) GetProvidersByPubkeys(ctx context.Context, pubkeys []string) (resp []db.ProviderDB, err error) {
@@ -54,11 +78,18 @@ func (r *repository) GetProvidersByPubkeys(ctx context.Context, pubkeys []string
 			p.status_ratio,
 			p.statuses_reason_stats,
 			COALESCE(p.uptime, 0) * 100 as uptime,
+			p.uptime_24h * 100 as uptime_24h,
+			p.uptime_7d * 100 as uptime_7d,
+			p.uptime_30d * 100 as uptime_30d,
 			COALESCE(p.rating, 0) as rating,
 			p.max_span,
 			p.rate_per_mb_per_day * 1024 * 200 * 30 as price, -- NanoTON per 200GB per month
 			p.min_span,
 			p.max_bag_size_bytes,
+			p.retrieval_p50_ms,
+			p.retrieval_p95_ms,
+			p.rtt_p50_ms,
+			p.rtt_p95_ms,
 			p.registered_at,
 			CASE
 				WHEN p.ip_info - 'ip' <> '{}'::jsonb THEN p.ip_info - 'ip'
@@ -76,6 +107,7 @@ func (r *repository) GetProvidersByPubkeys(ctx context.Context, pubkeys []string
 			t.usage_ram,
 			t.ram_usage_percent,
 			t.updated_at,
+			t.is_signed,
 			b.qd64_disk_read_speed,
 			b.qd64_disk_write_speed,
 			b.speedtest_download,
@@ -275,7 +307,8 @@ func (r *repository) UpdateTelemetry(ctx context.Context, telemetry []db.Telemet
 			disks_load,
 			disks_load_percent,
 			iops,
-			pps
+			pps,
+			is_signed
 		)
 		SELECT 
 			lower(t->>'public_key'),
@@ -339,7 +372,8 @@ func (r *repository) UpdateTelemetry(ctx context.Context, telemetry []db.Telemet
 				WHEN jsonb_typeof(t->'pps') = 'array' 
 				THEN ARRAY( SELECT jsonb_array_elements_text(t->'pps')::float8 ) 
 				ELSE '{}'::float8[] 
-			END
+			END,
+			COALESCE((t->>'is_signed')::boolean, false)
 		FROM jsonb_array_elements($1::jsonb) t
 		ON CONFLICT (public_key) DO UPDATE SET
 			storage_git_hash = EXCLUDED.storage_git_hash,
@@ -374,6 +408,7 @@ func (r *repository) UpdateTelemetry(ctx context.Context, telemetry []db.Telemet
 			disks_load_percent = EXCLUDED.disks_load_percent,
 			iops = EXCLUDED.iops,
 			pps = EXCLUDED.pps,
+			is_signed = EXCLUDED.is_signed,
 			updated_at = now()
 	`
 
@@ -399,7 +434,8 @@ func (r *repository) UpdateBenchmarks(ctx context.Context, benchmarks []db.Bench
 			speedtest_upload,
 			speedtest_ping,
 			country,
-			isp
+			isp,
+			is_signed
 		)
 		SELECT
 			lower(b->>'public_key'),
@@ -412,7 +448,8 @@ func (r *repository) UpdateBenchmarks(ctx context.Context, benchmarks []db.Bench
 			(b->>'speedtest_upload')::double precision,
 			(b->>'speedtest_ping')::float8,
 			b->>'country',
-			b->>'isp'
+			b->>'isp',
+			COALESCE((b->>'is_signed')::boolean, false)
 		FROM jsonb_array_elements($1::jsonb) AS b
 		ON CONFLICT (public_key) DO UPDATE SET
 			disk = EXCLUDED.disk,
@@ -424,7 +461,8 @@ func (r *repository) UpdateBenchmarks(ctx context.Context, benchmarks []db.Bench
 			speedtest_upload = EXCLUDED.speedtest_upload,
 			speedtest_ping = EXCLUDED.speedtest_ping,
 			country = EXCLUDED.country,
-			isp = EXCLUDED.isp
+			isp = EXCLUDED.isp,
+			is_signed = EXCLUDED.is_signed
 	`
 
 	_, err = r.db.Exec(ctx, query, benchmarks)
@@ -432,24 +470,57 @@ func (r *repository) UpdateBenchmarks(ctx context.Context, benchmarks []db.Bench
 	return
 }
 
-func (r *repository) AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (err error) {
+// AddStatuses saves providers statuses and returns providers which went online or offline since the previous check
+func (r *repository) AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (changed []db.ProviderStatusUpdate, err error) {
 	if len(providers) == 0 {
 		return
 	}
 
 	query := `
-		INSERT INTO providers.statuses (public_key, is_online, check_time)
-		SELECT
-			lower(p->>'public_key'),
-			(p->>'is_online')::boolean,
-			NOW()
-		FROM jsonb_array_elements($1::jsonb) AS p
-		ON CONFLICT (public_key) DO UPDATE SET
-			is_online = EXCLUDED.is_online,
-			check_time = NOW()
+		WITH new_statuses AS (
+			SELECT
+				lower(p->>'public_key') AS public_key,
+				(p->>'is_online')::boolean AS is_online,
+				(p->>'rtt_ms')::integer AS rtt_ms,
+				p->>'error_class' AS error_class
+			FROM jsonb_array_elements($1::jsonb) AS p
+		), old_statuses AS (
+			SELECT s.public_key, s.is_online
+			FROM providers.statuses s
+				JOIN new_statuses n ON n.public_key = s.public_key
+		), upserted AS (
+			INSERT INTO providers.statuses (public_key, is_online, rtt_ms, error_class, check_time)
+			SELECT public_key, is_online, rtt_ms, error_class, NOW()
+			FROM new_statuses
+			ON CONFLICT (public_key) DO UPDATE SET
+				is_online = EXCLUDED.is_online,
+				rtt_ms = EXCLUDED.rtt_ms,
+				error_class = EXCLUDED.error_class,
+				check_time = NOW()
+			RETURNING public_key, is_online
+		)
+		SELECT u.public_key, u.is_online
+		FROM upserted u
+			JOIN old_statuses o ON o.public_key = u.public_key
+		WHERE o.is_online <> u.is_online
 	`
 
-	_, err = r.db.Exec(ctx, query, providers)
+	rows, err := r.db.Query(ctx, query, providers)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s db.ProviderStatusUpdate
+		if rErr := rows.Scan(&s.Pubkey, &s.IsOnline); rErr != nil {
+			err = rErr
+			return
+		}
+		changed = append(changed, s)
+	}
+
+	err = rows.Err()
 
 	return
 }
@@ -496,12 +567,21 @@ func (r *repository) UpdateUptime(ctx context.Context) (err error) {
 			SELECT
 				public_key,
 				count(*) AS total,
-				count(*) filter (where is_online) AS online
+				count(*) filter (where is_online) AS online,
+				count(*) filter (where check_time > NOW() - INTERVAL '24 hours') AS total_24h,
+				count(*) filter (where is_online AND check_time > NOW() - INTERVAL '24 hours') AS online_24h,
+				count(*) filter (where check_time > NOW() - INTERVAL '7 days') AS total_7d,
+				count(*) filter (where is_online AND check_time > NOW() - INTERVAL '7 days') AS online_7d,
+				count(*) filter (where check_time > NOW() - INTERVAL '30 days') AS total_30d,
+				count(*) filter (where is_online AND check_time > NOW() - INTERVAL '30 days') AS online_30d
 			FROM providers.statuses_history
 			GROUP BY public_key
 		)
 		UPDATE providers.providers p
-		SET uptime = COALESCE((SELECT pu.online::float8 / pu.total), 0)
+		SET uptime = COALESCE((SELECT pu.online::float8 / pu.total), 0),
+			uptime_24h = COALESCE(pu.online_24h::float8 / NULLIF(pu.total_24h, 0), 0),
+			uptime_7d = COALESCE(pu.online_7d::float8 / NULLIF(pu.total_7d, 0), 0),
+			uptime_30d = COALESCE(pu.online_30d::float8 / NULLIF(pu.total_30d, 0), 0)
 		FROM provider_uptime pu
 		WHERE p.public_key = pu.public_key
 	`
@@ -511,60 +591,159 @@ func (r *repository) UpdateUptime(ctx context.Context) (err error) {
 	return
 }
 
-func (r *repository) UpdateRating(ctx context.Context) (err error) {
+// UpdateRetrievalLatency sets providers p50/p95 time of piece request in successful checks for the last 7 days
+func (r *repository) UpdateRetrievalLatency(ctx context.Context) (err error) {
 	query := `
-		WITH params AS (
-			SELECT 
-				p.public_key,
-				p.registered_at,
-				p.uptime,
-				p.max_span,
-				p.min_span,
-				0 as max_bag_size_bytes, -- p.max_bag_size_bytes 
-				p.rate_per_mb_per_day,
-				t.total_provider_space,
-				t.cpu_number,
-				t.total_ram,
-				b.qd64_disk_write_speed,
-				b.qd64_disk_read_speed,
-				b.speedtest_download,
-				b.speedtest_upload,
-				b.speedtest_ping
-			FROM providers.providers p
-				LEFT JOIN providers.telemetry t ON p.public_key = t.public_key
-				LEFT JOIN providers.benchmarks b ON p.public_key = b.public_key
-			WHERE p.is_initialized
+		WITH latency AS (
+			SELECT
+				provider_address,
+				percentile_cont(0.5) WITHIN GROUP (ORDER BY piece_ms) AS p50,
+				percentile_cont(0.95) WITHIN GROUP (ORDER BY piece_ms) AS p95
+			FROM providers.storage_proofs_history
+			WHERE reason = 0
+				AND piece_ms IS NOT NULL
+				AND checked_at > NOW() - INTERVAL '7 days'
+			GROUP BY provider_address
 		)
 		UPDATE providers.providers p
-		SET rating = (
-		(
-			(
-				0.0001 * EXTRACT(EPOCH FROM pr.registered_at) +
-				0.00002 * (COALESCE(pr.max_span, 0) - COALESCE(pr.min_span, 0)) +
-				0.00000000008 * COALESCE(pr.max_bag_size_bytes, 0) +
-				0.000000004 * COALESCE(pr.total_provider_space, 0) +
-				1.9 * LEAST(COALESCE(pr.cpu_number, 0), 128) +
-				0.0000006 * COALESCE(pr.total_ram, 0) +
-				0.00008 * COALESCE(providers.parse_speed_to_int(pr.qd64_disk_write_speed), 0) +
-				0.00008 * COALESCE(providers.parse_speed_to_int(pr.qd64_disk_read_speed), 0) +
-				0.00001 * COALESCE(pr.speedtest_download, 0) +
-				0.00004 * COALESCE(pr.speedtest_upload, 0) +
-				CASE WHEN COALESCE(pr.speedtest_ping, 0) > 0 THEN 400 / pr.speedtest_ping ELSE 1 END
-			)
-			* POWER(COALESCE(pr.uptime, 0.01), 
-				2 + LEAST(EXTRACT(EPOCH FROM NOW() - pr.registered_at) / (86400.0 * 90), 6)
-			)
+		SET retrieval_p50_ms = l.p50,
+			retrieval_p95_ms = l.p95
+		FROM providers.providers pp
+			LEFT JOIN latency l ON l.provider_address = pp.address
+		WHERE p.public_key = pp.public_key
+			AND (p.retrieval_p50_ms IS DISTINCT FROM l.p50 OR p.retrieval_p95_ms IS DISTINCT FROM l.p95)
+	`
+
+	_, err = r.db.Exec(ctx, query)
+
+	return
+}
+
+// UpdateStatusRTT sets providers p50/p95 round trip of storage rates queries answered in the last 24 hours
+func (r *repository) UpdateStatusRTT(ctx context.Context) (err error) {
+	query := `
+		WITH rtt AS (
+			SELECT
+				public_key,
+				percentile_cont(0.5) WITHIN GROUP (ORDER BY rtt_ms) AS p50,
+				percentile_cont(0.95) WITHIN GROUP (ORDER BY rtt_ms) AS p95
+			FROM providers.statuses_history
+			WHERE rtt_ms IS NOT NULL
+				AND check_time > NOW() - INTERVAL '24 hours'
+			GROUP BY public_key
 		)
-		/ GREATEST(LOG(COALESCE(NULLIF(pr.rate_per_mb_per_day / 100, 0), 1)), 1)
-		) / 10000.0
-		FROM params pr
-		WHERE p.public_key = pr.public_key
-    `
+		UPDATE providers.providers p
+		SET rtt_p50_ms = r.p50,
+			rtt_p95_ms = r.p95
+		FROM providers.providers pp
+			LEFT JOIN rtt r ON r.public_key = pp.public_key
+		WHERE p.public_key = pp.public_key
+			AND (p.rtt_p50_ms IS DISTINCT FROM r.p50 OR p.rtt_p95_ms IS DISTINCT FROM r.p95)
+	`
+
 	_, err = r.db.Exec(ctx, query)
 
 	return
 }
 
+// GetRatingInputs returns rating inputs of initialized providers, all of them if pubkeys is empty
+func (r *repository) GetRatingInputs(ctx context.Context, uptimeWindow string, pubkeys []string) (inputs []db.RatingInputs, err error) {
+	uptimeColumn, ok := constants.UptimeWindowsMap[uptimeWindow]
+	if !ok {
+		uptimeColumn = constants.UptimeWindowsMap["all"]
+	}
+
+	query := fmt.Sprintf(`
+		SELECT 
+			p.public_key,
+			p.registered_at,
+			p.%s AS uptime,
+			COALESCE(p.max_span, 0),
+			COALESCE(p.min_span, 0),
+			p.max_bag_size_bytes,
+			p.rate_per_mb_per_day,
+			t.total_provider_space,
+			t.cpu_number,
+			t.total_ram,
+			providers.parse_speed_to_int(b.qd64_disk_write_speed),
+			providers.parse_speed_to_int(b.qd64_disk_read_speed),
+			b.speedtest_download,
+			b.speedtest_upload,
+			b.speedtest_ping,
+			p.retrieval_p50_ms
+		FROM providers.providers p
+			LEFT JOIN providers.telemetry t ON p.public_key = t.public_key
+			LEFT JOIN providers.benchmarks b ON p.public_key = b.public_key
+		WHERE p.is_initialized
+			AND (cardinality($1::text[]) = 0 OR p.public_key = ANY($1::text[]))`, uptimeColumn)
+
+	if pubkeys == nil {
+		pubkeys = []string{}
+	}
+
+	rows, err := r.db.Query(ctx, query, pubkeys)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var in db.RatingInputs
+		if rErr := rows.Scan(
+			&in.PubKey,
+			&in.RegisteredAt,
+			&in.Uptime,
+			&in.MaxSpan,
+			&in.MinSpan,
+			&in.MaxBagSizeBytes,
+			&in.RatePerMBDay,
+			&in.TotalProviderSpace,
+			&in.CPUNumber,
+			&in.TotalRAM,
+			&in.DiskWriteSpeed,
+			&in.DiskReadSpeed,
+			&in.SpeedtestDownload,
+			&in.SpeedtestUpload,
+			&in.SpeedtestPing,
+			&in.RetrievalP50Ms,
+		); rErr != nil {
+			err = rErr
+			return
+		}
+		inputs = append(inputs, in)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) UpdateRating(ctx context.Context, ratings []db.ProviderRating) (err error) {
+	if len(ratings) == 0 {
+		return
+	}
+
+	query := `
+		UPDATE providers.providers p
+		SET rating = pr.rating
+		FROM (
+			SELECT
+				r->>'public_key' AS public_key,
+				(r->>'rating')::double precision AS rating
+			FROM jsonb_array_elements($1::jsonb) AS r
+		) AS pr
+		WHERE p.public_key = pr.public_key
+	`
+
+	_, err = r.db.Exec(ctx, query, ratings)
+
+	return
+}
+
 func (r *repository) GetAllProvidersPubkeys(ctx context.Context) (pubkeys []string, err error) {
 	query := `
 		SELECT public_key
@@ -679,8 +858,11 @@ func (r *repository) AddStorageContracts(ctx context.Context, contracts []db.Sto
 	return
 }
 
-func (r *repository) UpdateStatuses(ctx context.Context) (err error) {
+// UpdateStatuses sets the most frequent failed check reason for the last day
+// and returns providers where it has changed
+func (r *repository) UpdateStatuses(ctx context.Context) (changed []db.ProviderStatusChange, err error) {
 	query := `
+		WITH updated AS (
 		UPDATE providers.providers p 
 		SET status = selected_reasons.most_recent_reason,
 			status_ratio = selected_reasons.most_recent_ratio,
@@ -709,11 +891,31 @@ func (r *repository) UpdateStatuses(ctx context.Context) (err error) {
 				MAX(CASE WHEN t.rn = 1 THEN ROUND(t.cnt::numeric / t.total_cnt::numeric, 4) END) AS most_recent_ratio
 			FROM collect_statuses t
 			GROUP BY t.address
-		) selected_reasons
-		WHERE p.address = selected_reasons.address;
+		) selected_reasons, providers.providers old_p
+		WHERE p.address = selected_reasons.address AND old_p.public_key = p.public_key
+		RETURNING p.public_key, old_p.status AS old_status, p.status AS new_status
+		)
+		SELECT public_key, old_status, new_status
+		FROM updated
+		WHERE old_status IS DISTINCT FROM new_status;
 	`
 
-	_, err = r.db.Exec(ctx, query)
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c db.ProviderStatusChange
+		if rErr := rows.Scan(&c.PubKey, &c.OldStatus, &c.NewStatus); rErr != nil {
+			err = rErr
+			return
+		}
+		changed = append(changed, c)
+	}
+
+	err = rows.Err()
 
 	return
 }
@@ -724,7 +926,8 @@ func (r *repository) GetStorageContractsChecks(ctx context.Context, contracts []
 			sc.address, 
 			p.public_key, 
 			sc.reason, 
-			sc.reason_timestamp
+			sc.reason_timestamp,
+			sc.confidence
 		FROM providers.storage_contracts sc
 			JOIN providers.providers p ON p.address = sc.provider_address
 		WHERE sc.address = ANY($1::text[]);`
@@ -737,7 +940,7 @@ func (r *repository) GetStorageContractsChecks(ctx context.Context, contracts []
 
 	for rows.Next() {
 		var contract db.ContractCheck
-		if rErr := rows.Scan(&contract.Address, &contract.ProviderPublicKey, &contract.Reason, &contract.ReasonTimestamp); rErr != nil {
+		if rErr := rows.Scan(&contract.Address, &contract.ProviderPublicKey, &contract.Reason, &contract.ReasonTimestamp, &contract.Confidence); rErr != nil {
 			err = rErr
 			return
 		}
@@ -748,19 +951,260 @@ func (r *repository) GetStorageContractsChecks(ctx context.Context, contracts []
 	return
 }
 
+// GetBagContracts returns all storage contracts of the bag with their providers and last checks
+func (r *repository) GetBagContracts(ctx context.Context, bagID string) (contracts []db.BagContract, err error) {
+	query := `
+		SELECT
+			sc.address,
+			sc.owner_address,
+			sc.size,
+			sc.provider_address,
+			p.public_key,
+			sc.reason,
+			sc.reason_timestamp,
+			sc.confidence
+		FROM providers.storage_contracts sc
+			LEFT JOIN providers.providers p ON p.address = sc.provider_address
+		WHERE sc.bag_id = $1
+		ORDER BY sc.address, sc.provider_address;`
+
+	rows, err := r.db.Query(ctx, query, bagID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c db.BagContract
+		if rErr := rows.Scan(&c.Address, &c.OwnerAddress, &c.Size, &c.ProviderAddress, &c.ProviderPublicKey, &c.Reason, &c.ReasonTimestamp, &c.Confidence); rErr != nil {
+			err = rErr
+			return
+		}
+		contracts = append(contracts, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// GetOwnerContracts returns page of owner storage contracts with their providers and last known balances
+// ordered by contract address and total count of contracts. Providers which left contracts and closed
+// contracts are included if withHistory is set
+func (r *repository) GetOwnerContracts(ctx context.Context, owner string, withHistory bool, limit, offset int) (contracts []db.OwnerContract, total uint64, err error) {
+	query := `
+		WITH relations AS (
+			SELECT
+				address,
+				bag_id,
+				size,
+				provider_address,
+				reason,
+				reason_timestamp,
+				confidence,
+				NULL::timestamptz AS removed_at
+			FROM providers.storage_contracts
+			WHERE owner_address = $1
+			UNION ALL
+			SELECT
+				address,
+				bag_id,
+				size,
+				provider_address,
+				reason,
+				reason_timestamp,
+				NULL::double precision,
+				deleted_at
+			FROM providers.storage_contracts_history
+			WHERE $2 AND owner_address = $1
+		),
+		owned AS (
+			SELECT DISTINCT address
+			FROM relations
+		)
+		SELECT
+			t.total,
+			c.address,
+			c.bag_id,
+			c.size,
+			c.provider_address,
+			c.public_key,
+			c.reason,
+			c.reason_timestamp,
+			c.confidence,
+			c.removed_at,
+			c.balance,
+			c.days_left,
+			c.updated_at
+		FROM (SELECT count(*) AS total FROM owned) t
+			LEFT JOIN LATERAL (
+				SELECT
+					r.address,
+					r.bag_id,
+					r.size,
+					r.provider_address,
+					p.public_key,
+					r.reason,
+					r.reason_timestamp,
+					r.confidence,
+					r.removed_at,
+					b.balance,
+					b.days_left,
+					b.updated_at
+				FROM relations r
+					JOIN (
+						SELECT address
+						FROM owned
+						ORDER BY address
+						LIMIT $3 OFFSET $4
+					) o ON o.address = r.address
+					LEFT JOIN providers.providers p ON p.address = r.provider_address
+					LEFT JOIN providers.storage_contracts_balances b ON b.address = r.address
+			) c ON true
+		ORDER BY c.address, c.removed_at NULLS FIRST, c.provider_address;`
+
+	rows, err := r.db.Query(ctx, query, owner, withHistory, limit, offset)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			address         *string
+			bagID           *string
+			size            *uint64
+			providerAddress *string
+			c               db.OwnerContract
+		)
+		if rErr := rows.Scan(
+			&total, &address, &bagID, &size, &providerAddress, &c.ProviderPublicKey,
+			&c.Reason, &c.ReasonTimestamp, &c.Confidence, &c.RemovedAt,
+			&c.Balance, &c.DaysLeft, &c.BalanceUpdatedAt,
+		); rErr != nil {
+			err = rErr
+			return
+		}
+
+		// page is out of range, only total is returned
+		if address == nil {
+			continue
+		}
+
+		c.Address, c.BagID, c.Size, c.ProviderAddress = *address, *bagID, *size, *providerAddress
+		contracts = append(contracts, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// GetProviderContracts returns page of provider storage contracts ordered by address and total count of
+// contracts with given last check reasons, all contracts are counted if reasons are empty
+func (r *repository) GetProviderContracts(ctx context.Context, pubkey string, reasons []uint32, limit, offset int) (contracts []db.ProviderContract, total uint64, err error) {
+	query := `
+		WITH filtered AS (
+			SELECT
+				sc.address,
+				sc.bag_id,
+				sc.size,
+				sc.owner_address,
+				sc.reason,
+				sc.reason_timestamp,
+				sc.confidence
+			FROM providers.storage_contracts sc
+				JOIN providers.providers p ON p.address = sc.provider_address
+			WHERE p.public_key = $1
+				AND (cardinality($2::integer[]) = 0 OR sc.reason = ANY($2::integer[]))
+		)
+		SELECT
+			t.total,
+			c.address,
+			c.bag_id,
+			c.size,
+			c.owner_address,
+			c.reason,
+			c.reason_timestamp,
+			c.confidence
+		FROM (SELECT count(*) AS total FROM filtered) t
+			LEFT JOIN LATERAL (
+				SELECT *
+				FROM filtered
+				ORDER BY address
+				LIMIT $3 OFFSET $4
+			) c ON true;`
+
+	if reasons == nil {
+		reasons = []uint32{}
+	}
+
+	rows, err := r.db.Query(ctx, query, pubkey, reasons, limit, offset)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			address      *string
+			bagID        *string
+			size         *uint64
+			ownerAddress *string
+			c            db.ProviderContract
+		)
+		if rErr := rows.Scan(&total, &address, &bagID, &size, &ownerAddress, &c.Reason, &c.ReasonTimestamp, &c.Confidence); rErr != nil {
+			err = rErr
+			return
+		}
+
+		// page is out of range, only total is returned
+		if address == nil {
+			continue
+		}
+
+		c.Address, c.BagID, c.Size, c.OwnerAddress = *address, *bagID, *size, *ownerAddress
+		contracts = append(contracts, c)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// UpdateContractProofsChecks saves the last check result to contracts and appends all checks to history
 func (r *repository) UpdateContractProofsChecks(ctx context.Context, contractsProofs []db.ContractProofsCheck) (err error) {
 	query := `
 		WITH cte AS (
 			SELECT
 				c->>'contract_address' AS address,
 				c->>'provider_address' AS provider_address,
-				(c->>'reason')::integer AS reason
+				(c->>'reason')::integer AS reason,
+				(c->>'piece_id')::integer AS piece_id,
+				(c->>'latency_ms')::integer AS latency_ms,
+				(c->>'ping_ms')::integer AS ping_ms,
+				(c->>'info_ms')::integer AS info_ms,
+				(c->>'piece_ms')::integer AS piece_ms,
+				(c->>'piece_size')::integer AS piece_size,
+				(c->>'bytes_received')::bigint AS bytes_received,
+				(c->>'pieces_checked')::integer AS pieces_checked,
+				(c->>'pieces_valid')::integer AS pieces_valid,
+				(c->>'confidence')::double precision AS confidence,
+				(c->>'sample_seed')::bigint AS sample_seed
 			FROM jsonb_array_elements($1::jsonb) AS c
+		), history AS (
+			INSERT INTO providers.storage_proofs_history (
+				contract_address, provider_address, reason, piece_id, latency_ms,
+				ping_ms, info_ms, piece_ms, piece_size, bytes_received,
+				pieces_checked, pieces_valid, confidence, sample_seed, checked_at
+			)
+			SELECT address, provider_address, reason, piece_id, latency_ms,
+				ping_ms, info_ms, piece_ms, piece_size, bytes_received,
+				pieces_checked, pieces_valid, confidence, sample_seed, now()
+			FROM cte
 		)
 		UPDATE providers.storage_contracts sc
 		SET
 			reason = c.reason,
-			reason_timestamp = now()
+			reason_timestamp = now(),
+			confidence = c.confidence
 		FROM cte c
 		WHERE sc.address = c.address AND c.provider_address = sc.provider_address
 	`
@@ -770,6 +1214,50 @@ func (r *repository) UpdateContractProofsChecks(ctx context.Context, contractsPr
 	return
 }
 
+func (r *repository) GetStorageProofsHistory(ctx context.Context, contracts []string, from, to time.Time) (checks []db.StorageProofCheck, err error) {
+	query := `
+		SELECT
+			h.contract_address,
+			COALESCE(p.public_key, ''),
+			h.reason,
+			h.piece_id,
+			h.latency_ms,
+			h.pieces_checked,
+			h.pieces_valid,
+			h.confidence,
+			h.checked_at
+		FROM providers.storage_proofs_history h
+			LEFT JOIN providers.providers p ON p.address = h.provider_address
+		WHERE h.contract_address = ANY($1::text[])
+			AND h.checked_at >= $2
+			AND h.checked_at <= $3
+		ORDER BY h.contract_address, h.checked_at
+	`
+
+	rows, err := r.db.Query(ctx, query, contracts, from, to)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c db.StorageProofCheck
+		if rErr := rows.Scan(&c.ContractAddress, &c.ProviderPublicKey, &c.Reason, &c.PieceID, &c.LatencyMs, &c.PiecesChecked, &c.PiecesValid, &c.Confidence, &c.CheckedAt); rErr != nil {
+			err = rErr
+			return
+		}
+		checks = append(checks, c)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
 func (r *repository) GetStorageContracts(ctx context.Context) (contracts []db.ContractToProviderRelation, err error) {
 	query := `
 		SELECT 
@@ -838,6 +1326,43 @@ func (r *repository) UpdateRejectedStorageContracts(ctx context.Context, storage
 	return
 }
 
+// UpdateContractsBalances saves balances read from chain and removes balances of contracts
+// which are not stored anymore
+func (r *repository) UpdateContractsBalances(ctx context.Context, balances []db.ContractBalance) (err error) {
+	if len(balances) == 0 {
+		return
+	}
+
+	query := `
+		WITH balances AS (
+			SELECT
+				b->>'address' AS address,
+				(b->>'balance')::bigint AS balance,
+				(b->>'days_left')::double precision AS days_left
+			FROM jsonb_array_elements($1::jsonb) AS b
+		), upserted AS (
+			INSERT INTO providers.storage_contracts_balances (address, balance, days_left, updated_at)
+			SELECT address, balance, days_left, NOW()
+			FROM balances
+			ON CONFLICT (address) DO UPDATE SET
+				balance = EXCLUDED.balance,
+				days_left = EXCLUDED.days_left,
+				updated_at = EXCLUDED.updated_at
+		)
+		DELETE FROM providers.storage_contracts_balances scb
+		WHERE scb.address NOT IN (SELECT address FROM balances)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM providers.storage_contracts sc
+				WHERE sc.address = scb.address
+			)
+	`
+
+	_, err = r.db.Exec(ctx, query, balances)
+
+	return
+}
+
 func (r *repository) UpdateProvidersLT(ctx context.Context, providers []db.ProviderWalletLT) (err error) {
 	if len(providers) == 0 {
 		return
@@ -861,21 +1386,14 @@ func (r *repository) UpdateProvidersLT(ctx context.Context, providers []db.Provi
 	return
 }
 
-func (r *repository) UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (err error) {
+// UpdateProviders saves providers rates and returns changed rates of already initialized providers
+func (r *repository) UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (changed []db.ProviderPriceChange, err error) {
 	if len(providers) == 0 {
 		return
 	}
 
 	query := `
-		UPDATE providers.providers
-		SET
-			rate_per_mb_per_day = p.rate_per_mb_per_day,
-			min_bounty = p.min_bounty,
-			min_span = p.min_span,
-			max_span = p.max_span,
-			is_initialized = true,
-			updated_at = NOW()
-		FROM (
+		WITH new_rates AS (
 			SELECT
 				p->>'public_key' AS public_key,
 				(p->>'rate_per_mb_per_day')::bigint AS rate_per_mb_per_day,
@@ -883,11 +1401,68 @@ func (r *repository) UpdateProviders(ctx context.Context, providers []db.Provide
 				(p->>'min_span')::int AS min_span,
 				(p->>'max_span')::int AS max_span
 			FROM jsonb_array_elements($1::jsonb) AS p
-		) AS p
-		WHERE providers.providers.public_key = p.public_key
+		), old_rates AS (
+			SELECT pr.public_key, pr.rate_per_mb_per_day, pr.min_bounty, pr.min_span, pr.max_span
+			FROM providers.providers pr
+				JOIN new_rates n ON n.public_key = pr.public_key
+			WHERE pr.is_initialized
+		), updated AS (
+			UPDATE providers.providers
+			SET
+				rate_per_mb_per_day = p.rate_per_mb_per_day,
+				min_bounty = p.min_bounty,
+				min_span = p.min_span,
+				max_span = p.max_span,
+				is_initialized = true,
+				updated_at = NOW()
+			FROM new_rates AS p
+			WHERE providers.providers.public_key = p.public_key
+			RETURNING p.public_key, p.rate_per_mb_per_day, p.min_bounty, p.min_span, p.max_span
+		)
+		SELECT
+			u.public_key,
+			COALESCE(o.rate_per_mb_per_day, 0),
+			COALESCE(o.min_bounty, 0),
+			COALESCE(o.min_span, 0),
+			COALESCE(o.max_span, 0),
+			u.rate_per_mb_per_day,
+			u.min_bounty,
+			u.min_span,
+			u.max_span
+		FROM updated u
+			JOIN old_rates o ON o.public_key = u.public_key
+		WHERE o.rate_per_mb_per_day IS DISTINCT FROM u.rate_per_mb_per_day
+			OR o.min_bounty IS DISTINCT FROM u.min_bounty
+			OR o.min_span IS DISTINCT FROM u.min_span
+			OR o.max_span IS DISTINCT FROM u.max_span
 	`
 
-	_, err = r.db.Exec(ctx, query, providers)
+	rows, err := r.db.Query(ctx, query, providers)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c db.ProviderPriceChange
+		if rErr := rows.Scan(
+			&c.Pubkey,
+			&c.Old.RatePerMBDay,
+			&c.Old.MinBounty,
+			&c.Old.MinSpan,
+			&c.Old.MaxSpan,
+			&c.New.RatePerMBDay,
+			&c.New.MinBounty,
+			&c.New.MinSpan,
+			&c.New.MaxSpan,
+		); rErr != nil {
+			err = rErr
+			return
+		}
+		changed = append(changed, c)
+	}
+
+	err = rows.Err()
 
 	return
 }
@@ -974,6 +1549,415 @@ func (r *repository) UpdateProvidersIPInfo(ctx context.Context, ips []db.Provide
 	return
 }
 
+func (r *repository) GetProviderHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.ProviderHistoryPoint, err error) {
+	// history keeps values which were replaced at archived_at,
+	// current values are added as the latest point
+	query := `
+		WITH points AS (
+			SELECT archived_at AS ts, rating, uptime, rate_per_mb_per_day
+			FROM providers.providers_history
+			WHERE public_key = $1 AND archived_at BETWEEN $2 AND $3
+			UNION ALL
+			SELECT NOW(), rating, uptime, rate_per_mb_per_day
+			FROM providers.providers
+			WHERE public_key = $1 AND NOW() BETWEEN $2 AND $3
+		)
+		SELECT
+			to_timestamp((floor(extract(epoch FROM ts) / $4::bigint) * $4::bigint)::float8) AS bucket,
+			avg(rating)::float8,
+			(avg(uptime) * 100)::float8,
+			(avg(rate_per_mb_per_day) * 1024 * 200 * 30)::float8 -- NanoTON per 200GB per month
+		FROM points
+		GROUP BY bucket
+		ORDER BY bucket`
+
+	rows, err := r.db.Query(ctx, query, pubkey, from, to, int64(bucket.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.ProviderHistoryPoint
+		if rErr := rows.Scan(&p.Time, &p.Rating, &p.UpTime, &p.Price); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+// GetProviderPrices returns rates changes of provider in the range and the rates which were in effect at from.
+// History keeps replaced values, so each archived row is in effect since the previous one was archived
+func (r *repository) GetProviderPrices(ctx context.Context, pubkey string, from, to time.Time) (points []db.ProviderPricePoint, err error) {
+	query := `
+		WITH states AS (
+			SELECT archived_at AS until, rate_per_mb_per_day, min_bounty, min_span, max_span
+			FROM providers.providers_history
+			WHERE public_key = $1
+			UNION ALL
+			SELECT 'infinity'::timestamptz, rate_per_mb_per_day, min_bounty, min_span, max_span
+			FROM providers.providers
+			WHERE public_key = $1
+		), periods AS (
+			SELECT
+				COALESCE(lag(until) OVER w, (SELECT registered_at FROM providers.providers WHERE public_key = $1)) AS since,
+				rate_per_mb_per_day,
+				min_bounty,
+				min_span,
+				max_span,
+				rate_per_mb_per_day IS DISTINCT FROM lag(rate_per_mb_per_day) OVER w
+					OR min_bounty IS DISTINCT FROM lag(min_bounty) OVER w
+					OR min_span IS DISTINCT FROM lag(min_span) OVER w
+					OR max_span IS DISTINCT FROM lag(max_span) OVER w AS changed
+			FROM states
+			WINDOW w AS (ORDER BY until)
+		), changes AS (
+			SELECT since, rate_per_mb_per_day, min_bounty, min_span, max_span
+			FROM periods
+			WHERE changed AND rate_per_mb_per_day IS NOT NULL
+		)
+		SELECT since, rate_per_mb_per_day, COALESCE(min_bounty, 0), COALESCE(min_span, 0), COALESCE(max_span, 0)
+		FROM changes
+		WHERE since BETWEEN $2 AND $3
+			OR since = (SELECT max(since) FROM changes WHERE since < $2)
+		ORDER BY since`
+
+	rows, err := r.db.Query(ctx, query, pubkey, from, to)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.ProviderPricePoint
+		if rErr := rows.Scan(&p.Time, &p.RatePerMBDay, &p.MinBounty, &p.MinSpan, &p.MaxSpan); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+// GetNetworkPrices returns median, min and max rate per MB per day of providers at each bucket start
+func (r *repository) GetNetworkPrices(ctx context.Context, from, to time.Time, bucket time.Duration) (points []db.NetworkPricePoint, err error) {
+	query := `
+		WITH buckets AS (
+			SELECT generate_series(
+				to_timestamp((floor(extract(epoch FROM $1::timestamptz) / $3::bigint) * $3::bigint)::float8),
+				$2::timestamptz,
+				make_interval(secs => $3::bigint)
+			) AS ts
+		), rates AS (
+			SELECT
+				b.ts,
+				CASE WHEN h.found THEN h.rate_per_mb_per_day ELSE p.rate_per_mb_per_day END AS rate
+			FROM buckets b
+				JOIN providers.providers p ON p.registered_at <= b.ts
+				LEFT JOIN LATERAL (
+					SELECT true AS found, ph.rate_per_mb_per_day
+					FROM providers.providers_history ph
+					WHERE ph.public_key = p.public_key AND ph.archived_at > b.ts
+					ORDER BY ph.archived_at
+					LIMIT 1
+				) h ON true
+		)
+		SELECT
+			ts,
+			count(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY rate),
+			min(rate),
+			max(rate)
+		FROM rates
+		WHERE rate IS NOT NULL
+		GROUP BY ts
+		ORDER BY ts`
+
+	rows, err := r.db.Query(ctx, query, from, to, int64(bucket.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.NetworkPricePoint
+		if rErr := rows.Scan(&p.Time, &p.Providers, &p.Median, &p.Min, &p.Max); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+// GetQuoteCandidates returns online providers which accept bags of bagSize and have enough free space by telemetry
+func (r *repository) GetQuoteCandidates(ctx context.Context, bagSize uint64) (candidates []db.QuoteCandidate, err error) {
+	query := `
+		SELECT
+			p.public_key,
+			p.address,
+			p.rate_per_mb_per_day,
+			COALESCE(p.min_bounty, 0),
+			COALESCE(p.min_span, 0),
+			COALESCE(p.max_span, 0),
+			COALESCE(p.rating, 0)
+		FROM providers.providers p
+			JOIN providers.statuses s ON s.public_key = p.public_key
+			JOIN providers.telemetry t ON t.public_key = p.public_key
+		WHERE p.is_initialized
+			AND s.is_online
+			AND p.rate_per_mb_per_day IS NOT NULL
+			AND p.max_bag_size_bytes >= $1
+			AND (t.total_provider_space - t.used_provider_space) * 1024 * 1024 * 1024 >= $1 -- telemetry space is in GB
+		ORDER BY p.rate_per_mb_per_day, p.rating DESC`
+
+	rows, err := r.db.Query(ctx, query, int64(bagSize))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c db.QuoteCandidate
+		if rErr := rows.Scan(&c.PubKey, &c.Address, &c.RatePerMBDay, &c.MinBounty, &c.MinSpan, &c.MaxSpan, &c.Rating); rErr != nil {
+			err = rErr
+			return
+		}
+		candidates = append(candidates, c)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) GetNetworkStats(ctx context.Context) (stats db.NetworkStats, err error) {
+	query := `
+		WITH contracts AS (
+			SELECT DISTINCT ON (address) address, bag_id, owner_address, size
+			FROM providers.storage_contracts
+		)
+		SELECT
+			(SELECT count(*) FROM providers.providers),
+			(SELECT count(*) FROM providers.providers WHERE is_initialized),
+			(SELECT count(*) FROM providers.statuses WHERE is_online),
+			(SELECT COALESCE(sum(total_provider_space), 0)::float8 FROM providers.telemetry),
+			(SELECT COALESCE(sum(used_provider_space), 0)::float8 FROM providers.telemetry),
+			(SELECT count(*) FROM contracts),
+			(SELECT COALESCE(sum(size), 0) FROM contracts),
+			(SELECT count(DISTINCT bag_id) FROM contracts),
+			(SELECT count(DISTINCT owner_address) FROM contracts),
+			(
+				SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY rate_per_mb_per_day) * 1024 * 200 * 30 -- NanoTON per 200GB per month
+				FROM providers.providers
+				WHERE is_initialized AND rate_per_mb_per_day IS NOT NULL
+			),
+			(SELECT count(*) FROM providers.storage_proofs_history WHERE checked_at > NOW() - INTERVAL '24 hours'),
+			(SELECT count(*) FROM providers.storage_proofs_history WHERE checked_at > NOW() - INTERVAL '24 hours' AND reason = 0),
+			(
+				SELECT count(DISTINCT ip_info->>'country')
+				FROM providers.providers
+				WHERE COALESCE(ip_info->>'country', '') <> ''
+			),
+			(
+				SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY rating)
+				FROM providers.providers
+				WHERE is_initialized AND rating IS NOT NULL
+			),
+			(
+				SELECT COALESCE(jsonb_object_agg(storage_git_hash, cnt), '{}'::jsonb)
+				FROM (
+					SELECT storage_git_hash, count(*) AS cnt
+					FROM providers.telemetry
+					WHERE COALESCE(storage_git_hash, '') <> ''
+					GROUP BY storage_git_hash
+				) v
+			),
+			(
+				SELECT COALESCE(jsonb_object_agg(provider_git_hash, cnt), '{}'::jsonb)
+				FROM (
+					SELECT provider_git_hash, count(*) AS cnt
+					FROM providers.telemetry
+					WHERE COALESCE(provider_git_hash, '') <> ''
+					GROUP BY provider_git_hash
+				) v
+			)
+	`
+
+	err = r.db.QueryRow(ctx, query).Scan(
+		&stats.ProvidersRegistered,
+		&stats.ProvidersInitialized,
+		&stats.ProvidersOnline,
+		&stats.TotalProviderSpace,
+		&stats.UsedProviderSpace,
+		&stats.Contracts,
+		&stats.ContractsSize,
+		&stats.Bags,
+		&stats.Owners,
+		&stats.MedianPrice,
+		&stats.ProofsChecked24h,
+		&stats.ProofsValid24h,
+		&stats.Countries,
+		&stats.MedianRating,
+		&stats.StorageVersions,
+		&stats.ProviderVersions,
+	)
+
+	return
+}
+
+func (r *repository) GetProviderStatusesHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.StatusHistoryPoint, err error) {
+	query := `
+		SELECT
+			to_timestamp((floor(extract(epoch FROM check_time) / $4::bigint) * $4::bigint)::float8) AS bucket,
+			count(*) AS total,
+			count(*) filter (where is_online) AS online
+		FROM providers.statuses_history
+		WHERE public_key = $1 AND check_time BETWEEN $2 AND $3
+		GROUP BY bucket
+		ORDER BY bucket`
+
+	rows, err := r.db.Query(ctx, query, pubkey, from, to, int64(bucket.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.StatusHistoryPoint
+		if rErr := rows.Scan(&p.Time, &p.Total, &p.Online); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) GetProviderTelemetryHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.TelemetryHistoryPoint, err error) {
+	query := `
+		WITH points AS (
+			SELECT archived_at AS ts, total_provider_space, used_provider_space, free_space
+			FROM providers.telemetry_history
+			WHERE public_key = $1 AND archived_at BETWEEN $2 AND $3
+			UNION ALL
+			SELECT NOW(), total_provider_space, used_provider_space, free_space
+			FROM providers.telemetry
+			WHERE public_key = $1 AND NOW() BETWEEN $2 AND $3
+		)
+		SELECT
+			to_timestamp((floor(extract(epoch FROM ts) / $4::bigint) * $4::bigint)::float8) AS bucket,
+			avg(total_provider_space)::float8,
+			avg(used_provider_space)::float8,
+			avg(free_space)::float8
+		FROM points
+		GROUP BY bucket
+		ORDER BY bucket`
+
+	rows, err := r.db.Query(ctx, query, pubkey, from, to, int64(bucket.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.TelemetryHistoryPoint
+		if rErr := rows.Scan(&p.Time, &p.TotalProviderSpace, &p.UsedProviderSpace, &p.FreeSpace); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) GetProviderBenchmarksHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) (points []db.BenchmarkHistoryPoint, err error) {
+	query := `
+		WITH points AS (
+			SELECT archived_at AS ts, qd64_disk_read_speed, qd64_disk_write_speed, speedtest_download, speedtest_upload, speedtest_ping
+			FROM providers.benchmarks_history
+			WHERE public_key = $1 AND archived_at BETWEEN $2 AND $3
+			UNION ALL
+			SELECT NOW(), qd64_disk_read_speed, qd64_disk_write_speed, speedtest_download, speedtest_upload, speedtest_ping
+			FROM providers.benchmarks
+			WHERE public_key = $1 AND NOW() BETWEEN $2 AND $3
+		)
+		SELECT
+			to_timestamp((floor(extract(epoch FROM ts) / $4::bigint) * $4::bigint)::float8) AS bucket,
+			avg(providers.parse_speed_to_int(qd64_disk_read_speed))::float8,
+			avg(providers.parse_speed_to_int(qd64_disk_write_speed))::float8,
+			avg(speedtest_download)::float8,
+			avg(speedtest_upload)::float8,
+			avg(speedtest_ping)::float8
+		FROM points
+		GROUP BY bucket
+		ORDER BY bucket`
+
+	rows, err := r.db.Query(ctx, query, pubkey, from, to, int64(bucket.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p db.BenchmarkHistoryPoint
+		if rErr := rows.Scan(&p.Time, &p.DiskReadSpeed, &p.DiskWriteSpeed, &p.SpeedtestDownload, &p.SpeedtestUpload, &p.SpeedtestPing); rErr != nil {
+			err = rErr
+			return
+		}
+		points = append(points, p)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
 func (r *repository) CleanOldProvidersHistory(ctx context.Context, days int) (removed int, err error) {
 	query := `
 		DELETE FROM providers.providers_history
@@ -1038,6 +2022,142 @@ func (r *repository) CleanOldTelemetryHistory(ctx context.Context, days int) (re
 	return
 }
 
+func (r *repository) CleanOldStorageProofsHistory(ctx context.Context, days int) (removed int, err error) {
+	query := `
+		DELETE FROM providers.storage_proofs_history
+		WHERE checked_at < NOW() - INTERVAL '1 day' * $1
+	`
+	resp, err := r.db.Exec(ctx, query, days)
+	if err != nil {
+		err = fmt.Errorf("failed to clean old storage proofs history: %w", err)
+		return
+	}
+
+	removed = int(resp.RowsAffected())
+
+	return
+}
+
+const auditColumns = `
+	id, contract_address, provider_address, provider_public_key, bag_id, status, error,
+	pieces_total, pieces_checked, missing_pieces, invalid_pieces, bytes_received,
+	created_at, started_at, finished_at
+`
+
+// AddAudit creates pending audit of the contract stored by the provider, found is false if there is no such contract
+func (r *repository) AddAudit(ctx context.Context, pubkey string, contract string) (audit db.Audit, found bool, err error) {
+	query := `
+		INSERT INTO providers.audits (contract_address, provider_address, provider_public_key, bag_id)
+		SELECT sc.address, sc.provider_address, p.public_key, sc.bag_id
+		FROM providers.storage_contracts sc
+			JOIN providers.providers p ON p.address = sc.provider_address
+		WHERE sc.address = $1 AND p.public_key = $2
+		RETURNING ` + auditColumns
+
+	audit, err = scanAudit(r.db.QueryRow(ctx, query, contract, pubkey))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+			return
+		}
+
+		err = fmt.Errorf("failed to add audit: %w", err)
+		return
+	}
+
+	found = true
+
+	return
+}
+
+func (r *repository) GetAudit(ctx context.Context, id int64) (audit *db.Audit, err error) {
+	query := `SELECT ` + auditColumns + ` FROM providers.audits WHERE id = $1`
+
+	a, err := scanAudit(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+
+	audit = &a
+
+	return
+}
+
+// TakeAudit marks the oldest pending audit as running, audits running longer than staleAfter are taken again
+func (r *repository) TakeAudit(ctx context.Context, staleAfter time.Duration) (audit *db.Audit, err error) {
+	query := `
+		UPDATE providers.audits
+		SET status = 'running',
+			started_at = now()
+		WHERE id = (
+			SELECT id
+			FROM providers.audits
+			WHERE status = 'pending'
+				OR (status = 'running' AND started_at < now() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + auditColumns
+
+	a, err := scanAudit(r.db.QueryRow(ctx, query, staleAfter.Seconds()))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+
+	audit = &a
+
+	return
+}
+
+// FinishAudit saves audit report, pending status returns the audit to the queue
+func (r *repository) FinishAudit(ctx context.Context, result db.AuditResult) (err error) {
+	query := `
+		UPDATE providers.audits
+		SET status = $2,
+			error = NULLIF($3, ''),
+			pieces_total = $4,
+			pieces_checked = $5,
+			missing_pieces = $6,
+			invalid_pieces = $7,
+			bytes_received = $8,
+			finished_at = CASE WHEN $2 = 'pending' THEN NULL ELSE now() END
+		WHERE id = $1
+	`
+
+	if result.MissingPieces == nil {
+		result.MissingPieces = []int32{}
+	}
+	if result.InvalidPieces == nil {
+		result.InvalidPieces = []int32{}
+	}
+
+	_, err = r.db.Exec(ctx, query,
+		result.ID,
+		result.Status,
+		result.Error,
+		result.PiecesTotal,
+		result.PiecesChecked,
+		result.MissingPieces,
+		result.InvalidPieces,
+		result.BytesReceived,
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to finish audit: %w", err)
+		return
+	}
+
+	return
+}
+
 func NewRepository(db *pgxpool.Pool) Repository {
 	return &repository{
 		db: db,
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"mytonprovider-backend/pkg/models/db"
 )
 
 type metricsMiddleware struct {
@@ -36,6 +38,160 @@ func (m *metricsMiddleware) GetParam(ctx context.Context, key string) (value str
 	return m.repo.GetParam(ctx, key)
 }
 
+func (m *metricsMiddleware) StartJobRun(ctx context.Context, name string, instanceID string, manual bool) (id int64, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"StartJobRun", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.StartJobRun(ctx, name, instanceID, manual)
+}
+
+func (m *metricsMiddleware) FinishJobRun(ctx context.Context, id int64, runErr string, nextRunAt time.Time) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"FinishJobRun", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.FinishJobRun(ctx, id, runErr, nextRunAt)
+}
+
+func (m *metricsMiddleware) CloseInstanceJobRuns(ctx context.Context, instanceID string) (closed int, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"CloseInstanceJobRuns", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.CloseInstanceJobRuns(ctx, instanceID)
+}
+
+func (m *metricsMiddleware) CloseOtherInstancesJobRuns(ctx context.Context, names []string, instanceID string) (closed int, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"CloseOtherInstancesJobRuns", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.CloseOtherInstancesJobRuns(ctx, names, instanceID)
+}
+
+func (m *metricsMiddleware) GetJobsStatuses(ctx context.Context, names []string) (statuses []db.JobStatus, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetJobsStatuses", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetJobsStatuses(ctx, names)
+}
+
+func (m *metricsMiddleware) CleanOldJobRuns(ctx context.Context, days int) (removed int, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"CleanOldJobRuns", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.CleanOldJobRuns(ctx, days)
+}
+
+func (m *metricsMiddleware) AddJobTrigger(ctx context.Context, name string) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AddJobTrigger", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AddJobTrigger(ctx, name)
+}
+
+func (m *metricsMiddleware) TakeJobTriggers(ctx context.Context, names []string) (taken []string, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"TakeJobTriggers", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.TakeJobTriggers(ctx, names)
+}
+
+func (m *metricsMiddleware) GetJobTriggers(ctx context.Context, names []string, since time.Time) (triggers []db.JobTrigger, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetJobTriggers", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetJobTriggers(ctx, names, since)
+}
+
+func (m *metricsMiddleware) AcquireLease(ctx context.Context, key string, holder string, ttl time.Duration) (acquired bool, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AcquireLease", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AcquireLease(ctx, key, holder, ttl)
+}
+
+func (m *metricsMiddleware) ReleaseLease(ctx context.Context, key string, holder string) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"ReleaseLease", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.ReleaseLease(ctx, key, holder)
+}
+
+func (m *metricsMiddleware) HasNetworkDailySnapshot(ctx context.Context, day time.Time) (exists bool, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"HasNetworkDailySnapshot", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.HasNetworkDailySnapshot(ctx, day)
+}
+
+func (m *metricsMiddleware) AddNetworkDailySnapshot(ctx context.Context, snapshot db.NetworkDailySnapshot) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AddNetworkDailySnapshot", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AddNetworkDailySnapshot(ctx, snapshot)
+}
+
+func (m *metricsMiddleware) GetNetworkDailySnapshots(ctx context.Context, from, to time.Time) (snapshots []db.NetworkDailySnapshot, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetNetworkDailySnapshots", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetNetworkDailySnapshots(ctx, from, to)
+}
+
 func NewMetrics(reqCount *prometheus.CounterVec, reqDuration *prometheus.HistogramVec, repo Repository) Repository {
 	return &metricsMiddleware{
 		reqCount:    reqCount,
@@ -2,11 +2,18 @@ package system
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mytonprovider-backend/pkg/models/db"
 )
 
+// error of runs which were not finished by their instance
+const jobRunInterrupted = "interrupted"
+
 type repository struct {
 	db *pgxpool.Pool
 }
@@ -14,6 +21,23 @@ type repository struct {
 type Repository interface {
 	SetParam(ctx context.Context, key string, value string) (err error)
 	GetParam(ctx context.Context, key string) (value string, err error)
+
+	StartJobRun(ctx context.Context, name string, instanceID string, manual bool) (id int64, err error)
+	FinishJobRun(ctx context.Context, id int64, runErr string, nextRunAt time.Time) (err error)
+	CloseInstanceJobRuns(ctx context.Context, instanceID string) (closed int, err error)
+	CloseOtherInstancesJobRuns(ctx context.Context, names []string, instanceID string) (closed int, err error)
+	GetJobsStatuses(ctx context.Context, names []string) (statuses []db.JobStatus, err error)
+	CleanOldJobRuns(ctx context.Context, days int) (removed int, err error)
+	AddJobTrigger(ctx context.Context, name string) (err error)
+	TakeJobTriggers(ctx context.Context, names []string) (taken []string, err error)
+	GetJobTriggers(ctx context.Context, names []string, since time.Time) (triggers []db.JobTrigger, err error)
+
+	AcquireLease(ctx context.Context, key string, holder string, ttl time.Duration) (acquired bool, err error)
+	ReleaseLease(ctx context.Context, key string, holder string) (err error)
+
+	HasNetworkDailySnapshot(ctx context.Context, day time.Time) (exists bool, err error)
+	AddNetworkDailySnapshot(ctx context.Context, snapshot db.NetworkDailySnapshot) (err error)
+	GetNetworkDailySnapshots(ctx context.Context, from, to time.Time) (snapshots []db.NetworkDailySnapshot, err error)
 }
 
 func (r *repository) SetParam(ctx context.Context, key string, value string) (err error) {
@@ -64,6 +88,418 @@ func (r *repository) GetParam(ctx context.Context, key string) (value string, er
 	return
 }
 
+func (r *repository) StartJobRun(ctx context.Context, name string, instanceID string, manual bool) (id int64, err error) {
+	query := `
+		INSERT INTO system.job_runs (name, instance_id, manual, started_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id
+	`
+
+	err = r.db.QueryRow(ctx, query, name, instanceID, manual).Scan(&id)
+	if err != nil {
+		err = fmt.Errorf("failed to start job run: %w", err)
+		return
+	}
+
+	return
+}
+
+func (r *repository) FinishJobRun(ctx context.Context, id int64, runErr string, nextRunAt time.Time) (err error) {
+	query := `
+		UPDATE system.job_runs
+		SET finished_at = NOW(),
+			error = NULLIF($2, ''),
+			next_run_at = $3
+		WHERE id = $1
+	`
+
+	_, err = r.db.Exec(ctx, query, id, runErr, nextRunAt)
+	if err != nil {
+		err = fmt.Errorf("failed to finish job run: %w", err)
+		return
+	}
+
+	return
+}
+
+// CloseInstanceJobRuns marks unfinished runs of the instance as interrupted, they are left by
+// the previous process with the same instance id
+func (r *repository) CloseInstanceJobRuns(ctx context.Context, instanceID string) (closed int, err error) {
+	query := `
+		UPDATE system.job_runs
+		SET finished_at = NOW(),
+			error = $2
+		WHERE finished_at IS NULL AND instance_id = $1
+	`
+
+	resp, err := r.db.Exec(ctx, query, instanceID, jobRunInterrupted)
+	if err != nil {
+		err = fmt.Errorf("failed to close instance job runs: %w", err)
+		return
+	}
+
+	closed = int(resp.RowsAffected())
+
+	return
+}
+
+// CloseOtherInstancesJobRuns marks unfinished runs of the jobs started by other instances as
+// interrupted. It's called by the new leader, previous leader doesn't run these jobs anymore
+func (r *repository) CloseOtherInstancesJobRuns(ctx context.Context, names []string, instanceID string) (closed int, err error) {
+	query := `
+		UPDATE system.job_runs
+		SET finished_at = NOW(),
+			error = $3
+		WHERE finished_at IS NULL
+			AND name = ANY($1::text[])
+			AND instance_id IS DISTINCT FROM $2
+	`
+
+	resp, err := r.db.Exec(ctx, query, names, instanceID, jobRunInterrupted)
+	if err != nil {
+		err = fmt.Errorf("failed to close other instances job runs: %w", err)
+		return
+	}
+
+	closed = int(resp.RowsAffected())
+
+	return
+}
+
+// GetJobsStatuses returns the last run of every job with the time of the last successful run
+func (r *repository) GetJobsStatuses(ctx context.Context, names []string) (statuses []db.JobStatus, err error) {
+	query := `
+		SELECT
+			r.id,
+			r.name,
+			r.manual,
+			r.instance_id,
+			r.started_at,
+			r.finished_at,
+			r.error,
+			r.next_run_at,
+			s.finished_at AS last_success_at
+		FROM unnest($1::text[]) AS j(name)
+			JOIN LATERAL (
+				SELECT id, name, manual, instance_id, started_at, finished_at, error, next_run_at
+				FROM system.job_runs
+				WHERE name = j.name
+				ORDER BY started_at DESC
+				LIMIT 1
+			) r ON true
+			LEFT JOIN LATERAL (
+				SELECT finished_at
+				FROM system.job_runs
+				WHERE name = j.name AND finished_at IS NOT NULL AND error IS NULL
+				ORDER BY finished_at DESC
+				LIMIT 1
+			) s ON true
+	`
+
+	rows, err := r.db.Query(ctx, query, names)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s db.JobStatus
+		if rErr := rows.Scan(&s.ID, &s.Name, &s.Manual, &s.InstanceID, &s.StartedAt, &s.FinishedAt, &s.Error, &s.NextRunAt, &s.LastSuccessAt); rErr != nil {
+			err = rErr
+			return
+		}
+		statuses = append(statuses, s)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) CleanOldJobRuns(ctx context.Context, days int) (removed int, err error) {
+	query := `
+		DELETE FROM system.job_runs
+		WHERE started_at < NOW() - INTERVAL '1 day' * $1
+	`
+
+	resp, err := r.db.Exec(ctx, query, days)
+	if err != nil {
+		err = fmt.Errorf("failed to clean old job runs: %w", err)
+		return
+	}
+
+	removed = int(resp.RowsAffected())
+
+	return
+}
+
+// AddJobTrigger asks instances running the job to run it, repeated trigger updates request time
+func (r *repository) AddJobTrigger(ctx context.Context, name string) (err error) {
+	query := `
+		INSERT INTO system.job_triggers (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE
+		SET requested_at = NOW()
+	`
+
+	_, err = r.db.Exec(ctx, query, name)
+	if err != nil {
+		err = fmt.Errorf("failed to add job trigger: %w", err)
+		return
+	}
+
+	return
+}
+
+// TakeJobTriggers removes and returns requested manual runs of the jobs, it's used for jobs
+// which run on one instance
+func (r *repository) TakeJobTriggers(ctx context.Context, names []string) (taken []string, err error) {
+	query := `
+		DELETE FROM system.job_triggers
+		WHERE name = ANY($1::text[])
+		RETURNING name
+	`
+
+	rows, err := r.db.Query(ctx, query, names)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if rErr := rows.Scan(&name); rErr != nil {
+			err = rErr
+			return
+		}
+		taken = append(taken, name)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+// GetJobTriggers returns manual runs of the jobs requested after since, triggers are kept
+// so every instance running the jobs gets them
+func (r *repository) GetJobTriggers(ctx context.Context, names []string, since time.Time) (triggers []db.JobTrigger, err error) {
+	query := `
+		SELECT name, requested_at
+		FROM system.job_triggers
+		WHERE name = ANY($1::text[]) AND requested_at > $2
+	`
+
+	rows, err := r.db.Query(ctx, query, names, since)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t db.JobTrigger
+		if rErr := rows.Scan(&t.Name, &t.RequestedAt); rErr != nil {
+			err = rErr
+			return
+		}
+		triggers = append(triggers, t)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+// AcquireLease takes or renews the lease stored in params.
+// Lease is taken if it is free, already held by the holder or was not renewed for ttl.
+func (r *repository) AcquireLease(ctx context.Context, key string, holder string, ttl time.Duration) (acquired bool, err error) {
+	query := `
+		INSERT INTO system.params (key, value, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value,
+			updated_at = NOW()
+		WHERE system.params.value = EXCLUDED.value
+			OR system.params.updated_at IS NULL
+			OR system.params.updated_at < NOW() - make_interval(secs => $3)
+		RETURNING value
+	`
+
+	var value string
+	err = r.db.QueryRow(ctx, query, key, holder, ttl.Seconds()).Scan(&value)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+			return
+		}
+
+		err = fmt.Errorf("failed to acquire lease: %w", err)
+		return
+	}
+
+	acquired = value == holder
+
+	return
+}
+
+func (r *repository) ReleaseLease(ctx context.Context, key string, holder string) (err error) {
+	query := `
+		DELETE FROM system.params
+		WHERE key = $1 AND value = $2
+	`
+
+	_, err = r.db.Exec(ctx, query, key, holder)
+	if err != nil {
+		err = fmt.Errorf("failed to release lease: %w", err)
+		return
+	}
+
+	return
+}
+
+func (r *repository) HasNetworkDailySnapshot(ctx context.Context, day time.Time) (exists bool, err error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM system.network_daily
+			WHERE day = $1::date
+		)
+	`
+
+	err = r.db.QueryRow(ctx, query, day).Scan(&exists)
+
+	return
+}
+
+// AddNetworkDailySnapshot saves snapshot of the day, existing snapshot is kept
+func (r *repository) AddNetworkDailySnapshot(ctx context.Context, snapshot db.NetworkDailySnapshot) (err error) {
+	query := `
+		INSERT INTO system.network_daily (
+			day,
+			providers_registered,
+			providers_initialized,
+			providers_online,
+			total_provider_space,
+			used_provider_space,
+			contracts,
+			contracts_size,
+			bags,
+			owners,
+			median_price,
+			median_rating,
+			proofs_checked,
+			proofs_valid,
+			countries,
+			storage_versions,
+			provider_versions
+		) VALUES ($1::date, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (day) DO NOTHING
+	`
+
+	_, err = r.db.Exec(ctx, query,
+		snapshot.Day,
+		snapshot.ProvidersRegistered,
+		snapshot.ProvidersInitialized,
+		snapshot.ProvidersOnline,
+		snapshot.TotalProviderSpace,
+		snapshot.UsedProviderSpace,
+		int64(snapshot.Contracts),
+		int64(snapshot.ContractsSize),
+		int64(snapshot.Bags),
+		int64(snapshot.Owners),
+		snapshot.MedianPrice,
+		snapshot.MedianRating,
+		int64(snapshot.ProofsChecked24h),
+		int64(snapshot.ProofsValid24h),
+		snapshot.Countries,
+		snapshot.StorageVersions,
+		snapshot.ProviderVersions,
+	)
+	if err != nil {
+		err = fmt.Errorf("failed to add network daily snapshot: %w", err)
+	}
+
+	return
+}
+
+func (r *repository) GetNetworkDailySnapshots(ctx context.Context, from, to time.Time) (snapshots []db.NetworkDailySnapshot, err error) {
+	query := `
+		SELECT
+			day::timestamptz,
+			providers_registered,
+			providers_initialized,
+			providers_online,
+			total_provider_space,
+			used_provider_space,
+			contracts,
+			contracts_size,
+			bags,
+			owners,
+			median_price,
+			median_rating,
+			proofs_checked,
+			proofs_valid,
+			countries,
+			storage_versions,
+			provider_versions
+		FROM system.network_daily
+		WHERE day BETWEEN $1::date AND $2::date
+		ORDER BY day
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s db.NetworkDailySnapshot
+		if rErr := rows.Scan(
+			&s.Day,
+			&s.ProvidersRegistered,
+			&s.ProvidersInitialized,
+			&s.ProvidersOnline,
+			&s.TotalProviderSpace,
+			&s.UsedProviderSpace,
+			&s.Contracts,
+			&s.ContractsSize,
+			&s.Bags,
+			&s.Owners,
+			&s.MedianPrice,
+			&s.MedianRating,
+			&s.ProofsChecked24h,
+			&s.ProofsValid24h,
+			&s.Countries,
+			&s.StorageVersions,
+			&s.ProviderVersions,
+		); rErr != nil {
+			err = rErr
+			return
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
 func NewRepository(db *pgxpool.Pool) Repository {
 	return &repository{
 		db: db,
@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+type metricsMiddleware struct {
+	reqCount    *prometheus.CounterVec
+	reqDuration *prometheus.HistogramVec
+	repo        Repository
+}
+
+func (m *metricsMiddleware) AddSubscription(ctx context.Context, subscription db.WebhookSubscription) (resp db.WebhookSubscription, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AddSubscription", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AddSubscription(ctx, subscription)
+}
+
+func (m *metricsMiddleware) GetSubscriptions(ctx context.Context) (subscriptions []db.WebhookSubscription, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetSubscriptions", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetSubscriptions(ctx)
+}
+
+func (m *metricsMiddleware) DeleteSubscription(ctx context.Context, id int64) (deleted bool, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"DeleteSubscription", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.DeleteSubscription(ctx, id)
+}
+
+func (m *metricsMiddleware) AddEvents(ctx context.Context, events []db.WebhookEvent) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"AddEvents", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.AddEvents(ctx, events)
+}
+
+func (m *metricsMiddleware) GetPendingDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []db.WebhookDelivery, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"GetPendingDeliveries", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.GetPendingDeliveries(ctx, limit, lease)
+}
+
+func (m *metricsMiddleware) UpdateDeliveries(ctx context.Context, results []db.WebhookDeliveryResult) (err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"UpdateDeliveries", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.UpdateDeliveries(ctx, results)
+}
+
+func (m *metricsMiddleware) CleanOldDeliveries(ctx context.Context, days int) (removed int, err error) {
+	defer func(s time.Time) {
+		labels := []string{
+			"CleanOldDeliveries", strconv.FormatBool(err != nil),
+		}
+		m.reqCount.WithLabelValues(labels...).Add(1)
+		m.reqDuration.WithLabelValues(labels...).Observe(time.Since(s).Seconds())
+	}(time.Now())
+	return m.repo.CleanOldDeliveries(ctx, days)
+}
+
+func NewMetrics(reqCount *prometheus.CounterVec, reqDuration *prometheus.HistogramVec, repo Repository) Repository {
+	return &metricsMiddleware{
+		reqCount:    reqCount,
+		reqDuration: reqDuration,
+		repo:        repo,
+	}
+}
@@ -0,0 +1,225 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+type Repository interface {
+	AddSubscription(ctx context.Context, subscription db.WebhookSubscription) (resp db.WebhookSubscription, err error)
+	GetSubscriptions(ctx context.Context) (subscriptions []db.WebhookSubscription, err error)
+	DeleteSubscription(ctx context.Context, id int64) (deleted bool, err error)
+
+	AddEvents(ctx context.Context, events []db.WebhookEvent) (err error)
+	GetPendingDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []db.WebhookDelivery, err error)
+	UpdateDeliveries(ctx context.Context, results []db.WebhookDeliveryResult) (err error)
+	CleanOldDeliveries(ctx context.Context, days int) (removed int, err error)
+}
+
+func (r *repository) AddSubscription(ctx context.Context, subscription db.WebhookSubscription) (resp db.WebhookSubscription, err error) {
+	query := `
+		INSERT INTO system.webhook_subscriptions (url, secret, providers, contracts)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, url, secret, providers, contracts, created_at
+	`
+
+	err = r.db.QueryRow(ctx, query, subscription.URL, subscription.Secret, subscription.Providers, subscription.Contracts).
+		Scan(&resp.ID, &resp.URL, &resp.Secret, &resp.Providers, &resp.Contracts, &resp.CreatedAt)
+	if err != nil {
+		err = fmt.Errorf("failed to add webhook subscription: %w", err)
+		return
+	}
+
+	return
+}
+
+func (r *repository) GetSubscriptions(ctx context.Context) (subscriptions []db.WebhookSubscription, err error) {
+	query := `
+		SELECT id, url, secret, providers, contracts, created_at
+		FROM system.webhook_subscriptions
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s db.WebhookSubscription
+		if rErr := rows.Scan(&s.ID, &s.URL, &s.Secret, &s.Providers, &s.Contracts, &s.CreatedAt); rErr != nil {
+			err = rErr
+			return
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, id int64) (deleted bool, err error) {
+	query := `
+		DELETE FROM system.webhook_subscriptions
+		WHERE id = $1
+	`
+
+	resp, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		err = fmt.Errorf("failed to delete webhook subscription: %w", err)
+		return
+	}
+
+	deleted = resp.RowsAffected() > 0
+
+	return
+}
+
+// AddEvents queues delivery of every event to each subscription watching its provider or contract
+func (r *repository) AddEvents(ctx context.Context, events []db.WebhookEvent) (err error) {
+	if len(events) == 0 {
+		return
+	}
+
+	query := `
+		INSERT INTO system.webhook_deliveries (subscription_id, event_type, payload)
+		SELECT
+			s.id,
+			e->>'type',
+			e
+		FROM jsonb_array_elements($1::jsonb) AS e
+			JOIN system.webhook_subscriptions s
+				ON lower(e->>'provider_pubkey') = ANY(s.providers)
+				OR (e->>'contract_address' IS NOT NULL AND e->>'contract_address' = ANY(s.contracts))
+	`
+
+	_, err = r.db.Exec(ctx, query, events)
+	if err != nil {
+		err = fmt.Errorf("failed to add webhook events: %w", err)
+		return
+	}
+
+	return
+}
+
+// GetPendingDeliveries claims deliveries due for sending, claimed deliveries are postponed by lease
+// so other instances don't send them until results are saved or the lease expires
+func (r *repository) GetPendingDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []db.WebhookDelivery, err error) {
+	query := `
+		WITH claimed AS (
+			SELECT id
+			FROM system.webhook_deliveries
+			WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE system.webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM claimed c, system.webhook_subscriptions s
+		WHERE d.id = c.id AND s.id = d.subscription_id
+		RETURNING
+			d.id,
+			d.subscription_id,
+			s.url,
+			s.secret,
+			d.event_type,
+			d.payload,
+			d.attempts
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = nil
+		}
+
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d db.WebhookDelivery
+		if rErr := rows.Scan(&d.ID, &d.SubscriptionID, &d.URL, &d.Secret, &d.EventType, &d.Payload, &d.Attempts); rErr != nil {
+			err = rErr
+			return
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	err = rows.Err()
+
+	return
+}
+
+func (r *repository) UpdateDeliveries(ctx context.Context, results []db.WebhookDeliveryResult) (err error) {
+	if len(results) == 0 {
+		return
+	}
+
+	query := `
+		UPDATE system.webhook_deliveries d
+		SET attempts = d.attempts + 1,
+			delivered_at = CASE WHEN r.delivered THEN NOW() ELSE NULL END,
+			failed_at = CASE WHEN r.failed THEN NOW() ELSE NULL END,
+			last_error = NULLIF(r.error, ''),
+			next_attempt_at = CASE WHEN r.delivered OR r.failed THEN d.next_attempt_at ELSE r.next_attempt_at END
+		FROM (
+			SELECT
+				(r->>'id')::bigint AS id,
+				(r->>'delivered')::boolean AS delivered,
+				(r->>'failed')::boolean AS failed,
+				r->>'error' AS error,
+				(r->>'next_attempt_at')::timestamptz AS next_attempt_at
+			FROM jsonb_array_elements($1::jsonb) AS r
+		) AS r
+		WHERE d.id = r.id
+	`
+
+	_, err = r.db.Exec(ctx, query, results)
+	if err != nil {
+		err = fmt.Errorf("failed to update webhook deliveries: %w", err)
+		return
+	}
+
+	return
+}
+
+func (r *repository) CleanOldDeliveries(ctx context.Context, days int) (removed int, err error) {
+	query := `
+		DELETE FROM system.webhook_deliveries
+		WHERE created_at < NOW() - INTERVAL '1 day' * $1
+			AND (delivered_at IS NOT NULL OR failed_at IS NOT NULL)
+	`
+	resp, err := r.db.Exec(ctx, query, days)
+	if err != nil {
+		err = fmt.Errorf("failed to clean old webhook deliveries: %w", err)
+		return
+	}
+
+	removed = int(resp.RowsAffected())
+
+	return
+}
+
+func NewRepository(db *pgxpool.Pool) Repository {
+	return &repository{
+		db: db,
+	}
+}
@@ -0,0 +1,137 @@
+package audits
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/utils"
+)
+
+// runAuditsJob is started right after audit is requested instead of waiting for the next run
+const runAuditsJob = "RunAudits"
+
+type service struct {
+	audits    audits
+	scheduler scheduler
+	logger    *slog.Logger
+}
+
+type audits interface {
+	AddAudit(ctx context.Context, pubkey string, contract string) (audit db.Audit, found bool, err error)
+	GetAudit(ctx context.Context, id int64) (audit *db.Audit, err error)
+}
+
+type scheduler interface {
+	RunJob(ctx context.Context, name string) (found bool, err error)
+}
+
+type Audits interface {
+	AddAudit(ctx context.Context, req v1.AuditRequest) (resp v1.Audit, err error)
+	GetAudit(ctx context.Context, id int64) (resp v1.Audit, err error)
+}
+
+func (s *service) AddAudit(ctx context.Context, req v1.AuditRequest) (resp v1.Audit, err error) {
+	log := s.logger.With(slog.String("method", "AddAudit"))
+
+	if !utils.ValidatePubKey(req.ProviderPubKey) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid provider pubkey")
+		return
+	}
+
+	contract, ok := utils.NormalizeAddress(req.Contract)
+	if !ok {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid contract address")
+		return
+	}
+
+	audit, found, dbErr := s.audits.AddAudit(ctx, strings.ToLower(req.ProviderPubKey), contract)
+	if dbErr != nil {
+		log.Error("failed to add audit", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if !found {
+		err = models.NewAppError(models.NotFoundErrorCode, "storage contract of the provider not found")
+		return
+	}
+
+	// audit is started on the next job run anyway
+	if _, rErr := s.scheduler.RunJob(ctx, runAuditsJob); rErr != nil {
+		log.Warn("failed to trigger audits job", slog.String("error", rErr.Error()))
+	}
+
+	log.Info("audit requested", slog.Int64("id", audit.ID), slog.String("provider_pubkey", audit.ProviderPublicKey), slog.String("contract", contract))
+
+	resp = convertAudit(audit)
+
+	return
+}
+
+func (s *service) GetAudit(ctx context.Context, id int64) (resp v1.Audit, err error) {
+	log := s.logger.With(slog.String("method", "GetAudit"), slog.Int64("id", id))
+
+	audit, dbErr := s.audits.GetAudit(ctx, id)
+	if dbErr != nil {
+		log.Error("failed to get audit", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if audit == nil {
+		err = models.NewAppError(models.NotFoundErrorCode, "audit not found")
+		return
+	}
+
+	resp = convertAudit(*audit)
+
+	return
+}
+
+func convertAudit(a db.Audit) v1.Audit {
+	resp := v1.Audit{
+		ID:             a.ID,
+		ProviderPubKey: a.ProviderPublicKey,
+		Contract:       a.ContractAddress,
+		BagID:          a.BagID,
+		Status:         a.Status,
+		PiecesTotal:    a.PiecesTotal,
+		PiecesChecked:  a.PiecesChecked,
+		MissingPieces:  a.MissingPieces,
+		InvalidPieces:  a.InvalidPieces,
+		BytesReceived:  a.BytesReceived,
+		CreatedAt:      a.CreatedAt.Unix(),
+	}
+
+	if a.Error != nil {
+		resp.Error = *a.Error
+	}
+
+	if a.StartedAt != nil {
+		t := a.StartedAt.Unix()
+		resp.StartedAt = &t
+	}
+
+	if a.FinishedAt != nil {
+		t := a.FinishedAt.Unix()
+		resp.FinishedAt = &t
+	}
+
+	return resp
+}
+
+func NewService(
+	audits audits,
+	scheduler scheduler,
+	logger *slog.Logger,
+) Audits {
+	return &service{
+		audits:    audits,
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
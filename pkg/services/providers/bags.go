@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/utils"
+)
+
+func (s *service) GetBag(ctx context.Context, bagID string) (resp v1.BagResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetBag"), slog.String("bag_id", bagID))
+
+	if !utils.ValidateBagID(bagID) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid bag id")
+		return
+	}
+	bagID = strings.ToLower(bagID)
+
+	contracts, dbErr := s.providers.GetBagContracts(ctx, bagID)
+	if dbErr != nil {
+		log.Error("failed to get bag contracts", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if len(contracts) == 0 {
+		err = models.NewAppError(models.NotFoundErrorCode, "bag not found")
+		return
+	}
+
+	resp = groupBagContracts(bagID, contracts)
+
+	return
+}
+
+// groupBagContracts groups contract rows by contract address, rows must be ordered by it.
+// Provider storing the bag in several contracts is counted once in replication
+func groupBagContracts(bagID string, contracts []db.BagContract) (resp v1.BagResponse) {
+	resp = v1.BagResponse{
+		BagID:     bagID,
+		Contracts: []v1.BagContract{},
+	}
+
+	valid := make(map[string]struct{})
+	for _, c := range contracts {
+		if n := len(resp.Contracts); n == 0 || resp.Contracts[n-1].Address != c.Address {
+			resp.Contracts = append(resp.Contracts, v1.BagContract{
+				Address:      c.Address,
+				OwnerAddress: c.OwnerAddress,
+				Size:         c.Size,
+				Providers:    []v1.BagProvider{},
+			})
+		}
+
+		p := newBagProvider(c.ProviderPublicKey, c.ProviderAddress, c.Reason, c.ReasonTimestamp, c.Confidence)
+
+		if c.Reason != nil && constants.ReasonCode(*c.Reason) == constants.ValidStorageProof {
+			valid[c.ProviderAddress] = struct{}{}
+		}
+
+		last := &resp.Contracts[len(resp.Contracts)-1]
+		last.Providers = append(last.Providers, p)
+	}
+
+	resp.Replication = uint32(len(valid))
+
+	return
+}
+
+func newBagProvider(pubkey *string, address string, reason *uint32, reasonTime *time.Time, confidence *float64) v1.BagProvider {
+	p := v1.BagProvider{
+		PublicKey:  pubkey,
+		Address:    address,
+		Reason:     reason,
+		Confidence: confidence,
+	}
+
+	if reasonTime != nil {
+		timestamp := reasonTime.Unix()
+		p.ReasonTimestamp = &timestamp
+	}
+
+	return p
+}
@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"testing"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+func Test_GroupBagContracts(t *testing.T) {
+	valid := uint32(constants.ValidStorageProof)
+	failed := uint32(constants.CantGetPiece)
+	pubkey := "provider"
+
+	contracts := []db.BagContract{
+		{Address: "c1", ProviderAddress: "p1", ProviderPublicKey: &pubkey, Reason: &valid},
+		{Address: "c1", ProviderAddress: "p2", Reason: &failed},
+		{Address: "c2", ProviderAddress: "p1", Reason: &valid},
+		{Address: "c2", ProviderAddress: "p3"},
+	}
+
+	resp := groupBagContracts("bag", contracts)
+
+	if len(resp.Contracts) != 2 || len(resp.Contracts[0].Providers) != 2 || len(resp.Contracts[1].Providers) != 2 {
+		t.Fatalf("unexpected contracts: %+v", resp.Contracts)
+	}
+
+	if resp.Replication != 1 {
+		t.Fatalf("replication = %d, want 1", resp.Replication)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 const (
 	filtersRangeKey = "filtersRange"
+	statsKey        = "stats"
 )
 
 type cacheMiddleware struct {
@@ -27,6 +28,30 @@ func (c *cacheMiddleware) SearchProviders(ctx context.Context, req v1.SearchProv
 	return c.svc.SearchProviders(ctx, req)
 }
 
+func (c *cacheMiddleware) GetProvider(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderResponse, err error) {
+	return c.svc.GetProvider(ctx, pubkey, req)
+}
+
+func (c *cacheMiddleware) GetStorageContractsHistory(ctx context.Context, req v1.ContractsHistoryRequest) (resp v1.ContractsHistoryResponse, err error) {
+	return c.svc.GetStorageContractsHistory(ctx, req)
+}
+
+func (c *cacheMiddleware) GetProviderRating(ctx context.Context, pubkey string) (resp v1.ProviderRatingResponse, err error) {
+	return c.svc.GetProviderRating(ctx, pubkey)
+}
+
+func (c *cacheMiddleware) GetProviderPrices(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderPricesResponse, err error) {
+	return c.svc.GetProviderPrices(ctx, pubkey, req)
+}
+
+func (c *cacheMiddleware) GetNetworkPrices(ctx context.Context, req v1.ProviderHistoryRequest) (resp v1.NetworkPricesResponse, err error) {
+	return c.svc.GetNetworkPrices(ctx, req)
+}
+
+func (c *cacheMiddleware) GetQuote(ctx context.Context, req v1.QuoteRequest) (resp v1.QuoteResponse, err error) {
+	return c.svc.GetQuote(ctx, req)
+}
+
 func (c *cacheMiddleware) GetFiltersRange(ctx context.Context) (filtersRange v1.FiltersRangeResp, err error) {
 	v, ok := c.cache.Get(filtersRangeKey)
 	if !ok {
@@ -41,6 +66,20 @@ func (c *cacheMiddleware) GetFiltersRange(ctx context.Context) (filtersRange v1.
 	return c.actualFiltersRange(ctx)
 }
 
+func (c *cacheMiddleware) GetStats(ctx context.Context) (resp v1.StatsResponse, err error) {
+	v, ok := c.cache.Get(statsKey)
+	if !ok {
+		return c.actualStats(ctx)
+	}
+
+	resp, ok = v.(v1.StatsResponse)
+	if ok {
+		return
+	}
+
+	return c.actualStats(ctx)
+}
+
 func (c *cacheMiddleware) GetLatestTelemetry(ctx context.Context) (providers []interface{}, err error) {
 	data := c.latestTelemetryBuffer.GetAll()
 	if len(data) == 0 {
@@ -61,12 +100,15 @@ func (c *cacheMiddleware) GetLatestTelemetry(ctx context.Context) (providers []i
 	return
 }
 
-func (c *cacheMiddleware) UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte) (err error) {
-	err = c.svc.UpdateTelemetry(ctx, telemetry, rawBody)
+func (c *cacheMiddleware) UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte, sign v1.PayloadSignature) (err error) {
+	err = c.svc.UpdateTelemetry(ctx, telemetry, rawBody, sign)
 	if err != nil {
 		return
 	}
 
+	// invalid signatures are rejected by service, so here signed means verified
+	telemetry.IsSigned = sign.Signature != ""
+
 	telemetryCopy, copyErr := utils.DeepCopy(telemetry)
 	if copyErr != nil {
 		telemetryCopy = telemetry
@@ -79,12 +121,14 @@ func (c *cacheMiddleware) UpdateTelemetry(ctx context.Context, telemetry v1.Tele
 	return
 }
 
-func (c *cacheMiddleware) UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest) (err error) {
-	err = c.svc.UpdateBenchmarks(ctx, benchmark)
+func (c *cacheMiddleware) UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest, rawBody []byte, sign v1.PayloadSignature) (err error) {
+	err = c.svc.UpdateBenchmarks(ctx, benchmark, rawBody, sign)
 	if err != nil {
 		return
 	}
 
+	benchmark.IsSigned = sign.Signature != ""
+
 	benchmarkCopy, copyErr := utils.DeepCopy(benchmark)
 	if copyErr != nil {
 		benchmarkCopy = benchmark
@@ -94,6 +138,18 @@ func (c *cacheMiddleware) UpdateBenchmarks(ctx context.Context, benchmark v1.Ben
 	return
 }
 
+func (c *cacheMiddleware) GetBag(ctx context.Context, bagID string) (v1.BagResponse, error) {
+	return c.svc.GetBag(ctx, bagID)
+}
+
+func (c *cacheMiddleware) GetOwnerContracts(ctx context.Context, owner string, req v1.OwnerContractsRequest) (v1.OwnerContractsResponse, error) {
+	return c.svc.GetOwnerContracts(ctx, owner, req)
+}
+
+func (c *cacheMiddleware) GetProviderContracts(ctx context.Context, pubkey string, req v1.ProviderContractsRequest) (v1.ProviderContractsResponse, error) {
+	return c.svc.GetProviderContracts(ctx, pubkey, req)
+}
+
 func (c *cacheMiddleware) GetStorageContractsChecks(ctx context.Context, req v1.ContractsStatusesRequest) ([]v1.ContractCheck, error) {
 	return c.svc.GetStorageContractsChecks(ctx, req)
 }
@@ -108,6 +164,16 @@ func (c *cacheMiddleware) actualFiltersRange(ctx context.Context) (filtersRange
 	return
 }
 
+func (c *cacheMiddleware) actualStats(ctx context.Context) (resp v1.StatsResponse, err error) {
+	resp, err = c.svc.GetStats(ctx)
+	if err != nil {
+		return
+	}
+
+	c.cache.Set(statsKey, resp)
+	return
+}
+
 func NewCacheMiddleware(
 	svc Providers,
 	telemetry *cache.SimpleCache,
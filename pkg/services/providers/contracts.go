@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/utils"
+)
+
+const (
+	maxProviderContractsLimit = 1000
+	maxContractsReasonsFilter = 32
+)
+
+func (s *service) GetProviderContracts(ctx context.Context, pubkey string, req v1.ProviderContractsRequest) (resp v1.ProviderContractsResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetProviderContracts"), slog.String("pubkey", pubkey))
+
+	if !utils.ValidatePubKey(pubkey) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid pubkey")
+		return
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	if len(req.Reasons) > maxContractsReasonsFilter {
+		err = models.NewAppError(models.BadRequestErrorCode, "too many reasons in filter")
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > maxProviderContractsLimit {
+		limit = maxProviderContractsLimit
+	}
+	offset := max(req.Offset, 0)
+
+	p, dbErr := s.providers.GetProvidersByPubkeys(ctx, []string{pubkey})
+	if dbErr != nil {
+		log.Error("failed to get provider", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if len(p) == 0 {
+		err = models.NewAppError(models.NotFoundErrorCode, "provider not found")
+		return
+	}
+
+	contracts, total, dbErr := s.providers.GetProviderContracts(ctx, pubkey, req.Reasons, limit, offset)
+	if dbErr != nil {
+		log.Error("failed to get provider contracts", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.ProviderContractsResponse{
+		PubKey:    pubkey,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+		Contracts: make([]v1.ProviderContract, 0, len(contracts)),
+	}
+
+	for _, c := range contracts {
+		contract := v1.ProviderContract{
+			Address:      c.Address,
+			BagID:        c.BagID,
+			Size:         c.Size,
+			OwnerAddress: c.OwnerAddress,
+			Reason:       c.Reason,
+			Confidence:   c.Confidence,
+		}
+
+		if c.ReasonTimestamp != nil {
+			timestamp := c.ReasonTimestamp.Unix()
+			contract.ReasonTimestamp = &timestamp
+		}
+
+		resp.Contracts = append(resp.Contracts, contract)
+	}
+
+	return
+}
@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"sort"
+	"time"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const (
+	defaultHistoryRange  = 7 * 24 * time.Hour
+	maxHistoryRange      = 365 * 24 * time.Hour
+	defaultHistoryPoints = 168
+	maxHistoryPoints     = 1000
+	minHistoryBucket     = 5 * time.Minute
+)
+
+type historyRange struct {
+	from   time.Time
+	to     time.Time
+	bucket time.Duration
+}
+
+func resolveHistoryRange(req v1.ProviderHistoryRequest, now time.Time) (r historyRange, err error) {
+	r.to = now
+	if req.To > 0 {
+		r.to = time.Unix(req.To, 0)
+	}
+
+	r.from = r.to.Add(-defaultHistoryRange)
+	if req.From > 0 {
+		r.from = time.Unix(req.From, 0)
+	}
+
+	span := r.to.Sub(r.from)
+	if span <= 0 {
+		err = models.NewAppError(models.BadRequestErrorCode, "from must be before to")
+		return
+	}
+
+	if span > maxHistoryRange {
+		err = models.NewAppError(models.BadRequestErrorCode, "requested range is too long")
+		return
+	}
+
+	if req.Bucket > 0 {
+		r.bucket = time.Duration(req.Bucket) * time.Second
+		if r.bucket < minHistoryBucket || span/r.bucket > maxHistoryPoints {
+			err = models.NewAppError(models.BadRequestErrorCode, "invalid bucket size")
+			return
+		}
+
+		return
+	}
+
+	r.bucket = (span / defaultHistoryPoints).Truncate(time.Second)
+	if r.bucket < minHistoryBucket {
+		r.bucket = minHistoryBucket
+	}
+
+	return
+}
+
+// mergeHistory joins separate history series into one timeline ordered by bucket time.
+func mergeHistory(
+	providers []db.ProviderHistoryPoint,
+	statuses []db.StatusHistoryPoint,
+	telemetry []db.TelemetryHistoryPoint,
+	benchmarks []db.BenchmarkHistoryPoint,
+) []v1.ProviderHistoryPoint {
+	points := make(map[int64]*v1.ProviderHistoryPoint)
+	point := func(t time.Time) *v1.ProviderHistoryPoint {
+		ts := t.Unix()
+		p, ok := points[ts]
+		if !ok {
+			p = &v1.ProviderHistoryPoint{Time: ts}
+			points[ts] = p
+		}
+
+		return p
+	}
+
+	for _, h := range providers {
+		p := point(h.Time)
+		p.Rating = h.Rating
+		p.UpTime = h.UpTime
+		p.Price = h.Price
+	}
+
+	for _, h := range statuses {
+		p := point(h.Time)
+		p.ChecksTotal = &h.Total
+		p.ChecksOnline = &h.Online
+	}
+
+	for _, h := range telemetry {
+		p := point(h.Time)
+		p.TotalProviderSpace = h.TotalProviderSpace
+		p.UsedProviderSpace = h.UsedProviderSpace
+		p.FreeSpace = h.FreeSpace
+	}
+
+	for _, h := range benchmarks {
+		p := point(h.Time)
+		p.BenchmarkDiskReadSpeed = h.DiskReadSpeed
+		p.BenchmarkDiskWriteSpeed = h.DiskWriteSpeed
+		p.SpeedtestDownload = h.SpeedtestDownload
+		p.SpeedtestUpload = h.SpeedtestUpload
+		p.SpeedtestPing = h.SpeedtestPing
+	}
+
+	timeline := make([]v1.ProviderHistoryPoint, 0, len(points))
+	for _, p := range points {
+		timeline = append(timeline, *p)
+	}
+
+	sort.Slice(timeline, func(i, j int) bool {
+		return timeline[i].Time < timeline[j].Time
+	})
+
+	return timeline
+}
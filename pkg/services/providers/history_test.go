@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"testing"
+	"time"
+
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+func Test_ResolveHistoryRange(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	day := int64(24 * 60 * 60)
+
+	tests := []struct {
+		name       string
+		req        v1.ProviderHistoryRequest
+		ok         bool
+		wantFrom   int64
+		wantTo     int64
+		wantBucket time.Duration
+	}{
+		{
+			name:       "default range",
+			ok:         true,
+			wantFrom:   now.Unix() - 7*day,
+			wantTo:     now.Unix(),
+			wantBucket: time.Hour,
+		},
+		{
+			name:       "from is before to",
+			req:        v1.ProviderHistoryRequest{To: now.Unix() - day},
+			ok:         true,
+			wantFrom:   now.Unix() - 8*day,
+			wantTo:     now.Unix() - day,
+			wantBucket: time.Hour,
+		},
+		{
+			name:       "short range uses min bucket",
+			req:        v1.ProviderHistoryRequest{From: now.Unix() - 3600},
+			ok:         true,
+			wantFrom:   now.Unix() - 3600,
+			wantTo:     now.Unix(),
+			wantBucket: minHistoryBucket,
+		},
+		{
+			name:       "explicit bucket",
+			req:        v1.ProviderHistoryRequest{From: now.Unix() - day, Bucket: 600},
+			ok:         true,
+			wantFrom:   now.Unix() - day,
+			wantTo:     now.Unix(),
+			wantBucket: 10 * time.Minute,
+		},
+		{
+			name:       "max range",
+			req:        v1.ProviderHistoryRequest{From: now.Unix() - 365*day},
+			ok:         true,
+			wantFrom:   now.Unix() - 365*day,
+			wantTo:     now.Unix(),
+			wantBucket: (365 * 24 * time.Hour / defaultHistoryPoints).Truncate(time.Second),
+		},
+		{name: "from after to", req: v1.ProviderHistoryRequest{From: now.Unix() + 1}},
+		{name: "empty range", req: v1.ProviderHistoryRequest{From: now.Unix(), To: now.Unix()}},
+		{name: "too long range", req: v1.ProviderHistoryRequest{From: now.Unix() - 366*day}},
+		{name: "too small bucket", req: v1.ProviderHistoryRequest{Bucket: 60}},
+		{name: "too many points", req: v1.ProviderHistoryRequest{From: now.Unix() - 30*day, Bucket: 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := resolveHistoryRange(tt.req, now)
+			if !tt.ok {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", r)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if r.from.Unix() != tt.wantFrom || r.to.Unix() != tt.wantTo || r.bucket != tt.wantBucket {
+				t.Fatalf("range = %d..%d by %s, want %d..%d by %s",
+					r.from.Unix(), r.to.Unix(), r.bucket, tt.wantFrom, tt.wantTo, tt.wantBucket)
+			}
+		})
+	}
+}
+
+func Test_MergeHistory(t *testing.T) {
+	t1 := time.Unix(1_700_000_000, 0)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+	value := func(v float64) *float64 { return &v }
+
+	timeline := mergeHistory(
+		[]db.ProviderHistoryPoint{{Time: t2, Rating: value(4.5)}},
+		[]db.StatusHistoryPoint{{Time: t1, Total: 10, Online: 9}, {Time: t2, Total: 12, Online: 12}},
+		[]db.TelemetryHistoryPoint{{Time: t3, FreeSpace: value(100)}},
+		[]db.BenchmarkHistoryPoint{{Time: t2, SpeedtestPing: value(15)}},
+	)
+
+	if len(timeline) != 3 {
+		t.Fatalf("got %d points, want 3", len(timeline))
+	}
+
+	for i, want := range []time.Time{t1, t2, t3} {
+		if timeline[i].Time != want.Unix() {
+			t.Fatalf("point %d time = %d, want %d", i, timeline[i].Time, want.Unix())
+		}
+	}
+
+	if p := timeline[0]; *p.ChecksTotal != 10 || *p.ChecksOnline != 9 || p.Rating != nil || p.FreeSpace != nil {
+		t.Errorf("unexpected first point: %+v", p)
+	}
+
+	if p := timeline[1]; *p.Rating != 4.5 || *p.ChecksTotal != 12 || *p.SpeedtestPing != 15 || p.FreeSpace != nil {
+		t.Errorf("unexpected second point: %+v", p)
+	}
+
+	if p := timeline[2]; *p.FreeSpace != 100 || p.ChecksTotal != nil || p.Rating != nil {
+		t.Errorf("unexpected third point: %+v", p)
+	}
+
+	if timeline := mergeHistory(nil, nil, nil, nil); len(timeline) != 0 {
+		t.Fatalf("got %d points of empty history", len(timeline))
+	}
+}
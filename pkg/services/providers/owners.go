@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/utils"
+)
+
+const maxOwnerContractsLimit = 500
+
+func (s *service) GetOwnerContracts(ctx context.Context, owner string, req v1.OwnerContractsRequest) (resp v1.OwnerContractsResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetOwnerContracts"), slog.String("owner", owner))
+
+	owner, ok := utils.NormalizeAddress(owner)
+	if !ok {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid owner address")
+		return
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > maxOwnerContractsLimit {
+		limit = maxOwnerContractsLimit
+	}
+	offset := max(req.Offset, 0)
+
+	rows, total, dbErr := s.providers.GetOwnerContracts(ctx, owner, req.History, limit, offset)
+	if dbErr != nil {
+		log.Error("failed to get owner contracts", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.OwnerContractsResponse{
+		Owner:     owner,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+		Contracts: groupOwnerContracts(rows),
+	}
+
+	return
+}
+
+// groupOwnerContracts groups contract rows by contract address, rows must be ordered by it.
+// Balances are set for active contracts only
+func groupOwnerContracts(rows []db.OwnerContract) []v1.OwnerContract {
+	contracts := []v1.OwnerContract{}
+	for _, r := range rows {
+		if n := len(contracts); n == 0 || contracts[n-1].Address != r.Address {
+			contracts = append(contracts, v1.OwnerContract{
+				Address:   r.Address,
+				BagID:     r.BagID,
+				Size:      r.Size,
+				Providers: []v1.OwnerContractProvider{},
+			})
+		}
+
+		p := v1.OwnerContractProvider{
+			BagProvider: newBagProvider(r.ProviderPublicKey, r.ProviderAddress, r.Reason, r.ReasonTimestamp, r.Confidence),
+		}
+
+		if r.RemovedAt != nil {
+			removedAt := r.RemovedAt.Unix()
+			p.RemovedAt = &removedAt
+		}
+
+		last := &contracts[len(contracts)-1]
+		last.Providers = append(last.Providers, p)
+
+		if r.RemovedAt == nil {
+			last.Active = true
+			setContractBalance(last, r)
+		}
+	}
+
+	return contracts
+}
+
+// setContractBalance sets the last balance of contract stored by the storage proofs worker
+func setContractBalance(contract *v1.OwnerContract, r db.OwnerContract) {
+	if r.Balance == nil || r.BalanceUpdatedAt == nil {
+		return
+	}
+
+	updatedAt := r.BalanceUpdatedAt.Unix()
+	contract.Balance = r.Balance
+	contract.DaysLeft = r.DaysLeft
+	contract.BalanceUpdatedAt = &updatedAt
+}
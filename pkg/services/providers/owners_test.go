@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"testing"
+	"time"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+func Test_GroupOwnerContracts(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	removedAt := now.Add(-time.Hour)
+	balance := uint64(1_000_000_000)
+	daysLeft := 12.5
+
+	rows := []db.OwnerContract{
+		// active contract, the provider which left it doesn't close it
+		{Address: "c1", ProviderAddress: "p1", Balance: &balance, DaysLeft: &daysLeft, BalanceUpdatedAt: &now},
+		{Address: "c1", ProviderAddress: "p2", RemovedAt: &removedAt, Balance: &balance, DaysLeft: &daysLeft, BalanceUpdatedAt: &now},
+		// closed contract keeps its last balance in db until it's cleaned
+		{Address: "c2", ProviderAddress: "p1", RemovedAt: &removedAt, Balance: &balance, BalanceUpdatedAt: &now},
+		// balance isn't read from chain yet
+		{Address: "c3", ProviderAddress: "p3"},
+		// providers don't charge anything
+		{Address: "c4", ProviderAddress: "p4", Balance: &balance, BalanceUpdatedAt: &now},
+	}
+
+	contracts := groupOwnerContracts(rows)
+
+	if len(contracts) != 4 {
+		t.Fatalf("got %d contracts, want 4: %+v", len(contracts), contracts)
+	}
+
+	tests := []struct {
+		address   string
+		active    bool
+		providers int
+		removed   int
+		balance   bool
+		daysLeft  bool
+	}{
+		{address: "c1", active: true, providers: 2, removed: 1, balance: true, daysLeft: true},
+		{address: "c2", active: false, providers: 1, removed: 1},
+		{address: "c3", active: true, providers: 1},
+		{address: "c4", active: true, providers: 1, balance: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			c := contracts[i]
+			if c.Address != tt.address || c.Active != tt.active || len(c.Providers) != tt.providers {
+				t.Fatalf("unexpected contract: %+v", c)
+			}
+
+			removed := 0
+			for _, p := range c.Providers {
+				if p.RemovedAt != nil {
+					removed++
+				}
+			}
+
+			if removed != tt.removed {
+				t.Errorf("got %d removed providers, want %d", removed, tt.removed)
+			}
+
+			if (c.Balance != nil) != tt.balance || (c.BalanceUpdatedAt != nil) != tt.balance {
+				t.Errorf("balance = %v, updated at %v, want set = %t", c.Balance, c.BalanceUpdatedAt, tt.balance)
+			}
+
+			if tt.balance && (*c.Balance != balance || *c.BalanceUpdatedAt != now.Unix()) {
+				t.Errorf("balance = %d, updated at %d, want %d at %d", *c.Balance, *c.BalanceUpdatedAt, balance, now.Unix())
+			}
+
+			if (c.DaysLeft != nil) != tt.daysLeft {
+				t.Errorf("days left = %v, want set = %t", c.DaysLeft, tt.daysLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/utils"
+)
+
+func (s *service) GetProviderPrices(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderPricesResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetProviderPrices"), slog.String("pubkey", pubkey))
+
+	if !utils.ValidatePubKey(pubkey) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid pubkey")
+		return
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	r, err := resolveHistoryRange(req, time.Now())
+	if err != nil {
+		return
+	}
+
+	p, dbErr := s.providers.GetProvidersByPubkeys(ctx, []string{pubkey})
+	if dbErr != nil {
+		log.Error("failed to get provider", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if len(p) == 0 {
+		err = models.NewAppError(models.NotFoundErrorCode, "provider not found")
+		return
+	}
+
+	points, dbErr := s.providers.GetProviderPrices(ctx, pubkey, r.from, r.to)
+	if dbErr != nil {
+		log.Error("failed to get provider prices", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.ProviderPricesResponse{
+		PubKey: pubkey,
+		From:   r.from.Unix(),
+		To:     r.to.Unix(),
+		Points: make([]v1.ProviderPricePoint, 0, len(points)),
+	}
+
+	for _, p := range points {
+		resp.Points = append(resp.Points, v1.ProviderPricePoint{
+			Time:         p.Time.Unix(),
+			RatePerMBDay: p.RatePerMBDay,
+			MinBounty:    p.MinBounty,
+			MinSpan:      p.MinSpan,
+			MaxSpan:      p.MaxSpan,
+		})
+	}
+
+	return
+}
+
+func (s *service) GetNetworkPrices(ctx context.Context, req v1.ProviderHistoryRequest) (resp v1.NetworkPricesResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetNetworkPrices"))
+
+	r, err := resolveHistoryRange(req, time.Now())
+	if err != nil {
+		return
+	}
+
+	points, dbErr := s.providers.GetNetworkPrices(ctx, r.from, r.to, r.bucket)
+	if dbErr != nil {
+		log.Error("failed to get network prices", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.NetworkPricesResponse{
+		From:   r.from.Unix(),
+		To:     r.to.Unix(),
+		Bucket: int64(r.bucket.Seconds()),
+		Points: make([]v1.NetworkPricePoint, 0, len(points)),
+	}
+
+	for _, p := range points {
+		resp.Points = append(resp.Points, v1.NetworkPricePoint{
+			Time:      p.Time.Unix(),
+			Providers: p.Providers,
+			Median:    p.Median,
+			Min:       p.Min,
+			Max:       p.Max,
+		})
+	}
+
+	return
+}
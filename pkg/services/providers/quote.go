@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tlb"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/pricing"
+)
+
+const (
+	maxQuoteProviders = 32
+	maxQuoteDuration  = 10 * 365 * 24 * 60 * 60
+)
+
+func (s *service) GetQuote(ctx context.Context, req v1.QuoteRequest) (resp v1.QuoteResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetQuote"))
+
+	if req.Providers == 0 {
+		req.Providers = 1
+	}
+
+	switch {
+	case req.BagSize == 0:
+		err = models.NewAppError(models.BadRequestErrorCode, "bag_size is required")
+	case req.Duration == 0 || req.Duration > maxQuoteDuration:
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid duration")
+	case req.Providers < 0 || req.Providers > maxQuoteProviders:
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid providers count")
+	}
+	if err != nil {
+		return
+	}
+
+	candidates, dbErr := s.providers.GetQuoteCandidates(ctx, req.BagSize)
+	if dbErr != nil {
+		log.Error("failed to get quote candidates", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.QuoteResponse{
+		BagSize:   req.BagSize,
+		Duration:  req.Duration,
+		Providers: cheapestQuotes(candidates, req),
+	}
+
+	total := new(big.Int)
+	for _, p := range resp.Providers {
+		total.Add(total, new(big.Int).SetUint64(p.Cost))
+	}
+	if !total.IsUint64() {
+		resp = v1.QuoteResponse{}
+		err = models.NewAppError(models.BadRequestErrorCode, "total cost is too large")
+		return
+	}
+
+	resp.TotalCost = total.Uint64()
+	resp.TotalCostTON = tlb.FromNanoTON(total).String()
+
+	return
+}
+
+// cheapestQuotes returns quotes of providers accepting the contract, the cheapest first
+func cheapestQuotes(candidates []db.QuoteCandidate, req v1.QuoteRequest) []v1.QuoteProvider {
+	quotes := make([]v1.QuoteProvider, 0, len(candidates))
+	for _, c := range candidates {
+		q, ok := pricing.QuoteStorage(pricing.Rates{
+			RatePerMBDay: c.RatePerMBDay,
+			MinBounty:    c.MinBounty,
+			MinSpan:      c.MinSpan,
+			MaxSpan:      c.MaxSpan,
+		}, req.BagSize, req.Duration, req.Span)
+		if !ok || !q.Cost.IsUint64() {
+			continue
+		}
+
+		quotes = append(quotes, v1.QuoteProvider{
+			PubKey:       c.PubKey,
+			Address:      c.Address,
+			Rating:       c.Rating,
+			RatePerMBDay: c.RatePerMBDay,
+			Span:         q.Span,
+			Proofs:       q.Proofs,
+			Bounty:       q.Bounty.Uint64(),
+			Cost:         q.Cost.Uint64(),
+			CostTON:      tlb.FromNanoTON(q.Cost).String(),
+		})
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Cost < quotes[j].Cost
+	})
+
+	if len(quotes) > req.Providers {
+		quotes = quotes[:req.Providers]
+	}
+
+	return quotes
+}
@@ -7,20 +7,31 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	"mytonprovider-backend/pkg/constants"
 	"mytonprovider-backend/pkg/models"
 	v1 "mytonprovider-backend/pkg/models/api/v1"
 	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/rating"
+	"mytonprovider-backend/pkg/utils"
 )
 
 const (
 	maxProvidersLimit  = 1000
 	telemetryBodyLimit = 5000
+
+	maxContractsHistory          = 100
+	defaultContractsHistoryRange = 7 * 24 * time.Hour
+	maxContractsHistoryRange     = 31 * 24 * time.Hour
 )
 
 type service struct {
-	providers providers
-	logger    *slog.Logger
+	providers          providers
+	verifier           *signatureVerifier
+	scorer             rating.Scorer
+	ratingUptimeWindow string
+	logger             *slog.Logger
 }
 
 type providers interface {
@@ -28,15 +39,38 @@ type providers interface {
 	GetFiltersRange(ctx context.Context) (db.FiltersRange, error)
 	GetFilteredProviders(ctx context.Context, filters db.ProviderFilters, sort db.ProviderSort, limit, offset int) ([]db.ProviderDB, error)
 	GetStorageContractsChecks(ctx context.Context, contracts []string) ([]db.ContractCheck, error)
+	GetBagContracts(ctx context.Context, bagID string) ([]db.BagContract, error)
+	GetOwnerContracts(ctx context.Context, owner string, withHistory bool, limit, offset int) ([]db.OwnerContract, uint64, error)
+	GetProviderContracts(ctx context.Context, pubkey string, reasons []uint32, limit, offset int) ([]db.ProviderContract, uint64, error)
+	GetStorageProofsHistory(ctx context.Context, contracts []string, from, to time.Time) ([]db.StorageProofCheck, error)
+	GetProviderHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) ([]db.ProviderHistoryPoint, error)
+	GetProviderPrices(ctx context.Context, pubkey string, from, to time.Time) ([]db.ProviderPricePoint, error)
+	GetNetworkPrices(ctx context.Context, from, to time.Time, bucket time.Duration) ([]db.NetworkPricePoint, error)
+	GetQuoteCandidates(ctx context.Context, bagSize uint64) ([]db.QuoteCandidate, error)
+	GetNetworkStats(ctx context.Context) (db.NetworkStats, error)
+	GetProviderStatusesHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) ([]db.StatusHistoryPoint, error)
+	GetProviderTelemetryHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) ([]db.TelemetryHistoryPoint, error)
+	GetProviderBenchmarksHistory(ctx context.Context, pubkey string, from, to time.Time, bucket time.Duration) ([]db.BenchmarkHistoryPoint, error)
+	GetRatingInputs(ctx context.Context, uptimeWindow string, pubkeys []string) ([]db.RatingInputs, error)
 }
 
 type Providers interface {
 	SearchProviders(ctx context.Context, req v1.SearchProvidersRequest) (providers []v1.Provider, err error)
 	GetLatestTelemetry(ctx context.Context) (providers []interface{}, err error)
 	GetFiltersRange(ctx context.Context) (filtersRange v1.FiltersRangeResp, err error)
-	UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte) (err error)
-	UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest) (err error)
+	UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte, sign v1.PayloadSignature) (err error)
+	UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest, rawBody []byte, sign v1.PayloadSignature) (err error)
 	GetStorageContractsChecks(ctx context.Context, req v1.ContractsStatusesRequest) ([]v1.ContractCheck, error)
+	GetStorageContractsHistory(ctx context.Context, req v1.ContractsHistoryRequest) (resp v1.ContractsHistoryResponse, err error)
+	GetBag(ctx context.Context, bagID string) (resp v1.BagResponse, err error)
+	GetOwnerContracts(ctx context.Context, owner string, req v1.OwnerContractsRequest) (resp v1.OwnerContractsResponse, err error)
+	GetProviderContracts(ctx context.Context, pubkey string, req v1.ProviderContractsRequest) (resp v1.ProviderContractsResponse, err error)
+	GetProvider(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderResponse, err error)
+	GetProviderRating(ctx context.Context, pubkey string) (resp v1.ProviderRatingResponse, err error)
+	GetProviderPrices(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderPricesResponse, err error)
+	GetNetworkPrices(ctx context.Context, req v1.ProviderHistoryRequest) (resp v1.NetworkPricesResponse, err error)
+	GetQuote(ctx context.Context, req v1.QuoteRequest) (resp v1.QuoteResponse, err error)
+	GetStats(ctx context.Context) (resp v1.StatsResponse, err error)
 }
 
 func (s *service) SearchProviders(ctx context.Context, req v1.SearchProvidersRequest) (providers []v1.Provider, err error) {
@@ -52,6 +86,149 @@ func (s *service) SearchProviders(ctx context.Context, req v1.SearchProvidersReq
 	return
 }
 
+func (s *service) GetProvider(ctx context.Context, pubkey string, req v1.ProviderHistoryRequest) (resp v1.ProviderResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetProvider"), slog.String("pubkey", pubkey))
+
+	if !utils.ValidatePubKey(pubkey) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid pubkey")
+		return
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	r, err := resolveHistoryRange(req, time.Now())
+	if err != nil {
+		return
+	}
+
+	p, dbErr := s.providers.GetProvidersByPubkeys(ctx, []string{pubkey})
+	if dbErr != nil {
+		log.Error("failed to get provider", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if len(p) == 0 {
+		err = models.NewAppError(models.NotFoundErrorCode, "provider not found")
+		return
+	}
+
+	providersHistory, dbErr := s.providers.GetProviderHistory(ctx, pubkey, r.from, r.to, r.bucket)
+	if dbErr != nil {
+		log.Error("failed to get providers history", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	statusesHistory, dbErr := s.providers.GetProviderStatusesHistory(ctx, pubkey, r.from, r.to, r.bucket)
+	if dbErr != nil {
+		log.Error("failed to get statuses history", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	telemetryHistory, dbErr := s.providers.GetProviderTelemetryHistory(ctx, pubkey, r.from, r.to, r.bucket)
+	if dbErr != nil {
+		log.Error("failed to get telemetry history", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	benchmarksHistory, dbErr := s.providers.GetProviderBenchmarksHistory(ctx, pubkey, r.from, r.to, r.bucket)
+	if dbErr != nil {
+		log.Error("failed to get benchmarks history", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.ProviderResponse{
+		Provider: convertDBProvidersToAPI(p)[0],
+		History: v1.ProviderHistory{
+			From:   r.from.Unix(),
+			To:     r.to.Unix(),
+			Bucket: int64(r.bucket.Seconds()),
+			Points: mergeHistory(providersHistory, statusesHistory, telemetryHistory, benchmarksHistory),
+		},
+	}
+
+	return
+}
+
+func (s *service) GetProviderRating(ctx context.Context, pubkey string) (resp v1.ProviderRatingResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetProviderRating"), slog.String("pubkey", pubkey))
+
+	if !utils.ValidatePubKey(pubkey) {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid pubkey")
+		return
+	}
+	pubkey = strings.ToLower(pubkey)
+
+	p, dbErr := s.providers.GetProvidersByPubkeys(ctx, []string{pubkey})
+	if dbErr != nil {
+		log.Error("failed to get provider", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if len(p) == 0 {
+		err = models.NewAppError(models.NotFoundErrorCode, "provider not found")
+		return
+	}
+
+	inputs, dbErr := s.providers.GetRatingInputs(ctx, s.ratingUptimeWindow, []string{pubkey})
+	if dbErr != nil {
+		log.Error("failed to get rating inputs", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	// provider is not initialized yet, so it's not rated
+	if len(inputs) == 0 {
+		resp = v1.ProviderRatingResponse{
+			PubKey:       p[0].PubKey,
+			Rating:       p[0].Rating,
+			Components:   []v1.RatingComponent{},
+			UptimeWindow: s.ratingUptimeWindow,
+		}
+		return
+	}
+
+	in := inputs[0]
+	r := s.scorer.Score(in, time.Now())
+
+	// share of the base in the final score, zero if score was not calculated
+	scale := 0.0
+	if r.Score != 0 {
+		scale = r.UptimeFactor / r.PriceDivisor / r.Divider
+	}
+
+	components := make([]v1.RatingComponent, 0, len(r.Components))
+	for _, c := range r.Components {
+		components = append(components, v1.RatingComponent{
+			Name:         c.Name,
+			Value:        c.Value,
+			Coefficient:  c.Coefficient,
+			Contribution: c.Contribution,
+			ScoreShare:   c.Contribution * scale,
+		})
+	}
+
+	resp = v1.ProviderRatingResponse{
+		PubKey:         p[0].PubKey,
+		Rating:         p[0].Rating,
+		Score:          r.Score,
+		Components:     components,
+		Base:           r.Base,
+		UptimeWindow:   s.ratingUptimeWindow,
+		Uptime:         in.Uptime * 100,
+		UptimeExponent: r.UptimeExponent,
+		UptimeFactor:   r.UptimeFactor,
+		PriceDivisor:   r.PriceDivisor,
+		Divider:        r.Divider,
+	}
+
+	return
+}
+
 func (s *service) GetLatestTelemetry(ctx context.Context) (providers []interface{}, err error) {
 	// logic in cache middleware
 
@@ -96,7 +273,7 @@ func (s *service) GetFiltersRange(ctx context.Context) (filtersRange v1.FiltersR
 	return
 }
 
-func (s *service) UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte) (err error) {
+func (s *service) UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryRequest, rawBody []byte, sign v1.PayloadSignature) (err error) {
 	if telemetry.Storage.Provider.PubKey == "" {
 		return models.NewAppError(models.BadRequestErrorCode, "")
 	}
@@ -105,16 +282,42 @@ func (s *service) UpdateTelemetry(ctx context.Context, telemetry v1.TelemetryReq
 		return models.NewAppError(models.BadRequestErrorCode, "request body too large")
 	}
 
+	signed, err := s.verifier.verify(telemetryPayload, telemetry.Storage.Provider.PubKey, rawBody, sign)
+	if err != nil {
+		s.logger.Warn("telemetry rejected",
+			slog.String("method", "UpdateTelemetry"),
+			slog.String("pubkey", telemetry.Storage.Provider.PubKey),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	if !signed {
+		s.logger.Debug("unsigned telemetry accepted", slog.String("pubkey", telemetry.Storage.Provider.PubKey))
+	}
+
 	// logic in cache middleware
 
 	return nil
 }
 
-func (s *service) UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest) (err error) {
+func (s *service) UpdateBenchmarks(ctx context.Context, benchmark v1.BenchmarksRequest, rawBody []byte, sign v1.PayloadSignature) (err error) {
 	if benchmark.PubKey == "" {
 		return models.NewAppError(models.BadRequestErrorCode, "")
 	}
 
+	signed, err := s.verifier.verify(benchmarksPayload, benchmark.PubKey, rawBody, sign)
+	if err != nil {
+		s.logger.Warn("benchmarks rejected",
+			slog.String("method", "UpdateBenchmarks"),
+			slog.String("pubkey", benchmark.PubKey),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	if !signed {
+		s.logger.Debug("unsigned benchmarks accepted", slog.String("pubkey", benchmark.PubKey))
+	}
+
 	// logic in cache middleware
 
 	return nil
@@ -184,12 +387,19 @@ func convertDBProvidersToAPI(providersDB []db.ProviderDB) []v1.Provider {
 			StatusRatio:         provider.StatusRatio,
 			StatusesReasonStats: statusesReasonStats,
 			UpTime:              provider.UpTime,
+			UpTime24h:           provider.UpTime24h,
+			UpTime7d:            provider.UpTime7d,
+			UpTime30d:           provider.UpTime30d,
 			WorkingTime:         workingTime,
 			Rating:              provider.Rating,
 			MaxSpan:             provider.MaxSpan,
 			Price:               provider.Price,
 			MinSpan:             provider.MinSpan,
 			MaxBagSizeBytes:     provider.MaxBagSizeBytes,
+			RetrievalP50Ms:      provider.RetrievalP50Ms,
+			RetrievalP95Ms:      provider.RetrievalP95Ms,
+			RTTP50Ms:            provider.RTTP50Ms,
+			RTTP95Ms:            provider.RTTP95Ms,
 			RegTime:             provider.RegTime,
 			LastOnlineCheckTime: provider.LastOnlineCheckTime,
 			IsSendTelemetry:     provider.IsSendTelemetry,
@@ -213,6 +423,7 @@ func convertDBProvidersToAPI(providersDB []db.ProviderDB) []v1.Provider {
 				SpeedtestPing:           provider.Telemetry.SpeedtestPing,
 				Country:                 provider.Telemetry.Country,
 				ISP:                     provider.Telemetry.ISP,
+				IsSigned:                provider.Telemetry.IsSigned,
 			},
 		})
 	}
@@ -225,10 +436,17 @@ func buildProviderQueryParams(req v1.SearchProvidersRequest) (db.ProviderFilters
 		Location:                     req.Filters.Location,
 		RatingGt:                     req.Filters.RatingGt,
 		RatingLt:                     req.Filters.RatingLt,
+		RTTP50MsLt:                   req.Filters.RTTP50MsLt,
 		RegTimeDaysGt:                req.Filters.RegTimeDaysGt,
 		RegTimeDaysLt:                req.Filters.RegTimeDaysLt,
 		UpTimeGtPercent:              req.Filters.UpTimeGtPercent,
 		UpTimeLtPercent:              req.Filters.UpTimeLtPercent,
+		UpTime24hGtPercent:           req.Filters.UpTime24hGtPercent,
+		UpTime24hLtPercent:           req.Filters.UpTime24hLtPercent,
+		UpTime7dGtPercent:            req.Filters.UpTime7dGtPercent,
+		UpTime7dLtPercent:            req.Filters.UpTime7dLtPercent,
+		UpTime30dGtPercent:           req.Filters.UpTime30dGtPercent,
+		UpTime30dLtPercent:           req.Filters.UpTime30dLtPercent,
 		WorkingTimeGtSec:             req.Filters.WorkingTimeGtSec,
 		WorkingTimeLtSec:             req.Filters.WorkingTimeLtSec,
 		PriceGt:                      req.Filters.PriceGt,
@@ -319,6 +537,7 @@ func (s *service) GetStorageContractsChecks(ctx context.Context, req v1.Contract
 			Address:           dbReason.Address,
 			ProviderPublicKey: dbReason.ProviderPublicKey,
 			Reason:            dbReason.Reason,
+			Confidence:        dbReason.Confidence,
 		}
 
 		if dbReason.ReasonTimestamp != nil {
@@ -332,12 +551,100 @@ func (s *service) GetStorageContractsChecks(ctx context.Context, req v1.Contract
 	return reasons, nil
 }
 
+func (s *service) GetStorageContractsHistory(ctx context.Context, req v1.ContractsHistoryRequest) (resp v1.ContractsHistoryResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetStorageContractsHistory"))
+
+	if len(req.Contracts) == 0 {
+		resp.Contracts = []v1.ContractHistory{}
+		return
+	}
+
+	if len(req.Contracts) > maxContractsHistory {
+		err = models.NewAppError(models.BadRequestErrorCode, "too many contracts in request")
+		return
+	}
+
+	to := time.Now()
+	if req.To > 0 {
+		to = time.Unix(req.To, 0)
+	}
+
+	from := to.Add(-defaultContractsHistoryRange)
+	if req.From > 0 {
+		from = time.Unix(req.From, 0)
+	}
+
+	if !from.Before(to) {
+		err = models.NewAppError(models.BadRequestErrorCode, "from must be before to")
+		return
+	}
+
+	if to.Sub(from) > maxContractsHistoryRange {
+		err = models.NewAppError(models.BadRequestErrorCode, "requested range is too long")
+		return
+	}
+
+	addresses := make([]string, 0, len(req.Contracts))
+	for _, addr := range req.Contracts {
+		a, ok := utils.NormalizeAddress(addr)
+		if !ok {
+			err = models.NewAppError(models.BadRequestErrorCode, "invalid contract address: "+addr)
+			return
+		}
+		addresses = append(addresses, a)
+	}
+
+	checks, dbErr := s.providers.GetStorageProofsHistory(ctx, addresses, from, to)
+	if dbErr != nil {
+		log.Error("failed to get storage proofs history", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	timelines := make(map[string][]v1.StorageProofCheck, len(addresses))
+	for _, c := range checks {
+		timelines[c.ContractAddress] = append(timelines[c.ContractAddress], v1.StorageProofCheck{
+			ProviderPublicKey: c.ProviderPublicKey,
+			Reason:            c.Reason,
+			PieceID:           c.PieceID,
+			LatencyMs:         c.LatencyMs,
+			PiecesChecked:     c.PiecesChecked,
+			PiecesValid:       c.PiecesValid,
+			Confidence:        c.Confidence,
+			Timestamp:         c.CheckedAt.Unix(),
+		})
+	}
+
+	resp.Contracts = make([]v1.ContractHistory, 0, len(addresses))
+	for _, addr := range addresses {
+		timeline := timelines[addr]
+		if timeline == nil {
+			timeline = []v1.StorageProofCheck{}
+		}
+
+		resp.Contracts = append(resp.Contracts, v1.ContractHistory{
+			Address: addr,
+			Checks:  timeline,
+		})
+	}
+
+	return
+}
+
 func NewService(
 	providers providers,
+	allowUnsigned bool,
+	signatureMaxAge time.Duration,
+	signatureRejected *prometheus.CounterVec,
+	scorer rating.Scorer,
+	ratingUptimeWindow string,
 	logger *slog.Logger,
 ) Providers {
 	return &service{
-		providers: providers,
-		logger:    logger,
+		providers:          providers,
+		verifier:           newSignatureVerifier(allowUnsigned, signatureMaxAge, signatureRejected),
+		scorer:             scorer,
+		ratingUptimeWindow: ratingUptimeWindow,
+		logger:             logger,
 	}
 }
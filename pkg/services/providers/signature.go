@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mytonprovider-backend/pkg/cache"
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+)
+
+const (
+	telemetryPayload  = "telemetry"
+	benchmarksPayload = "benchmarks"
+
+	rejectInvalidPubKey    = "invalid_pubkey"
+	rejectUnsigned         = "unsigned"
+	rejectInvalidSignature = "invalid_signature"
+	rejectExpired          = "expired"
+	rejectReplay           = "replay"
+)
+
+type signatureVerifier struct {
+	seen          *cache.SimpleCache
+	allowUnsigned bool
+	maxAge        time.Duration
+	rejected      *prometheus.CounterVec
+}
+
+// verify checks payload signature made with provider key over "<timestamp>:<raw body>".
+// Unsigned payloads are accepted only if allowUnsigned is set, in that case signed is false.
+func (v *signatureVerifier) verify(payload, pubkey string, rawBody []byte, sign v1.PayloadSignature) (signed bool, err error) {
+	if sign.Signature == "" {
+		if v.allowUnsigned {
+			return false, nil
+		}
+
+		return false, v.reject(payload, rejectUnsigned, "payload signature required")
+	}
+
+	key, dErr := hex.DecodeString(pubkey)
+	if dErr != nil || len(key) != ed25519.PublicKeySize {
+		return false, v.reject(payload, rejectInvalidPubKey, "invalid provider pubkey")
+	}
+
+	signature, dErr := hex.DecodeString(sign.Signature)
+	if dErr != nil || len(signature) != ed25519.SignatureSize {
+		return false, v.reject(payload, rejectInvalidSignature, "invalid signature")
+	}
+
+	signedAt := time.Unix(sign.Timestamp, 0)
+	if d := time.Since(signedAt); d > v.maxAge || d < -v.maxAge {
+		return false, v.reject(payload, rejectExpired, "signature timestamp out of allowed window")
+	}
+
+	if !ed25519.Verify(key, signedMessage(sign.Timestamp, rawBody), signature) {
+		return false, v.reject(payload, rejectInvalidSignature, "invalid signature")
+	}
+
+	// signature is deterministic, so the same body and timestamp can't be sent twice
+	// while it is still in the allowed window
+	seenKey := payload + "/" + strings.ToLower(sign.Signature)
+	if !v.seen.SetIfAbsent(seenKey, struct{}{}) {
+		return false, v.reject(payload, rejectReplay, "payload already received")
+	}
+
+	return true, nil
+}
+
+func (v *signatureVerifier) reject(payload, reason, message string) error {
+	v.rejected.WithLabelValues(payload, reason).Inc()
+
+	return models.NewAppError(models.UnauthorizedErrorCode, message)
+}
+
+func signedMessage(timestamp int64, rawBody []byte) []byte {
+	msg := make([]byte, 0, len(rawBody)+21)
+	msg = strconv.AppendInt(msg, timestamp, 10)
+	msg = append(msg, ':')
+	msg = append(msg, rawBody...)
+
+	return msg
+}
+
+func newSignatureVerifier(allowUnsigned bool, maxAge time.Duration, rejected *prometheus.CounterVec) *signatureVerifier {
+	return &signatureVerifier{
+		// keep seen signatures a bit longer than they can be accepted
+		seen:          cache.NewSimpleCache(2 * maxAge),
+		allowUnsigned: allowUnsigned,
+		maxAge:        maxAge,
+		rejected:      rejected,
+	}
+}
@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+)
+
+func newTestVerifier(allowUnsigned bool) *signatureVerifier {
+	rejected := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "rejected"}, []string{"payload", "reason"})
+	return newSignatureVerifier(allowUnsigned, time.Minute, rejected)
+}
+
+func sign(t *testing.T, key ed25519.PrivateKey, timestamp int64, body []byte) v1.PayloadSignature {
+	t.Helper()
+
+	return v1.PayloadSignature{
+		Signature: hex.EncodeToString(ed25519.Sign(key, signedMessage(timestamp, body))),
+		Timestamp: timestamp,
+	}
+}
+
+func Test_SignatureVerify(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pubkey := hex.EncodeToString(pub)
+	body := []byte(`{"storage":{}}`)
+	now := time.Now().Unix()
+
+	badSignature := sign(t, key, now, body)
+	badSignature.Signature = hex.EncodeToString(ed25519.Sign(key, []byte("other")))
+
+	tests := []struct {
+		name          string
+		allowUnsigned bool
+		pubkey        string
+		sign          v1.PayloadSignature
+		signed        bool
+		reason        string
+	}{
+		{name: "valid signature", pubkey: pubkey, sign: sign(t, key, now, body), signed: true},
+		{name: "bad signature", pubkey: pubkey, sign: badSignature, reason: rejectInvalidSignature},
+		{name: "malformed signature", pubkey: pubkey, sign: v1.PayloadSignature{Signature: "zz", Timestamp: now}, reason: rejectInvalidSignature},
+		{name: "invalid pubkey", pubkey: "abc", sign: sign(t, key, now, body), reason: rejectInvalidPubKey},
+		{name: "too old timestamp", pubkey: pubkey, sign: sign(t, key, now-120, body), reason: rejectExpired},
+		{name: "future timestamp", pubkey: pubkey, sign: sign(t, key, now+120, body), reason: rejectExpired},
+		{name: "unsigned is allowed", allowUnsigned: true, pubkey: pubkey},
+		{name: "unsigned is rejected", pubkey: pubkey, reason: rejectUnsigned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(tt.allowUnsigned)
+
+			signed, err := v.verify(telemetryPayload, tt.pubkey, body, tt.sign)
+			if signed != tt.signed {
+				t.Errorf("signed = %t, want %t", signed, tt.signed)
+			}
+
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var appErr *models.AppError
+			if !errors.As(err, &appErr) || appErr.Code != models.UnauthorizedErrorCode {
+				t.Fatalf("error = %v, want unauthorized", err)
+			}
+
+			if n := testutil.ToFloat64(v.rejected.WithLabelValues(telemetryPayload, tt.reason)); n != 1 {
+				t.Fatalf("%s rejections = %f, want 1", tt.reason, n)
+			}
+		})
+	}
+}
+
+func Test_SignatureVerifyReplay(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pubkey := hex.EncodeToString(pub)
+	body := []byte(`{"storage":{}}`)
+	s := sign(t, key, time.Now().Unix(), body)
+
+	v := newTestVerifier(false)
+
+	// the same signed body is accepted by one of concurrent requests only
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if signed, err := v.verify(telemetryPayload, pubkey, body, s); err == nil && signed {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted.Load() != 1 {
+		t.Fatalf("accepted %d times, want 1", accepted.Load())
+	}
+
+	if n := testutil.ToFloat64(v.rejected.WithLabelValues(telemetryPayload, rejectReplay)); n != 15 {
+		t.Fatalf("replay rejections = %f, want 15", n)
+	}
+
+	// benchmarks are signed separately, the same signature of telemetry is not a replay
+	if _, err := v.verify(benchmarksPayload, pubkey, body, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
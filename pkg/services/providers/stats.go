@@ -0,0 +1,41 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+)
+
+func (s *service) GetStats(ctx context.Context) (resp v1.StatsResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetStats"))
+
+	stats, dbErr := s.providers.GetNetworkStats(ctx)
+	if dbErr != nil {
+		log.Error("failed to get network stats", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.StatsResponse{
+		ProvidersRegistered:  stats.ProvidersRegistered,
+		ProvidersInitialized: stats.ProvidersInitialized,
+		ProvidersOnline:      stats.ProvidersOnline,
+		TotalProviderSpace:   stats.TotalProviderSpace,
+		UsedProviderSpace:    stats.UsedProviderSpace,
+		Contracts:            stats.Contracts,
+		ContractsSize:        stats.ContractsSize,
+		Bags:                 stats.Bags,
+		Owners:               stats.Owners,
+		MedianPrice:          stats.MedianPrice,
+		Countries:            stats.Countries,
+	}
+
+	if stats.ProofsChecked24h > 0 {
+		ratio := float64(stats.ProofsValid24h) / float64(stats.ProofsChecked24h)
+		resp.ProofsRatio24h = &ratio
+	}
+
+	return
+}
@@ -0,0 +1,200 @@
+package system
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const defaultStatsHistoryRange = 365 * 24 * time.Hour
+
+type service struct {
+	jobs       jobs
+	snapshots  snapshots
+	scheduler  scheduler
+	instanceID string
+	logger     *slog.Logger
+}
+
+type jobs interface {
+	GetJobsStatuses(ctx context.Context, names []string) ([]db.JobStatus, error)
+}
+
+type snapshots interface {
+	GetNetworkDailySnapshots(ctx context.Context, from, to time.Time) ([]db.NetworkDailySnapshot, error)
+}
+
+type scheduler interface {
+	Jobs() (names []string)
+	RunJob(ctx context.Context, name string) (found bool, err error)
+	Leader(ctx context.Context) (instanceID string, err error)
+}
+
+type System interface {
+	GetJobs(ctx context.Context) (resp v1.JobsResponse, err error)
+	RunJob(ctx context.Context, name string) (err error)
+	GetStatsHistory(ctx context.Context, req v1.StatsHistoryRequest) (resp v1.StatsHistoryResponse, err error)
+}
+
+func (s *service) GetJobs(ctx context.Context) (resp v1.JobsResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetJobs"))
+
+	names := s.scheduler.Jobs()
+
+	statuses, dbErr := s.jobs.GetJobsStatuses(ctx, names)
+	if dbErr != nil {
+		log.Error("failed to get jobs statuses", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	leader, dbErr := s.scheduler.Leader(ctx)
+	if dbErr != nil {
+		log.Error("failed to get leader", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp.Leader = leader
+	resp.Instance = s.instanceID
+
+	statusesMap := make(map[string]db.JobStatus, len(statuses))
+	for _, st := range statuses {
+		statusesMap[st.Name] = st
+	}
+
+	resp.Jobs = make([]v1.JobStatus, 0, len(names))
+	for _, name := range names {
+		job := v1.JobStatus{
+			Name: name,
+		}
+
+		if st, ok := statusesMap[name]; ok {
+			// runs interrupted by crash are closed on instance restart or by the next leader
+			job.Running = st.FinishedAt == nil
+			job.Manual = st.Manual
+			if st.InstanceID != nil {
+				job.Instance = *st.InstanceID
+			}
+			job.LastStartedAt = st.StartedAt.Unix()
+			if st.FinishedAt != nil {
+				job.LastFinishedAt = st.FinishedAt.Unix()
+				job.LastDurationMs = st.FinishedAt.Sub(st.StartedAt).Milliseconds()
+			}
+			if st.Error != nil {
+				job.LastError = *st.Error
+			}
+			if st.LastSuccessAt != nil {
+				job.LastSuccessAt = st.LastSuccessAt.Unix()
+			}
+			if st.NextRunAt != nil {
+				job.NextRunAt = st.NextRunAt.Unix()
+			}
+		}
+
+		resp.Jobs = append(resp.Jobs, job)
+	}
+
+	return
+}
+
+func (s *service) RunJob(ctx context.Context, name string) (err error) {
+	log := s.logger.With(slog.String("method", "RunJob"), slog.String("job", name))
+
+	found, dbErr := s.scheduler.RunJob(ctx, name)
+	if dbErr != nil {
+		log.Error("failed to trigger job", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if !found {
+		err = models.NewAppError(models.NotFoundErrorCode, "job not found")
+		return
+	}
+
+	log.Info("job triggered manually")
+
+	return
+}
+
+func (s *service) GetStatsHistory(ctx context.Context, req v1.StatsHistoryRequest) (resp v1.StatsHistoryResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetStatsHistory"))
+
+	to := time.Now()
+	if req.To > 0 {
+		to = time.Unix(req.To, 0)
+	}
+
+	from := to.Add(-defaultStatsHistoryRange)
+	if req.From > 0 {
+		from = time.Unix(req.From, 0)
+	}
+
+	if !from.Before(to) {
+		err = models.NewAppError(models.BadRequestErrorCode, "from must be before to")
+		return
+	}
+
+	snapshots, dbErr := s.snapshots.GetNetworkDailySnapshots(ctx, from.UTC(), to.UTC())
+	if dbErr != nil {
+		log.Error("failed to get network daily snapshots", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = v1.StatsHistoryResponse{
+		From:   from.Unix(),
+		To:     to.Unix(),
+		Points: make([]v1.StatsHistoryPoint, 0, len(snapshots)),
+	}
+
+	for _, sn := range snapshots {
+		p := v1.StatsHistoryPoint{
+			Day:                  sn.Day.Unix(),
+			ProvidersRegistered:  sn.ProvidersRegistered,
+			ProvidersInitialized: sn.ProvidersInitialized,
+			ProvidersOnline:      sn.ProvidersOnline,
+			TotalProviderSpace:   sn.TotalProviderSpace,
+			UsedProviderSpace:    sn.UsedProviderSpace,
+			Contracts:            sn.Contracts,
+			ContractsSize:        sn.ContractsSize,
+			Bags:                 sn.Bags,
+			Owners:               sn.Owners,
+			MedianPrice:          sn.MedianPrice,
+			MedianRating:         sn.MedianRating,
+			Countries:            sn.Countries,
+			StorageVersions:      sn.StorageVersions,
+			ProviderVersions:     sn.ProviderVersions,
+		}
+
+		if sn.ProofsChecked24h > 0 {
+			ratio := float64(sn.ProofsValid24h) / float64(sn.ProofsChecked24h)
+			p.ProofsRatio24h = &ratio
+		}
+
+		resp.Points = append(resp.Points, p)
+	}
+
+	return
+}
+
+func NewService(
+	jobs jobs,
+	snapshots snapshots,
+	scheduler scheduler,
+	instanceID string,
+	logger *slog.Logger,
+) System {
+	return &service{
+		jobs:       jobs,
+		snapshots:  snapshots,
+		scheduler:  scheduler,
+		instanceID: instanceID,
+		logger:     logger,
+	}
+}
@@ -0,0 +1,158 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/utils"
+)
+
+const (
+	maxSubscriptionTargets = 1000
+	maxURLLength           = 2048
+	secretLength           = 32
+)
+
+type service struct {
+	webhooks webhooks
+	logger   *slog.Logger
+}
+
+type webhooks interface {
+	AddSubscription(ctx context.Context, subscription db.WebhookSubscription) (db.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]db.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) (bool, error)
+}
+
+type Webhooks interface {
+	AddSubscription(ctx context.Context, req v1.WebhookSubscriptionRequest) (resp v1.WebhookSubscription, err error)
+	GetSubscriptions(ctx context.Context) (resp v1.WebhookSubscriptionsResponse, err error)
+	DeleteSubscription(ctx context.Context, id int64) (err error)
+}
+
+func (s *service) AddSubscription(ctx context.Context, req v1.WebhookSubscriptionRequest) (resp v1.WebhookSubscription, err error) {
+	log := s.logger.With(slog.String("method", "AddSubscription"))
+
+	u, pErr := url.Parse(req.URL)
+	if len(req.URL) > maxURLLength || pErr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		err = models.NewAppError(models.BadRequestErrorCode, "invalid url")
+		return
+	}
+
+	if len(req.Providers)+len(req.Contracts) == 0 {
+		err = models.NewAppError(models.BadRequestErrorCode, "providers or contracts are required")
+		return
+	}
+
+	if len(req.Providers)+len(req.Contracts) > maxSubscriptionTargets {
+		err = models.NewAppError(models.BadRequestErrorCode, "too many providers and contracts")
+		return
+	}
+
+	subscription := db.WebhookSubscription{
+		URL:       req.URL,
+		Providers: make([]string, 0, len(req.Providers)),
+		Contracts: make([]string, 0, len(req.Contracts)),
+	}
+
+	for _, pubkey := range req.Providers {
+		if !utils.ValidatePubKey(pubkey) {
+			err = models.NewAppError(models.BadRequestErrorCode, "invalid provider pubkey: "+pubkey)
+			return
+		}
+		subscription.Providers = append(subscription.Providers, strings.ToLower(pubkey))
+	}
+
+	for _, addr := range req.Contracts {
+		a, ok := utils.NormalizeAddress(addr)
+		if !ok {
+			err = models.NewAppError(models.BadRequestErrorCode, "invalid contract address: "+addr)
+			return
+		}
+		subscription.Contracts = append(subscription.Contracts, a)
+	}
+
+	secret := make([]byte, secretLength)
+	if _, rErr := rand.Read(secret); rErr != nil {
+		log.Error("failed to generate secret", slog.String("error", rErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+	subscription.Secret = hex.EncodeToString(secret)
+
+	subscription, dbErr := s.webhooks.AddSubscription(ctx, subscription)
+	if dbErr != nil {
+		log.Error("failed to add subscription", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp = convertSubscription(subscription)
+	resp.Secret = subscription.Secret
+
+	return
+}
+
+func (s *service) GetSubscriptions(ctx context.Context) (resp v1.WebhookSubscriptionsResponse, err error) {
+	log := s.logger.With(slog.String("method", "GetSubscriptions"))
+
+	subscriptions, dbErr := s.webhooks.GetSubscriptions(ctx)
+	if dbErr != nil {
+		log.Error("failed to get subscriptions", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	resp.Subscriptions = make([]v1.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, convertSubscription(subscription))
+	}
+
+	return
+}
+
+func (s *service) DeleteSubscription(ctx context.Context, id int64) (err error) {
+	log := s.logger.With(slog.String("method", "DeleteSubscription"), slog.Int64("id", id))
+
+	deleted, dbErr := s.webhooks.DeleteSubscription(ctx, id)
+	if dbErr != nil {
+		log.Error("failed to delete subscription", slog.String("error", dbErr.Error()))
+		err = models.NewAppError(models.InternalServerErrorCode, "")
+		return
+	}
+
+	if !deleted {
+		err = models.NewAppError(models.NotFoundErrorCode, "subscription not found")
+		return
+	}
+
+	return
+}
+
+// convertSubscription hides the secret, it is shown only once on creation
+func convertSubscription(s db.WebhookSubscription) v1.WebhookSubscription {
+	return v1.WebhookSubscription{
+		ID:        s.ID,
+		URL:       s.URL,
+		Providers: s.Providers,
+		Contracts: s.Contracts,
+		CreatedAt: s.CreatedAt.Unix(),
+	}
+}
+
+func NewService(
+	webhooks webhooks,
+	logger *slog.Logger,
+) Webhooks {
+	return &service{
+		webhooks: webhooks,
+		logger:   logger,
+	}
+}
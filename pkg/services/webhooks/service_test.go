@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"mytonprovider-backend/pkg/models"
+	v1 "mytonprovider-backend/pkg/models/api/v1"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+type fakeWebhooks struct {
+	subscriptions []db.WebhookSubscription
+}
+
+func (f *fakeWebhooks) AddSubscription(_ context.Context, s db.WebhookSubscription) (db.WebhookSubscription, error) {
+	s.ID = int64(len(f.subscriptions) + 1)
+	s.CreatedAt = time.Now()
+	f.subscriptions = append(f.subscriptions, s)
+	return s, nil
+}
+
+func (f *fakeWebhooks) GetSubscriptions(context.Context) ([]db.WebhookSubscription, error) {
+	return f.subscriptions, nil
+}
+
+func (f *fakeWebhooks) DeleteSubscription(context.Context, int64) (bool, error) {
+	return false, nil
+}
+
+// Events are matched to subscriptions by lower case provider pubkey and contract address in
+// the stored form, so subscription targets must be saved normalized
+func Test_AddSubscriptionTargets(t *testing.T) {
+	const (
+		pubkey        = "8F2A1C7E0B5D4A3F9E6C2B1A0D8E7F6C5B4A3928170F6E5D4C3B2A1908F7E6D5"
+		nonBounceable = "UQABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAMvf"
+		contract      = "EQABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAJYa"
+	)
+
+	tests := []struct {
+		name          string
+		req           v1.WebhookSubscriptionRequest
+		ok            bool
+		wantProviders []string
+		wantContracts []string
+	}{
+		{
+			name:          "targets are normalized",
+			req:           v1.WebhookSubscriptionRequest{URL: "https://example.com/hook", Providers: []string{pubkey}, Contracts: []string{nonBounceable}},
+			ok:            true,
+			wantProviders: []string{"8f2a1c7e0b5d4a3f9e6c2b1a0d8e7f6c5b4a3928170f6e5d4c3b2a1908f7e6d5"},
+			wantContracts: []string{contract},
+		},
+		{name: "no targets", req: v1.WebhookSubscriptionRequest{URL: "https://example.com/hook"}},
+		{name: "invalid pubkey", req: v1.WebhookSubscriptionRequest{URL: "https://example.com/hook", Providers: []string{"abc"}}},
+		{name: "invalid contract", req: v1.WebhookSubscriptionRequest{URL: "https://example.com/hook", Contracts: []string{"abc"}}},
+		{name: "invalid url", req: v1.WebhookSubscriptionRequest{URL: "ftp://example.com", Providers: []string{pubkey}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWebhooks{}
+			s := NewService(repo, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			resp, err := s.AddSubscription(context.Background(), tt.req)
+			if !tt.ok {
+				var appErr *models.AppError
+				if !errors.As(err, &appErr) || appErr.Code != models.BadRequestErrorCode || len(repo.subscriptions) != 0 {
+					t.Fatalf("error = %v, want bad request", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			saved := repo.subscriptions[0]
+			if len(saved.Providers) != 1 || saved.Providers[0] != tt.wantProviders[0] {
+				t.Fatalf("providers = %v, want %v", saved.Providers, tt.wantProviders)
+			}
+
+			if len(saved.Contracts) != 1 || saved.Contracts[0] != tt.wantContracts[0] {
+				t.Fatalf("contracts = %v, want %v", saved.Contracts, tt.wantContracts)
+			}
+
+			if resp.Secret == "" || resp.Secret != saved.Secret {
+				t.Fatal("secret is not returned on creation")
+			}
+
+			list, err := s.GetSubscriptions(context.Background())
+			if err != nil || len(list.Subscriptions) != 1 || list.Subscriptions[0].Secret != "" {
+				t.Fatalf("secret must be hidden in subscriptions list: %+v, %v", list, err)
+			}
+		})
+	}
+}
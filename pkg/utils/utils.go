@@ -3,6 +3,8 @@ package utils
 import (
 	"strings"
 	"time"
+
+	"github.com/xssnick/tonutils-go/address"
 )
 
 func TryNTimes(f func() error, n int) (err error) {
@@ -32,3 +34,18 @@ func ValidateBagID(bagid string) bool {
 
 	return true
 }
+
+// ValidatePubKey checks provider public key, it has the same hex format as bag id.
+func ValidatePubKey(pubkey string) bool {
+	return ValidateBagID(pubkey)
+}
+
+// NormalizeAddress returns contract address in the same user-friendly form as it is stored in db.
+func NormalizeAddress(addr string) (string, bool) {
+	a, err := address.ParseAddr(addr)
+	if err != nil {
+		return "", false
+	}
+
+	return a.Bounce(true).Testnet(false).String(), true
+}
@@ -11,12 +11,26 @@ type repository interface {
 	CleanOldStatusesHistory(ctx context.Context, days int) (removed int, err error)
 	CleanOldBenchmarksHistory(ctx context.Context, days int) (removed int, err error)
 	CleanOldTelemetryHistory(ctx context.Context, days int) (removed int, err error)
+	CleanOldStorageProofsHistory(ctx context.Context, days int) (removed int, err error)
+}
+
+// job runs are needed only to see recent problems, no reason to store them long
+const jobRunsStoreDays = 7
+
+type webhooks interface {
+	CleanOldDeliveries(ctx context.Context, days int) (removed int, err error)
+}
+
+type jobs interface {
+	CleanOldJobRuns(ctx context.Context, days int) (removed int, err error)
 }
 
 type cleanerWorker struct {
-	repo   repository
-	days   int
-	logger *slog.Logger
+	repo     repository
+	webhooks webhooks
+	jobs     jobs
+	days     int
+	logger   *slog.Logger
 }
 
 type Worker interface {
@@ -62,13 +76,36 @@ func (w *cleanerWorker) CleanupOldData(ctx context.Context) (interval time.Durat
 		log.Info("cleaned old telemetry history", slog.Int("removed", removed))
 	}
 
+	if removed, err := w.repo.CleanOldStorageProofsHistory(ctx, w.days); err != nil {
+		log.Error("failed to clean old storage proofs history", slog.Int("days", w.days), slog.String("err", err.Error()))
+		interval = failureInterval
+	} else if removed > 0 {
+		log.Info("cleaned old storage proofs history", slog.Int("removed", removed))
+	}
+
+	if removed, err := w.webhooks.CleanOldDeliveries(ctx, w.days); err != nil {
+		log.Error("failed to clean old webhook deliveries", slog.Int("days", w.days), slog.String("err", err.Error()))
+		interval = failureInterval
+	} else if removed > 0 {
+		log.Info("cleaned old webhook deliveries", slog.Int("removed", removed))
+	}
+
+	if removed, err := w.jobs.CleanOldJobRuns(ctx, jobRunsStoreDays); err != nil {
+		log.Error("failed to clean old job runs", slog.Int("days", jobRunsStoreDays), slog.String("err", err.Error()))
+		interval = failureInterval
+	} else if removed > 0 {
+		log.Info("cleaned old job runs", slog.Int("removed", removed))
+	}
+
 	return
 }
 
-func NewWorker(repo repository, days int, logger *slog.Logger) Worker {
+func NewWorker(repo repository, webhooks webhooks, jobs jobs, days int, logger *slog.Logger) Worker {
 	return &cleanerWorker{
-		repo:   repo,
-		days:   days,
-		logger: logger,
+		repo:     repo,
+		webhooks: webhooks,
+		jobs:     jobs,
+		days:     days,
+		logger:   logger,
 	}
 }
@@ -0,0 +1,200 @@
+package providersmaster
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const (
+	// audit is returned to the queue if it was not finished in this time after max duration
+	auditStaleMargin = 10 * time.Minute
+	// provider is pinged after this number of missing pieces in a row, audit stops if it doesn't respond
+	auditMaxMissingInARow = 20
+	auditSaveTimeout      = 10 * time.Second
+)
+
+// Audit sets limits of full bag download audits
+type Audit struct {
+	BandwidthLimit uint64        `env:"AUDIT_BANDWIDTH_LIMIT" envDefault:"1048576"` // bytes per second, 0 - unlimited
+	MaxDuration    time.Duration `env:"AUDIT_MAX_DURATION" envDefault:"6h"`
+}
+
+// RunAudits downloads every piece of the bag for the oldest requested audit and saves the report
+func (w *providersMasterWorker) RunAudits(ctx context.Context) (interval time.Duration, err error) {
+	const (
+		successInterval = 1 * time.Minute
+		failureInterval = 15 * time.Second
+	)
+
+	log := w.logger.With(slog.String("worker", "RunAudits"))
+
+	interval = successInterval
+
+	audit, err := w.providers.TakeAudit(ctx, w.audit.MaxDuration+auditStaleMargin)
+	if err != nil {
+		log.Error("failed to take audit", "error", err)
+		interval = failureInterval
+		return
+	}
+
+	if audit == nil {
+		return
+	}
+
+	log = log.With(slog.Int64("audit_id", audit.ID), slog.String("provider_pubkey", audit.ProviderPublicKey), slog.String("bag_id", audit.BagID))
+	log.Info("starting audit")
+
+	auditCtx, cancel := context.WithTimeout(ctx, w.audit.MaxDuration)
+	result := w.runAudit(auditCtx, *audit, log)
+	cancel()
+
+	// leader changed or instance is stopping, audit will be run again
+	if ctx.Err() != nil {
+		result = db.AuditResult{
+			ID:     audit.ID,
+			Status: constants.AuditPending,
+		}
+	}
+
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), auditSaveTimeout)
+	err = w.providers.FinishAudit(saveCtx, result)
+	saveCancel()
+	if err != nil {
+		log.Error("failed to save audit result", "error", err)
+		interval = failureInterval
+		return
+	}
+
+	log.Info("audit finished",
+		"status", result.Status,
+		"checked", result.PiecesChecked,
+		"missing", len(result.MissingPieces),
+		"invalid", len(result.InvalidPieces),
+	)
+
+	// there may be more audits in the queue
+	interval = 0
+
+	return
+}
+
+func (w *providersMasterWorker) runAudit(ctx context.Context, audit db.Audit, log *slog.Logger) (result db.AuditResult) {
+	result = db.AuditResult{
+		ID:     audit.ID,
+		Status: constants.AuditFailed,
+	}
+
+	sc := db.ContractToProviderRelation{
+		ProviderPublicKey: audit.ProviderPublicKey,
+		ProviderAddress:   audit.ProviderAddress,
+		Address:           audit.ContractAddress,
+		BagID:             audit.BagID,
+	}
+
+	ips, err := w.updateProvidersIPs(ctx, []db.ContractToProviderRelation{sc})
+	if err != nil {
+		result.Error = "failed to find provider storage: " + err.Error()
+		return
+	}
+
+	ip, ok := ips[audit.ProviderPublicKey]
+	if !ok {
+		result.Error = "provider storage ip not found"
+		return
+	}
+
+	conn, reason, err := connectStorage(ctx, w.storage, ip.Storage, log)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if reason != constants.ValidStorageProof {
+		result.Error = fmt.Sprintf("provider storage is unavailable, reason %d", reason)
+		return
+	}
+	defer conn.Close()
+
+	info, over, received, reason := getTorrentInfo(ctx, conn, audit.BagID, log)
+	result.BytesReceived += received
+	if reason != constants.ValidStorageProof {
+		result.Error = fmt.Sprintf("failed to get bag info, reason %d", reason)
+		return
+	}
+
+	piecesTotal := int32(piecesCount(info))
+	result.PiecesTotal = &piecesTotal
+
+	start := time.Now()
+	est := time.Now()
+	var missingInARow int
+	for id := int32(0); id < piecesTotal; id++ {
+		if ctx.Err() != nil {
+			result.Error = "audit is not finished in time"
+			return
+		}
+
+		// peer can be closed after some time, so for extra stability we reinit it
+		if time.Since(est) > 5*time.Second {
+			conn.Reinit()
+			est = time.Now()
+		}
+
+		reason, received := checkPiece(ctx, conn, over, id, info.RootHash, log)
+		result.PiecesChecked++
+		result.BytesReceived += received
+
+		switch reason {
+		case constants.ValidStorageProof:
+			missingInARow = 0
+		case constants.CantGetPiece:
+			result.MissingPieces = append(result.MissingPieces, id)
+			missingInARow++
+		default:
+			result.InvalidPieces = append(result.InvalidPieces, id)
+		}
+
+		if missingInARow >= auditMaxMissingInARow {
+			missingInARow = 0
+
+			pingCtx, pingCancel := context.WithTimeout(ctx, pingTimeout)
+			pErr := conn.Ping(pingCtx)
+			pingCancel()
+			if pErr != nil {
+				result.Error = "provider stopped responding"
+				return
+			}
+		}
+
+		w.limitBandwidth(ctx, start, result.BytesReceived)
+	}
+
+	result.Status = constants.AuditDone
+
+	return
+}
+
+// limitBandwidth sleeps until average download speed since start drops to the limit
+func (w *providersMasterWorker) limitBandwidth(ctx context.Context, start time.Time, received int64) {
+	if w.audit.BandwidthLimit == 0 {
+		return
+	}
+
+	expected := time.Duration(float64(received) / float64(w.audit.BandwidthLimit) * float64(time.Second))
+	wait := expected - time.Since(start)
+	if wait <= 0 {
+		return
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
@@ -0,0 +1,103 @@
+package providersmaster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+func Test_RunAudits(t *testing.T) {
+	const pieces = 8
+
+	allPieces := pieceIDs(pieces)
+
+	tests := []struct {
+		name      string
+		mode      fakeBagMode
+		pieces    uint32
+		bandwidth uint64
+		cancelled bool
+		status    string
+		checked   int32
+		missing   []int32
+		invalid   []int32
+	}{
+		{name: "all pieces valid", mode: bagStored, pieces: pieces, status: constants.AuditDone, checked: pieces},
+		{name: "missing pieces", mode: bagNoPieces, pieces: pieces, status: constants.AuditDone, checked: pieces, missing: allPieces},
+		{name: "bad proof", mode: bagBadProof, pieces: pieces, status: constants.AuditDone, checked: pieces, invalid: allPieces},
+		{name: "bandwidth limited", mode: bagStored, pieces: pieces, bandwidth: 4096, status: constants.AuditDone, checked: pieces},
+		// leader changed, audit goes back to the queue
+		{name: "parent context cancelled", mode: bagStored, pieces: pieces, cancelled: true, status: constants.AuditPending},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bag := newFakeBag(t, uint64(i+1), tt.pieces)
+
+			network := newFakeNetwork()
+			provider := network.addProvider(t, "10.0.0.1", newFakeStorage(map[fakeBag]fakeBagMode{bag: tt.mode}))
+
+			repo := &fakeProviders{audits: []db.Audit{{
+				ID:                int64(i + 1),
+				ContractAddress:   newFakeContractAddress(1),
+				ProviderAddress:   "provider-" + provider.pubkey()[:8],
+				ProviderPublicKey: provider.pubkey(),
+				BagID:             bag.id,
+			}}}
+
+			w := newTestWorker(network, repo, &fakeTon{})
+			w.audit = Audit{BandwidthLimit: tt.bandwidth, MaxDuration: time.Minute}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			start := time.Now()
+			if _, err := w.RunAudits(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			elapsed := time.Since(start)
+
+			if len(repo.results) != 1 {
+				t.Fatalf("got %d audit results, want 1", len(repo.results))
+			}
+
+			result := repo.results[0]
+			if result.ID != int64(i+1) || result.Status != tt.status || result.PiecesChecked != tt.checked {
+				t.Fatalf("unexpected result: %+v", result)
+			}
+
+			if !reflect.DeepEqual(result.MissingPieces, tt.missing) {
+				t.Errorf("missing pieces = %v, want %v", result.MissingPieces, tt.missing)
+			}
+
+			if !reflect.DeepEqual(result.InvalidPieces, tt.invalid) {
+				t.Errorf("invalid pieces = %v, want %v", result.InvalidPieces, tt.invalid)
+			}
+
+			if tt.bandwidth > 0 {
+				limited := time.Duration(float64(result.BytesReceived) / float64(tt.bandwidth) * float64(time.Second))
+				// the last piece is checked after the previous wait, so it may be not waited for
+				if result.BytesReceived == 0 || elapsed < limited/2 {
+					t.Errorf("audit of %d bytes took %s, want about %s", result.BytesReceived, elapsed, limited)
+				}
+			}
+		})
+	}
+}
+
+// pieceIDs returns ids of the first n pieces
+func pieceIDs(n int) []int32 {
+	ids := make([]int32, n)
+	for i := range ids {
+		ids[i] = int32(i)
+	}
+
+	return ids
+}
@@ -0,0 +1,167 @@
+package providersmaster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+	"testing"
+
+	tonclient "mytonprovider-backend/pkg/clients/ton"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+// syntheticFixtures are hand-built accounts in the replay format for cases which are hard to pick
+// on chain: registrations before the last processed lt, contracts without storage info
+const syntheticFixtures = "../../clients/ton/testdata/synthetic"
+
+// addresses of accounts in synthetic fixtures
+const (
+	testMasterAddr    = "UQB3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d3d0x0"
+	testProvider1Addr = "EQARAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAFLf"
+	testProvider2Addr = "EQASAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAALkV"
+	testContract1Addr = "EQAhAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA-x"
+	testContract2Addr = "EQAiAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAOR7"
+	testOwnerAddr     = "EQAxAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAMt0"
+)
+
+func testPubkey(name string) string {
+	h := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(h[:])
+}
+
+// fixtureBagID is bag id of storage contract in chain fixtures
+func fixtureBagID(n uint64) string {
+	h := sha256.Sum256(binary.BigEndian.AppendUint64(nil, n))
+	return hex.EncodeToString(h[:])
+}
+
+func newReplayClient(t *testing.T, dir string) tonclient.Client {
+	t.Helper()
+
+	ton, err := tonclient.NewReplayClient(dir, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+
+	return ton
+}
+
+func newChainWorker(ton tonclient.Client, repo *fakeProviders, system *fakeSystem) *providersMasterWorker {
+	return &providersMasterWorker{
+		providers:  repo,
+		system:     system,
+		ton:        ton,
+		masterAddr: testMasterAddr,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func Test_CollectNewProviders(t *testing.T) {
+	tests := []struct {
+		name   string
+		lastLT string
+		known  []string
+		want   map[string]string
+	}{
+		{
+			name: "all registrations",
+			want: map[string]string{
+				testPubkey("provider-1"): testProvider1Addr,
+				testPubkey("provider-2"): testProvider2Addr,
+				testPubkey("provider-3"): "EQATAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAOBT",
+			},
+		},
+		{
+			name:   "after last processed lt",
+			lastLT: "1500",
+			known:  []string{testPubkey("provider-1")},
+			want: map[string]string{
+				testPubkey("provider-2"): testProvider2Addr,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProviders{pubkeys: tt.known}
+			system := &fakeSystem{params: map[string]string{lastLTKey: tt.lastLT}}
+			w := newChainWorker(newReplayClient(t, syntheticFixtures), repo, system)
+
+			if _, err := w.CollectNewProviders(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make(map[string]string, len(repo.created))
+			for _, p := range repo.created {
+				got[p.Pubkey] = p.Address
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("created providers = %v, want %v", got, tt.want)
+			}
+
+			if system.params[lastLTKey] != "6000" {
+				t.Fatalf("last processed lt = %s, want 6000", system.params[lastLTKey])
+			}
+		})
+	}
+}
+
+func Test_CollectProvidersNewStorageContracts(t *testing.T) {
+	repo := &fakeProviders{wallets: []db.ProviderWallet{
+		{PubKey: testPubkey("provider-1"), Address: testProvider1Addr},
+		{PubKey: testPubkey("provider-2"), Address: testProvider2Addr, LT: 2100},
+	}}
+	w := newChainWorker(newReplayClient(t, syntheticFixtures), repo, nil)
+
+	if _, err := w.CollectProvidersNewStorageContracts(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Slice(repo.walletsLT, func(i, j int) bool { return repo.walletsLT[i].LT < repo.walletsLT[j].LT })
+	wantLT := []db.ProviderWalletLT{
+		{PubKey: testPubkey("provider-1"), LT: 1400},
+		{PubKey: testPubkey("provider-2"), LT: 2200},
+	}
+	if !reflect.DeepEqual(repo.walletsLT, wantLT) {
+		t.Fatalf("providers lt = %+v, want %+v", repo.walletsLT, wantLT)
+	}
+
+	// third contract has no storage info in fixtures, as if it was not found
+	sort.Slice(repo.newContracts, func(i, j int) bool { return repo.newContracts[i].Address < repo.newContracts[j].Address })
+	want := []db.StorageContract{
+		{
+			ProvidersAddresses: map[string]struct{}{testProvider1Addr: {}},
+			Address:            testContract1Addr,
+			BagID:              fixtureBagID(10),
+			OwnerAddr:          testOwnerAddr,
+			Size:               1 << 20,
+			ChunkSize:          128 << 10,
+			LastLT:             1400,
+		},
+		{
+			ProvidersAddresses: map[string]struct{}{testProvider1Addr: {}, testProvider2Addr: {}},
+			Address:            testContract2Addr,
+			BagID:              fixtureBagID(11),
+			OwnerAddr:          testOwnerAddr,
+			Size:               2 << 20,
+			ChunkSize:          128 << 10,
+			LastLT:             2100,
+		},
+	}
+
+	if len(repo.newContracts) != len(want) {
+		t.Fatalf("got %d contracts, want %d: %+v", len(repo.newContracts), len(want), repo.newContracts)
+	}
+
+	for i := range want {
+		if !reflect.DeepEqual(repo.newContracts[i], want[i]) {
+			t.Errorf("contract = %+v, want %+v", repo.newContracts[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package providersmaster
+
+import (
+	"sort"
+	"sync"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+// contractKey identifies the contract of the provider, the same bag may be stored by several providers
+type contractKey struct {
+	contract string
+	provider string
+}
+
+// checksCollector gathers storage proofs checks from concurrent providers checks,
+// the last result of the contract and provider pair wins
+type checksCollector struct {
+	mu     sync.Mutex
+	checks map[contractKey]db.ContractProofsCheck
+}
+
+func newChecksCollector(size int) *checksCollector {
+	return &checksCollector{
+		checks: make(map[contractKey]db.ContractProofsCheck, size),
+	}
+}
+
+func (c *checksCollector) add(check db.ContractProofsCheck) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks[contractKey{contract: check.ContractAddress, provider: check.ProviderAddress}] = check
+}
+
+// fill sets the same reason to all contracts
+func (c *checksCollector) fill(contracts []db.ContractToProviderRelation, reason constants.ReasonCode) {
+	for _, sc := range contracts {
+		c.add(db.ContractProofsCheck{
+			ContractAddress: sc.Address,
+			ProviderAddress: sc.ProviderAddress,
+			Reason:          reason,
+		})
+	}
+}
+
+// results returns checks sorted by contract and provider
+func (c *checksCollector) results() []db.ContractProofsCheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	results := make([]db.ContractProofsCheck, 0, len(c.checks))
+	for _, check := range c.checks {
+		results = append(results, check)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ContractAddress != results[j].ContractAddress {
+			return results[i].ContractAddress < results[j].ContractAddress
+		}
+
+		return results[i].ProviderAddress < results[j].ProviderAddress
+	})
+
+	return results
+}
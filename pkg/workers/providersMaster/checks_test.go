@@ -0,0 +1,206 @@
+package providersmaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+func contract(provider string, n int, bag fakeBag) db.ContractToProviderRelation {
+	return db.ContractToProviderRelation{
+		ProviderPublicKey: provider,
+		ProviderAddress:   "addr-" + provider,
+		Address:           fmt.Sprintf("contract-%d", n),
+		BagID:             bag.id,
+	}
+}
+
+func providerIP(provider, ip string) db.ProviderIP {
+	return db.ProviderIP{
+		PublicKey: provider,
+		Storage:   db.IPInfo{IP: ip, Port: 1},
+	}
+}
+
+func Test_CheckContracts(t *testing.T) {
+	pause := bagChecksPause
+	bagChecksPause = 0
+	t.Cleanup(func() { bagChecksPause = pause })
+
+	stored := newFakeBag(t, 1, 4)
+	noInfo := newFakeBag(t, 2, 4)
+	noPieces := newFakeBag(t, 3, 4)
+	badProof := newFakeBag(t, 4, 4)
+
+	many := make([]fakeBag, 10)
+	manyModes := make(map[fakeBag]fakeBagMode, len(many))
+	for i := range many {
+		many[i] = newFakeBag(t, uint64(100+i), 1)
+		manyModes[many[i]] = bagNoInfo
+	}
+
+	type want map[contractKey]constants.ReasonCode
+
+	key := func(sc db.ContractToProviderRelation) contractKey {
+		return contractKey{contract: sc.Address, provider: sc.ProviderAddress}
+	}
+
+	tests := []struct {
+		name      string
+		contracts []db.ContractToProviderRelation
+		ips       map[string]db.ProviderIP
+		storages  map[string]*fakeStorage
+		want      func(contracts []db.ContractToProviderRelation) want
+	}{
+		{
+			name:      "ip not found",
+			contracts: []db.ContractToProviderRelation{contract("a", 1, stored), contract("a", 2, noInfo)},
+			ips:       map[string]db.ProviderIP{},
+			want: func(c []db.ContractToProviderRelation) want {
+				return want{key(c[0]): constants.IPNotFound, key(c[1]): constants.IPNotFound}
+			},
+		},
+		{
+			name:      "can't create peer",
+			contracts: []db.ContractToProviderRelation{contract("a", 1, stored)},
+			ips:       map[string]db.ProviderIP{"a": providerIP("a", "10.0.0.1")},
+			storages:  map[string]*fakeStorage{"10.0.0.1": {connectErr: errors.New("bad key")}},
+			want: func(c []db.ContractToProviderRelation) want {
+				return want{key(c[0]): constants.CantCreatePeer}
+			},
+		},
+		{
+			name:      "initial ping failure",
+			contracts: []db.ContractToProviderRelation{contract("a", 1, stored), contract("a", 2, stored)},
+			ips:       map[string]db.ProviderIP{"a": providerIP("a", "10.0.0.1")},
+			storages:  map[string]*fakeStorage{"10.0.0.1": {pingErr: errors.New("timeout")}},
+			want: func(c []db.ContractToProviderRelation) want {
+				return want{key(c[0]): constants.FailedInitialPing, key(c[1]): constants.FailedInitialPing}
+			},
+		},
+		{
+			name: "early abort after threshold",
+			contracts: func() (c []db.ContractToProviderRelation) {
+				for i, bag := range many {
+					c = append(c, contract("a", i, bag))
+				}
+				return
+			}(),
+			ips:      map[string]db.ProviderIP{"a": providerIP("a", "10.0.0.1")},
+			storages: map[string]*fakeStorage{"10.0.0.1": newFakeStorage(manyModes)},
+			want: func(c []db.ContractToProviderRelation) want {
+				// threshold is 20% of 10 contracts, provider is skipped when fails in a row exceed it
+				w := want{}
+				for i, sc := range c {
+					w[key(sc)] = constants.UnavailableProvider
+					if i < 3 {
+						w[key(sc)] = constants.GetInfoFailed
+					}
+				}
+				return w
+			},
+		},
+		{
+			name: "mixed outcomes",
+			contracts: []db.ContractToProviderRelation{
+				contract("a", 1, stored),
+				contract("a", 2, noInfo),
+				contract("a", 3, stored),
+				contract("a", 4, noPieces),
+				contract("a", 5, stored),
+				contract("a", 6, badProof),
+			},
+			ips: map[string]db.ProviderIP{"a": providerIP("a", "10.0.0.1")},
+			storages: map[string]*fakeStorage{"10.0.0.1": newFakeStorage(map[fakeBag]fakeBagMode{
+				stored:   bagStored,
+				noInfo:   bagNoInfo,
+				noPieces: bagNoPieces,
+				badProof: bagBadProof,
+			})},
+			want: func(c []db.ContractToProviderRelation) want {
+				return want{
+					key(c[0]): constants.ValidStorageProof,
+					key(c[1]): constants.GetInfoFailed,
+					key(c[2]): constants.ValidStorageProof,
+					key(c[3]): constants.CantGetPiece,
+					key(c[4]): constants.ValidStorageProof,
+					key(c[5]): constants.ProofCheckFailed,
+				}
+			},
+		},
+		{
+			name: "same bag on providers with the same storage ip",
+			contracts: []db.ContractToProviderRelation{
+				contract("a", 1, stored),
+				contract("b", 1, stored),
+				contract("c", 1, stored),
+			},
+			ips: map[string]db.ProviderIP{
+				"a": providerIP("a", "10.0.0.1"),
+				"b": providerIP("b", "10.0.0.1"),
+			},
+			storages: map[string]*fakeStorage{"10.0.0.1": newFakeStorage(map[fakeBag]fakeBagMode{stored: bagStored})},
+			want: func(c []db.ContractToProviderRelation) want {
+				return want{
+					key(c[0]): constants.ValidStorageProof,
+					key(c[1]): constants.ValidStorageProof,
+					key(c[2]): constants.IPNotFound,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &providersMasterWorker{
+				storage:  &fakeConnector{storages: tt.storages},
+				sampling: Sampling{MinPieces: 2, MaxPieces: 4},
+				logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			got := w.checkContracts(context.Background(), tt.contracts, tt.ips, w.logger)
+			want := tt.want(tt.contracts)
+
+			if len(got) != len(want) {
+				t.Fatalf("got %d checks, want %d", len(got), len(want))
+			}
+
+			for _, check := range got {
+				k := contractKey{contract: check.ContractAddress, provider: check.ProviderAddress}
+				reason, ok := want[k]
+				if !ok {
+					t.Errorf("unexpected check %+v", k)
+					continue
+				}
+
+				if check.Reason != reason {
+					t.Errorf("%+v: reason = %d, want %d", k, check.Reason, reason)
+				}
+			}
+		})
+	}
+}
+
+func Test_ChecksCollectorKeys(t *testing.T) {
+	c := newChecksCollector(0)
+	c.add(db.ContractProofsCheck{ContractAddress: "c1", ProviderAddress: "p1", Reason: constants.PingFailed})
+	c.add(db.ContractProofsCheck{ContractAddress: "c1", ProviderAddress: "p2", Reason: constants.ValidStorageProof})
+	c.add(db.ContractProofsCheck{ContractAddress: "c1", ProviderAddress: "p1", Reason: constants.ValidStorageProof})
+
+	results := c.results()
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	for _, r := range results {
+		if r.Reason != constants.ValidStorageProof {
+			t.Errorf("%s/%s: reason = %d, want the last one", r.ContractAddress, r.ProviderAddress, r.Reason)
+		}
+	}
+}
@@ -0,0 +1,568 @@
+package providersmaster
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	adnlAddress "github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-go/adnl/keys"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-storage-provider/pkg/transport"
+	"github.com/xssnick/tonutils-storage/storage"
+
+	tonclient "mytonprovider-backend/pkg/clients/ton"
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const (
+	fakePieceSize   = 128
+	fakeStoragePort = 2
+)
+
+// fakeBagMode is how fake storage serves the bag
+type fakeBagMode int
+
+const (
+	bagStored fakeBagMode = iota
+	bagNoInfo
+	bagNoPieces
+	bagBadProof
+)
+
+type fakeBag struct {
+	id    string
+	over  string
+	info  string
+	proof string
+}
+
+// newFakeBag creates bag with torrent info cell and piece proof valid for its root hash
+func newFakeBag(t *testing.T, n uint64, pieces uint32) fakeBag {
+	t.Helper()
+
+	root := cell.BeginCell().MustStoreUInt(n, 64).EndCell()
+	info, err := tlb.ToCell(&storage.TorrentInfo{
+		PieceSize:   fakePieceSize,
+		FileSize:    uint64(pieces) * fakePieceSize,
+		RootHash:    root.Hash(),
+		HeaderHash:  make([]byte, 32),
+		Description: tlb.Text{MaxFirstChunkSize: 100},
+	})
+	if err != nil {
+		t.Fatalf("failed to serialize torrent info: %v", err)
+	}
+
+	proof, err := root.CreateProof(cell.CreateProofSkeleton())
+	if err != nil {
+		t.Fatalf("failed to create proof: %v", err)
+	}
+
+	over, err := tl.Hash(keys.PublicKeyOverlay{Key: info.Hash()})
+	if err != nil {
+		t.Fatalf("failed to hash overlay key: %v", err)
+	}
+
+	return fakeBag{
+		id:    hex.EncodeToString(info.Hash()),
+		over:  hex.EncodeToString(over),
+		info:  string(info.ToBOC()),
+		proof: string(proof.ToBOC()),
+	}
+}
+
+// fakeStorage is provider storage serving bags in the given modes
+type fakeStorage struct {
+	connectErr error
+	pingErr    error
+	bags       map[string]fakeBagMode
+	byOverlay  map[string]fakeBag
+}
+
+func newFakeStorage(bags map[fakeBag]fakeBagMode) *fakeStorage {
+	s := &fakeStorage{
+		bags:      make(map[string]fakeBagMode, len(bags)),
+		byOverlay: make(map[string]fakeBag, len(bags)),
+	}
+
+	for bag, mode := range bags {
+		s.bags[bag.over] = mode
+		s.byOverlay[bag.over] = bag
+	}
+
+	return s
+}
+
+func (s *fakeStorage) has(bagID string) bool {
+	for _, bag := range s.byOverlay {
+		if bag.id == bagID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fakeConnector connects to fake storages by ip
+type fakeConnector struct {
+	storages map[string]*fakeStorage
+}
+
+type fakeConn struct {
+	s *fakeStorage
+}
+
+func (c *fakeConnector) Connect(_ context.Context, ip db.IPInfo) (storageConn, error) {
+	s, ok := c.storages[ip.IP]
+	if !ok {
+		return nil, errors.New("unknown address")
+	}
+
+	if s.connectErr != nil {
+		return nil, s.connectErr
+	}
+
+	return &fakeConn{s: s}, nil
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	return c.s.pingErr
+}
+
+func (c *fakeConn) Reinit() {}
+
+func (c *fakeConn) GetTorrentInfo(_ context.Context, over []byte) (container storage.TorrentInfoContainer, err error) {
+	key := hex.EncodeToString(over)
+	mode, ok := c.s.bags[key]
+	if !ok || mode == bagNoInfo {
+		err = errors.New("timeout")
+		return
+	}
+
+	container.Data = []byte(c.s.byOverlay[key].info)
+
+	return
+}
+
+func (c *fakeConn) GetPiece(_ context.Context, over []byte, _ int32) (piece storage.Piece, err error) {
+	key := hex.EncodeToString(over)
+	switch c.s.bags[key] {
+	case bagNoPieces:
+		err = errors.New("timeout")
+	case bagBadProof:
+		other, _ := cell.BeginCell().MustStoreUInt(0xdead, 16).EndCell().CreateProof(cell.CreateProofSkeleton())
+		piece.Proof = other.ToBOC()
+	default:
+		piece.Proof = []byte(c.s.byOverlay[key].proof)
+	}
+
+	return
+}
+
+func (c *fakeConn) Close() {}
+
+// fakeProvider is storage provider registered in fake network
+type fakeProvider struct {
+	key        ed25519.PublicKey
+	adnlKey    ed25519.PublicKey
+	storageKey ed25519.PublicKey
+	ip         string
+	// nil rates - provider doesn't respond
+	rates *transport.StorageRatesResponse
+	// storage can be found only via overlay DHT
+	noStorageProof bool
+}
+
+func (p *fakeProvider) pubkey() string {
+	return hex.EncodeToString(p.key)
+}
+
+type fakeAddress struct {
+	ip   string
+	port int32
+	key  ed25519.PublicKey
+}
+
+// fakeNetwork is in-memory TON network, it answers rates queries, DHT lookups
+// and bags requests of the registered providers
+type fakeNetwork struct {
+	fakeConnector
+	providers map[string]*fakeProvider
+	// ADNL addresses by key id
+	addresses map[string]fakeAddress
+}
+
+func newFakeNetwork() *fakeNetwork {
+	return &fakeNetwork{
+		fakeConnector: fakeConnector{storages: map[string]*fakeStorage{}},
+		providers:     map[string]*fakeProvider{},
+		addresses:     map[string]fakeAddress{},
+	}
+}
+
+func newFakeKey(t *testing.T) ed25519.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	return pub
+}
+
+func adnlID(t *testing.T, key ed25519.PublicKey) []byte {
+	t.Helper()
+
+	id, err := tl.Hash(keys.PublicKeyED25519{Key: key})
+	if err != nil {
+		t.Fatalf("failed to hash key: %v", err)
+	}
+
+	return id
+}
+
+// addProvider registers provider and its storage on the same ip in DHT
+func (n *fakeNetwork) addProvider(t *testing.T, ip string, s *fakeStorage) *fakeProvider {
+	t.Helper()
+
+	p := &fakeProvider{
+		key:        newFakeKey(t),
+		adnlKey:    newFakeKey(t),
+		storageKey: newFakeKey(t),
+		ip:         ip,
+		rates: &transport.StorageRatesResponse{
+			Available:    true,
+			RatePerMBDay: big.NewInt(1000).Bytes(),
+			MinBounty:    big.NewInt(50).Bytes(),
+			MinSpan:      3600,
+			MaxSpan:      86400,
+		},
+	}
+
+	n.providers[p.pubkey()] = p
+	n.addresses[hex.EncodeToString(adnlID(t, p.adnlKey))] = fakeAddress{ip: ip, port: 1, key: p.adnlKey}
+	n.addresses[hex.EncodeToString(adnlID(t, p.storageKey))] = fakeAddress{ip: ip, port: fakeStoragePort, key: p.storageKey}
+	n.storages[ip] = s
+
+	return p
+}
+
+func (n *fakeNetwork) GetStorageRates(_ context.Context, provider []byte, _ uint64) (*transport.StorageRatesResponse, error) {
+	p, ok := n.providers[hex.EncodeToString(provider)]
+	if !ok || p.rates == nil {
+		return nil, errors.New("provider is not responding")
+	}
+
+	return p.rates, nil
+}
+
+func (n *fakeNetwork) VerifyStorageADNLProof(_ context.Context, provider []byte, _ *address.Address) ([]byte, error) {
+	p, ok := n.providers[hex.EncodeToString(provider)]
+	if !ok || p.rates == nil || p.noStorageProof {
+		return nil, errors.New("failed to verify proof")
+	}
+
+	return tl.Hash(keys.PublicKeyED25519{Key: p.storageKey})
+}
+
+func (n *fakeNetwork) FindValue(_ context.Context, key *dht.Key, _ ...*dht.Continuation) (*dht.Value, *dht.Continuation, error) {
+	for _, p := range n.providers {
+		id, err := tl.Hash(keys.PublicKeyED25519{Key: p.key})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !bytes.Equal(id, key.ID) || string(key.Name) != "storage-provider" {
+			continue
+		}
+
+		adnl, err := tl.Hash(keys.PublicKeyED25519{Key: p.adnlKey})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := tl.Serialize(transport.ProviderDHTRecord{ADNLAddr: adnl}, true)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &dht.Value{Data: data}, nil, nil
+	}
+
+	return nil, nil, dht.ErrDHTValueIsNotFound
+}
+
+func (n *fakeNetwork) FindAddresses(_ context.Context, key []byte) (*adnlAddress.List, ed25519.PublicKey, error) {
+	a, ok := n.addresses[hex.EncodeToString(key)]
+	if !ok {
+		return nil, nil, dht.ErrDHTValueIsNotFound
+	}
+
+	return &adnlAddress.List{
+		Addresses: []*adnlAddress.UDP{{IP: net.ParseIP(a.ip).To4(), Port: a.port}},
+	}, a.key, nil
+}
+
+func (n *fakeNetwork) FindOverlayNodes(_ context.Context, bagID []byte, _ ...*dht.Continuation) (*overlay.NodesList, *dht.Continuation, error) {
+	nodes := &overlay.NodesList{}
+	for _, p := range n.providers {
+		if s, ok := n.storages[p.ip]; ok && s.has(hex.EncodeToString(bagID)) {
+			nodes.List = append(nodes.List, overlay.Node{ID: keys.PublicKeyED25519{Key: p.storageKey}})
+		}
+	}
+
+	if len(nodes.List) == 0 {
+		return nil, nil, dht.ErrDHTValueIsNotFound
+	}
+
+	return nodes, nil, nil
+}
+
+// fakeProviders is providers repository keeping written data in memory
+type fakeProviders struct {
+	providers
+
+	mu           sync.Mutex
+	pubkeys      []string
+	wallets      []db.ProviderWallet
+	contracts    []db.ContractToProviderRelation
+	created      []db.ProviderCreate
+	walletsLT    []db.ProviderWalletLT
+	newContracts []db.StorageContract
+	statuses     []db.ProviderStatusUpdate
+	updates      []db.ProviderUpdate
+	// saved rates of initialized providers
+	rates    map[string]db.ProviderRates
+	ips      []db.ProviderIP
+	rejected []db.ContractToProviderRelation
+	balances []db.ContractBalance
+	checks   []db.ContractProofsCheck
+	// requested audits are taken from the head
+	audits  []db.Audit
+	results []db.AuditResult
+}
+
+func (p *fakeProviders) GetAllProvidersWallets(context.Context) ([]db.ProviderWallet, error) {
+	return p.wallets, nil
+}
+
+func (p *fakeProviders) AddProviders(_ context.Context, providers []db.ProviderCreate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.created = append(p.created, providers...)
+
+	return nil
+}
+
+func (p *fakeProviders) UpdateProvidersLT(_ context.Context, wallets []db.ProviderWalletLT) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.walletsLT = append(p.walletsLT, wallets...)
+
+	return nil
+}
+
+func (p *fakeProviders) AddStorageContracts(_ context.Context, contracts []db.StorageContract) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.newContracts = append(p.newContracts, contracts...)
+
+	return nil
+}
+
+func (p *fakeProviders) GetAllProvidersPubkeys(context.Context) ([]string, error) {
+	return p.pubkeys, nil
+}
+
+func (p *fakeProviders) AddStatuses(_ context.Context, statuses []db.ProviderStatusUpdate) ([]db.ProviderStatusUpdate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.statuses = append(p.statuses, statuses...)
+
+	return nil, nil
+}
+
+func (p *fakeProviders) UpdateProviders(_ context.Context, updates []db.ProviderUpdate) (changed []db.ProviderPriceChange, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.updates = append(p.updates, updates...)
+
+	if p.rates == nil {
+		p.rates = make(map[string]db.ProviderRates)
+	}
+
+	for _, u := range updates {
+		rates := db.ProviderRates{RatePerMBDay: u.RatePerMBDay, MinBounty: u.MinBounty, MinSpan: u.MinSpan, MaxSpan: u.MaxSpan}
+		if old, ok := p.rates[u.Pubkey]; ok && old != rates {
+			changed = append(changed, db.ProviderPriceChange{Pubkey: u.Pubkey, Old: old, New: rates})
+		}
+		p.rates[u.Pubkey] = rates
+	}
+
+	return
+}
+
+func (p *fakeProviders) GetStorageContracts(context.Context) ([]db.ContractToProviderRelation, error) {
+	return p.contracts, nil
+}
+
+func (p *fakeProviders) UpdateRejectedStorageContracts(_ context.Context, contracts []db.ContractToProviderRelation) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rejected = append(p.rejected, contracts...)
+
+	return nil
+}
+
+func (p *fakeProviders) UpdateContractsBalances(_ context.Context, balances []db.ContractBalance) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.balances = append(p.balances, balances...)
+
+	return nil
+}
+
+func (p *fakeProviders) UpdateProvidersIPs(_ context.Context, ips []db.ProviderIP) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ips = append(p.ips, ips...)
+
+	return nil
+}
+
+func (p *fakeProviders) UpdateContractProofsChecks(_ context.Context, checks []db.ContractProofsCheck) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checks = append(p.checks, checks...)
+
+	return nil
+}
+
+func (p *fakeProviders) TakeAudit(context.Context, time.Duration) (*db.Audit, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.audits) == 0 {
+		return nil, nil
+	}
+
+	audit := p.audits[0]
+	p.audits = p.audits[1:]
+
+	return &audit, nil
+}
+
+func (p *fakeProviders) FinishAudit(_ context.Context, result db.AuditResult) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.results = append(p.results, result)
+
+	return nil
+}
+
+func (p *fakeProviders) UpdateRetrievalLatency(context.Context) error {
+	return nil
+}
+
+func (p *fakeProviders) UpdateStatusRTT(context.Context) error {
+	return nil
+}
+
+func (p *fakeProviders) UpdateStatuses(context.Context) ([]db.ProviderStatusChange, error) {
+	return nil, nil
+}
+
+// fakeTon reports the given providers of storage contracts
+type fakeTon struct {
+	ton
+
+	// providers public keys by contract address
+	providers map[string][]string
+}
+
+func (f *fakeTon) GetProvidersInfo(_ context.Context, addrs []string) ([]tonclient.StorageContractProviders, error) {
+	contracts := make([]tonclient.StorageContractProviders, 0, len(addrs))
+	for _, addr := range addrs {
+		contract := tonclient.StorageContractProviders{
+			Address: addr,
+			Balance: 1_000_000_000,
+		}
+
+		for _, pubkey := range f.providers[addr] {
+			key, err := hex.DecodeString(pubkey)
+			if err != nil {
+				return nil, err
+			}
+
+			contract.Providers = append(contract.Providers, tonclient.Provider{
+				Key:           string(key),
+				LastProofTime: time.Now(),
+				RatePerMBDay:  1000,
+				MaxSpan:       86400,
+			})
+		}
+
+		contracts = append(contracts, contract)
+	}
+
+	return contracts, nil
+}
+
+// fakeSystem keeps params in memory
+type fakeSystem struct {
+	params map[string]string
+}
+
+func (s *fakeSystem) GetParam(_ context.Context, key string) (string, error) {
+	return s.params[key], nil
+}
+
+func (s *fakeSystem) SetParam(_ context.Context, key string, value string) error {
+	s.params[key] = value
+	return nil
+}
+
+type fakeWebhooks struct {
+	mu     sync.Mutex
+	events []db.WebhookEvent
+}
+
+func (f *fakeWebhooks) AddEvents(_ context.Context, events []db.WebhookEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, events...)
+
+	return nil
+}
+
+// newFakeContractAddress returns valid contract address for n
+func newFakeContractAddress(n byte) string {
+	data := make([]byte, 32)
+	data[31] = n
+
+	return address.NewAddress(0, 0, data).String()
+}
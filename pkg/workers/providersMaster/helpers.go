@@ -2,38 +2,17 @@ package providersmaster
 
 import (
 	"math/big"
-	"strconv"
-	"sync"
 	"time"
 
-	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-storage/storage"
 
 	tonclient "mytonprovider-backend/pkg/clients/ton"
-	"mytonprovider-backend/pkg/constants"
 	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/pricing"
 )
 
-func fillStatuses(bagsStatuses *sync.Map, contracts []db.ContractToProviderRelation, reason constants.ReasonCode) {
-	for _, sc := range contracts {
-		bagsStatuses.Store(sc.ProviderAddress+sc.BagID, db.ContractProofsCheck{
-			ContractAddress: sc.Address,
-			ProviderAddress: sc.ProviderAddress,
-			Reason:          reason,
-		})
-	}
-}
-
-func getKey(bagID, ip string, port int32) string {
-	return ip + ":" + strconv.Itoa(int(port)) + "/" + bagID
-}
-
-func isRemovedByLowBalance(bagSize *big.Int, provider tonclient.Provider, contract tonclient.StorageContractProviders) bool {
-	var storageFee = tlb.MustFromTON("0.05").Nano()
-
-	mul := new(big.Int).Mul(new(big.Int).SetUint64(provider.RatePerMBDay), bagSize)
-	mul = mul.Mul(mul, new(big.Int).SetUint64(uint64(provider.MaxSpan)))
-	bounty := new(big.Int).Div(mul, big.NewInt(24*60*60*1024*1024))
-	bounty = bounty.Add(bounty, storageFee)
+func isRemovedByLowBalance(bagSize uint64, provider tonclient.Provider, contract tonclient.StorageContractProviders) bool {
+	bounty := pricing.SpanBalance(provider.RatePerMBDay, bagSize, provider.MaxSpan)
 
 	if new(big.Int).SetUint64(contract.Balance).Cmp(bounty) < 0 {
 		var deadline int64
@@ -51,3 +30,54 @@ func isRemovedByLowBalance(bagSize *big.Int, provider tonclient.Provider, contra
 
 	return false
 }
+
+// contractsBalances returns balances of contracts read from chain and estimates how long they last
+// with current providers rates. Contracts not read because of lite servers errors are skipped
+func contractsBalances(contracts []tonclient.StorageContractProviders, sizes map[string]uint64) []db.ContractBalance {
+	balances := make([]db.ContractBalance, 0, len(contracts))
+	for _, c := range contracts {
+		if c.LiteServerError {
+			continue
+		}
+
+		rates := make([]uint64, 0, len(c.Providers))
+		for _, p := range c.Providers {
+			rates = append(rates, p.RatePerMBDay)
+		}
+
+		b := db.ContractBalance{
+			Address: c.Address,
+			Balance: c.Balance,
+		}
+
+		if days, ok := pricing.DaysLeft(c.Balance, sizes[c.Address], rates); ok {
+			b.DaysLeft = &days
+		}
+
+		balances = append(balances, b)
+	}
+
+	return balances
+}
+
+func since(t time.Time) *time.Duration {
+	d := time.Since(t)
+	return &d
+}
+
+func milliseconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+
+	ms := d.Milliseconds()
+	return &ms
+}
+
+func piecesCount(info storage.TorrentInfo) uint32 {
+	if info.PieceSize == 0 {
+		return 0
+	}
+
+	return info.PiecesNum()
+}
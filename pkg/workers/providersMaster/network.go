@@ -0,0 +1,95 @@
+package providersmaster
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-storage/storage"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const maxAnswerSize = 32 << 20
+
+// errGateway is a local network problem, provider is not to blame for it
+var errGateway = errors.New("failed to start ADNL gateway")
+
+// storageConnector opens connections to providers storages
+type storageConnector interface {
+	Connect(ctx context.Context, ip db.IPInfo) (conn storageConn, err error)
+}
+
+// storageConn requests bags data from provider storage
+type storageConn interface {
+	Ping(ctx context.Context) (err error)
+	// Reinit recreates the connection in case it was lost
+	Reinit()
+	GetTorrentInfo(ctx context.Context, over []byte) (container storage.TorrentInfoContainer, err error)
+	GetPiece(ctx context.Context, over []byte, id int32) (piece storage.Piece, err error)
+	Close()
+}
+
+type adnlConnector struct {
+	prv ed25519.PrivateKey
+}
+
+type rldpConn struct {
+	gw   *adnl.Gateway
+	peer adnl.Peer
+	rl   *rldp.RLDP
+}
+
+func (c *adnlConnector) Connect(ctx context.Context, ip db.IPInfo) (conn storageConn, err error) {
+	gw := adnl.NewGateway(c.prv)
+	if err = gw.StartClient(); err != nil {
+		_ = gw.Close()
+		err = fmt.Errorf("%w: %w", errGateway, err)
+		return
+	}
+
+	addr := ip.IP + ":" + strconv.Itoa(int(ip.Port))
+	peer, err := gw.RegisterClient(addr, ip.PublicKey)
+	if err != nil {
+		_ = gw.Close()
+		err = fmt.Errorf("failed to create ADNL peer: %w", err)
+		return
+	}
+
+	conn = &rldpConn{
+		gw:   gw,
+		peer: peer,
+		rl:   rldp.NewClientV2(peer),
+	}
+
+	return
+}
+
+func (c *rldpConn) Ping(ctx context.Context) (err error) {
+	_, err = c.peer.Ping(ctx)
+	return
+}
+
+func (c *rldpConn) Reinit() {
+	c.peer.Reinit()
+}
+
+func (c *rldpConn) GetTorrentInfo(ctx context.Context, over []byte) (container storage.TorrentInfoContainer, err error) {
+	err = c.rl.DoQuery(ctx, maxAnswerSize, overlay.WrapQuery(over, &storage.GetTorrentInfo{}), &container)
+	return
+}
+
+func (c *rldpConn) GetPiece(ctx context.Context, over []byte, id int32) (piece storage.Piece, err error) {
+	err = c.rl.DoQuery(ctx, maxAnswerSize, overlay.WrapQuery(over, &storage.GetPiece{PieceID: id}), &piece)
+	return
+}
+
+func (c *rldpConn) Close() {
+	c.rl.Close()
+	_ = c.gw.Close()
+}
@@ -0,0 +1,225 @@
+package providersmaster
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	adnlAddress "github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-storage-provider/pkg/transport"
+
+	"mytonprovider-backend/pkg/constants"
+)
+
+const (
+	maxConcurrentRatesQueries = 50
+	// rates query timeout is this many times longer than the usual provider response time
+	ratesTimeoutFactor = 4
+	ratesMinTimeout    = 2 * time.Second
+	// weight of the last response time in the smoothed one
+	ratesSmoothing = 0.3
+)
+
+// errProviderUnreachable is returned when provider is in DHT, but its node address can't be used
+var errProviderUnreachable = errors.New("provider node is unreachable")
+
+// providersDHT marks failed lookups of providers nodes addresses, transport client connects
+// to providers with them, so rates queries failed on connecting are recognized by errors.Is
+type providersDHT struct {
+	transport.DHT
+}
+
+// NewProvidersDHT wraps DHT used by transport client of storage providers
+func NewProvidersDHT(d transport.DHT) transport.DHT {
+	return &providersDHT{DHT: d}
+}
+
+func (d *providersDHT) FindAddresses(ctx context.Context, key []byte) (list *adnlAddress.List, pub ed25519.PublicKey, err error) {
+	list, pub, err = d.DHT.FindAddresses(ctx, key)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", errProviderUnreachable, err)
+		return
+	}
+
+	// transport client connects to the first address
+	if len(list.Addresses) == 0 || list.Addresses[0].IP == nil {
+		err = fmt.Errorf("%w: no addresses", errProviderUnreachable)
+	}
+
+	return
+}
+
+// rates queries of one pass are spread over this window, so the pass takes about the same time
+var ratesPollSpread = 20 * time.Second
+
+// responseTimes keeps smoothed rates response time of each provider to pick query timeouts
+type responseTimes struct {
+	mu  sync.Mutex
+	avg map[string]time.Duration
+}
+
+func newResponseTimes() *responseTimes {
+	return &responseTimes{
+		avg: make(map[string]time.Duration),
+	}
+}
+
+// timeout returns rates query timeout, the longest one for providers that never responded
+func (r *responseTimes) timeout(pubkey string) time.Duration {
+	r.mu.Lock()
+	avg, ok := r.avg[pubkey]
+	r.mu.Unlock()
+
+	if !ok {
+		return providerResponseTimeout
+	}
+
+	return min(max(avg*ratesTimeoutFactor, ratesMinTimeout), providerResponseTimeout)
+}
+
+// observe updates provider response time, it's doubled on timeout so slowed down provider gets longer timeouts
+func (r *responseTimes) observe(pubkey string, d time.Duration, timedOut bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	avg, ok := r.avg[pubkey]
+	switch {
+	case timedOut && ok:
+		r.avg[pubkey] = min(avg*2, providerResponseTimeout/ratesTimeoutFactor)
+	case timedOut:
+	case !ok:
+		r.avg[pubkey] = d
+	default:
+		r.avg[pubkey] = time.Duration(ratesSmoothing*float64(d) + (1-ratesSmoothing)*float64(avg))
+	}
+}
+
+type ratesResult struct {
+	pubkey string
+	rates  *transport.StorageRatesResponse
+	err    error
+	// errClass is one of constants.Rates*, empty on valid response
+	errClass string
+	rtt      time.Duration
+}
+
+// classifyRatesError tells why storage rates query failed, connection errors are marked by providersDHT
+func classifyRatesError(err error, timedOut bool) string {
+	switch {
+	case timedOut || errors.Is(err, context.DeadlineExceeded):
+		return constants.RatesTimeout
+	case errors.Is(err, dht.ErrDHTValueIsNotFound):
+		return constants.RatesNotFound
+	case errors.Is(err, errProviderUnreachable):
+		return constants.RatesRefused
+	default:
+		return constants.RatesUnknownError
+	}
+}
+
+// validateRates checks that rates can be stored, rate and bounty are saved as bigint
+func validateRates(rates *transport.StorageRatesResponse) error {
+	switch {
+	case rates == nil:
+		return errors.New("empty response")
+	case len(rates.RatePerMBDay) > 7 || len(rates.MinBounty) > 7:
+		return errors.New("rate or bounty is too big")
+	case rates.MinSpan > rates.MaxSpan:
+		return errors.New("min span is greater than max span")
+	}
+
+	return nil
+}
+
+// pollRates queries storage rates of providers concurrently, queries start evenly over
+// ratesPollSpread with jitter. Providers with invalid public keys are skipped
+func (w *providersMasterWorker) pollRates(ctx context.Context, pubkeys []string, log *slog.Logger) []ratesResult {
+	start := time.Now()
+	defer func() {
+		w.ratesPollDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	var slot time.Duration
+	if len(pubkeys) > 0 {
+		slot = ratesPollSpread / time.Duration(len(pubkeys))
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make([]ratesResult, 0, len(pubkeys))
+	)
+	semaphore := make(chan struct{}, maxConcurrentRatesQueries)
+
+	for i, pubkey := range pubkeys {
+		d, err := hex.DecodeString(pubkey)
+		if err != nil || len(d) != 32 {
+			continue
+		}
+
+		delay := slot * time.Duration(i)
+		if slot > 0 {
+			delay += time.Duration(rand.Int63n(int64(slot)))
+		}
+
+		wg.Add(1)
+		go func(pubkey string, key []byte, delay time.Duration) {
+			defer wg.Done()
+
+			t := time.NewTimer(delay)
+			defer t.Stop()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			timeout := w.responseTimes.timeout(pubkey)
+			timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+			s := time.Now()
+			rates, err := w.providerClient.GetStorageRates(timeoutCtx, key, fakeSize)
+			elapsed := time.Since(s)
+			timedOut := timeoutCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+			cancel()
+
+			if err == nil || timedOut {
+				w.responseTimes.observe(pubkey, elapsed, timedOut)
+			}
+
+			if timedOut {
+				w.ratesTimeouts.Inc()
+				log.Debug("storage rates query timed out", "provider_pubkey", pubkey, "timeout", timeout)
+			}
+
+			res := ratesResult{pubkey: pubkey, rates: rates, err: err}
+			if err != nil {
+				res.errClass = classifyRatesError(err, timedOut)
+			} else {
+				res.rtt = elapsed
+				if vErr := validateRates(rates); vErr != nil {
+					res.err = vErr
+					res.errClass = constants.RatesInvalidResponse
+				}
+			}
+
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}(pubkey, d, delay)
+	}
+
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,163 @@
+package providersmaster
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-storage-provider/pkg/transport"
+
+	"mytonprovider-backend/pkg/constants"
+)
+
+func Test_ResponseTimes(t *testing.T) {
+	type observation struct {
+		d        time.Duration
+		timedOut bool
+	}
+
+	tests := []struct {
+		name         string
+		observations []observation
+		want         time.Duration
+	}{
+		{
+			name: "never responded",
+			want: providerResponseTimeout,
+		},
+		{
+			name:         "timed out without history",
+			observations: []observation{{d: providerResponseTimeout, timedOut: true}},
+			want:         providerResponseTimeout,
+		},
+		{
+			name:         "fast provider gets min timeout",
+			observations: []observation{{d: 100 * time.Millisecond}},
+			want:         ratesMinTimeout,
+		},
+		{
+			name:         "slow provider",
+			observations: []observation{{d: time.Second}},
+			want:         4 * time.Second,
+		},
+		{
+			name:         "smoothed response time",
+			observations: []observation{{d: time.Second}, {d: 2 * time.Second}},
+			want:         time.Duration(4 * (0.3*2 + 0.7*1) * float64(time.Second)),
+		},
+		{
+			name:         "timeout doubles response time",
+			observations: []observation{{d: time.Second}, {timedOut: true}},
+			want:         8 * time.Second,
+		},
+		{
+			name:         "timeouts are capped",
+			observations: []observation{{d: time.Second}, {timedOut: true}, {timedOut: true}, {timedOut: true}},
+			want:         providerResponseTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newResponseTimes()
+			for _, o := range tt.observations {
+				r.observe("provider", o.d, o.timedOut)
+			}
+
+			if got := r.timeout("provider"); got != tt.want {
+				t.Fatalf("timeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ClassifyRatesError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		timedOut bool
+		want     string
+	}{
+		{
+			name:     "query timed out",
+			err:      errors.New("failed to do request: response deadline exceeded"),
+			timedOut: true,
+			want:     constants.RatesTimeout,
+		},
+		{
+			name: "connect timed out",
+			err:  fmt.Errorf("failed to connect to provider: %w", context.DeadlineExceeded),
+			want: constants.RatesTimeout,
+		},
+		{
+			name: "not in dht",
+			err:  fmt.Errorf("failed to connect to provider: failed to find storage-provider in dht: %w", dht.ErrDHTValueIsNotFound),
+			want: constants.RatesNotFound,
+		},
+		{
+			name: "connection failed",
+			err:  fmt.Errorf("failed to connect to provider: failed to find address in dht: %w: no addresses", errProviderUnreachable),
+			want: constants.RatesRefused,
+		},
+		{
+			name: "other error",
+			err:  errors.New("failed to do request: failed to do query"),
+			want: constants.RatesUnknownError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyRatesError(tt.err, tt.timedOut); got != tt.want {
+				t.Fatalf("class = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PollRatesConnectErrors(t *testing.T) {
+	spread := ratesPollSpread
+	ratesPollSpread = 0
+	t.Cleanup(func() { ratesPollSpread = spread })
+
+	network := newFakeNetwork()
+	unreachable := network.addProvider(t, "10.0.0.1", newFakeStorage(nil))
+	// provider record is in DHT, but its node has no usable address
+	network.addresses[hex.EncodeToString(adnlID(t, unreachable.adnlKey))] = fakeAddress{key: unreachable.adnlKey}
+	missing := hex.EncodeToString(newFakeKey(t))
+
+	_, prv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	w := newTestWorker(network, &fakeProviders{}, nil)
+	// connect step of the real transport client runs on the fake DHT
+	w.providerClient = transport.NewClient(adnl.NewGateway(prv), NewProvidersDHT(network))
+
+	results := w.pollRates(context.Background(), []string{unreachable.pubkey(), missing}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	classes := make(map[string]string, len(results))
+	for _, r := range results {
+		if r.err == nil {
+			t.Fatalf("unexpected rates of %s: %+v", r.pubkey, r.rates)
+		}
+		classes[r.pubkey] = r.errClass
+	}
+
+	if classes[unreachable.pubkey()] != constants.RatesRefused {
+		t.Errorf("class of unreachable provider = %q, want %q", classes[unreachable.pubkey()], constants.RatesRefused)
+	}
+
+	if classes[missing] != constants.RatesNotFound {
+		t.Errorf("class of provider missing in DHT = %q, want %q", classes[missing], constants.RatesNotFound)
+	}
+}
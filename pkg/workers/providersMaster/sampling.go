@@ -0,0 +1,100 @@
+package providersmaster
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// confidenceLossShare is the share of lost pieces which the check is expected to notice
+const confidenceLossShare = 0.1
+
+// Sampling sets how many pieces of each bag are checked with proof:
+// MinPieces + bag size / BytesPerPiece, but not more than MaxPieces.
+// First and last pieces are always checked.
+type Sampling struct {
+	MinPieces     uint32 `env:"PROOF_SAMPLE_MIN_PIECES" envDefault:"3"`
+	MaxPieces     uint32 `env:"PROOF_SAMPLE_MAX_PIECES" envDefault:"16"`
+	BytesPerPiece uint64 `env:"PROOF_SAMPLE_BYTES_PER_PIECE" envDefault:"1073741824"` // one more piece per GiB
+	// Seed of pieces choice, 0 - new seed every run. Seed is saved to checks history, so any check can be repeated
+	Seed int64 `env:"PROOF_SAMPLE_SEED" envDefault:"0"`
+}
+
+// sampler picks pieces to check, the same seed gives the same pieces for a bag
+type sampler struct {
+	cfg  Sampling
+	seed int64
+}
+
+func newSampler(cfg Sampling) sampler {
+	seed := cfg.Seed
+	for seed == 0 {
+		seed = rand.Int63()
+	}
+
+	return sampler{
+		cfg:  cfg,
+		seed: seed,
+	}
+}
+
+// pieces returns sorted ids of pieces to check
+func (s sampler) pieces(bagID string, fileSize uint64, piecesNum uint32) (ids []int32) {
+	if piecesNum == 0 {
+		return nil
+	}
+
+	n := uint64(s.cfg.MinPieces)
+	if s.cfg.BytesPerPiece > 0 {
+		n += fileSize / s.cfg.BytesPerPiece
+	}
+	n = max(min(n, uint64(s.cfg.MaxPieces), uint64(piecesNum)), 1)
+
+	picked := map[int32]struct{}{0: {}}
+	ids = append(ids, 0)
+	if last := int32(piecesNum - 1); last > 0 {
+		picked[last] = struct{}{}
+		ids = append(ids, last)
+	}
+
+	r := rand.New(rand.NewSource(s.bagSeed(bagID)))
+	for uint64(len(ids)) < n {
+		id := int32(r.Int63n(int64(piecesNum)))
+		if _, ok := picked[id]; ok {
+			continue
+		}
+
+		picked[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	return
+}
+
+func (s sampler) bagSeed(bagID string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(bagID))
+
+	return s.seed ^ int64(h.Sum64())
+}
+
+// confidence is the share of valid pieces among sampled multiplied by the probability
+// that loss of confidenceLossShare of the bag would be noticed by sampled pieces
+func confidence(valid, sampled int, piecesNum uint32) float64 {
+	if sampled == 0 {
+		return 0
+	}
+
+	share := float64(valid) / float64(sampled)
+	if sampled >= int(piecesNum) {
+		// whole bag is checked
+		return share
+	}
+
+	return share * (1 - math.Pow(1-confidenceLossShare, float64(sampled)))
+}
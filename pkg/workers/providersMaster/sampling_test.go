@@ -0,0 +1,92 @@
+package providersmaster
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+const testBagID = "8f2a1c7e0b5d4a3f9e6c2b1a0d8e7f6c5b4a3928170f6e5d4c3b2a1908f7e6d5"
+
+func Test_SamplerPieces(t *testing.T) {
+	cfg := Sampling{
+		MinPieces:     3,
+		MaxPieces:     8,
+		BytesPerPiece: 1 << 30,
+	}
+
+	tests := []struct {
+		name      string
+		fileSize  uint64
+		piecesNum uint32
+		want      int
+	}{
+		{name: "no pieces", fileSize: 0, piecesNum: 0, want: 0},
+		{name: "single piece", fileSize: 100, piecesNum: 1, want: 1},
+		{name: "two pieces", fileSize: 200, piecesNum: 2, want: 2},
+		{name: "small bag", fileSize: 1 << 20, piecesNum: 8, want: 3},
+		{name: "weighted by size", fileSize: 3 << 30, piecesNum: 1 << 15, want: 6},
+		{name: "max pieces", fileSize: 100 << 30, piecesNum: 1 << 20, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := sampler{cfg: cfg, seed: 42}.pieces(testBagID, tt.fileSize, tt.piecesNum)
+			if len(ids) != tt.want {
+				t.Fatalf("pieces count = %d, want %d", len(ids), tt.want)
+			}
+
+			if tt.want == 0 {
+				return
+			}
+
+			if ids[0] != 0 || ids[len(ids)-1] != int32(tt.piecesNum-1) {
+				t.Errorf("first and last pieces are not checked: %v", ids)
+			}
+
+			if !slices.IsSorted(ids) || len(slices.Compact(slices.Clone(ids))) != len(ids) {
+				t.Errorf("pieces are not sorted or not unique: %v", ids)
+			}
+		})
+	}
+}
+
+func Test_SamplerDeterministic(t *testing.T) {
+	cfg := Sampling{MinPieces: 10, MaxPieces: 10}
+
+	a := sampler{cfg: cfg, seed: 7}.pieces(testBagID, 1<<30, 1<<16)
+	b := sampler{cfg: cfg, seed: 7}.pieces(testBagID, 1<<30, 1<<16)
+	if !slices.Equal(a, b) {
+		t.Fatalf("same seed gives different pieces: %v and %v", a, b)
+	}
+
+	c := sampler{cfg: cfg, seed: 8}.pieces(testBagID, 1<<30, 1<<16)
+	if slices.Equal(a, c) {
+		t.Fatalf("different seeds give the same pieces: %v", a)
+	}
+}
+
+func Test_Confidence(t *testing.T) {
+	tests := []struct {
+		name      string
+		valid     int
+		sampled   int
+		piecesNum uint32
+		want      float64
+	}{
+		{name: "nothing sampled", valid: 0, sampled: 0, piecesNum: 10, want: 0},
+		{name: "whole bag valid", valid: 4, sampled: 4, piecesNum: 4, want: 1},
+		{name: "whole bag half valid", valid: 2, sampled: 4, piecesNum: 4, want: 0.5},
+		{name: "one of many", valid: 1, sampled: 1, piecesNum: 1000, want: 0.1},
+		{name: "ten of many", valid: 10, sampled: 10, piecesNum: 1000, want: 1 - math.Pow(0.9, 10)},
+		{name: "failed piece", valid: 4, sampled: 8, piecesNum: 1000, want: 0.5 * (1 - math.Pow(0.9, 8))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := confidence(tt.valid, tt.sampled, tt.piecesNum); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("confidence = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,12 +16,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/xssnick/tonutils-go/address"
-	"github.com/xssnick/tonutils-go/adnl"
+	adnlAddress "github.com/xssnick/tonutils-go/adnl/address"
 	"github.com/xssnick/tonutils-go/adnl/dht"
 	"github.com/xssnick/tonutils-go/adnl/keys"
 	"github.com/xssnick/tonutils-go/adnl/overlay"
-	"github.com/xssnick/tonutils-go/adnl/rldp"
 	"github.com/xssnick/tonutils-go/tl"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -32,6 +32,7 @@ import (
 	tonclient "mytonprovider-backend/pkg/clients/ton"
 	"mytonprovider-backend/pkg/constants"
 	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/rating"
 	"mytonprovider-backend/pkg/utils"
 )
 
@@ -54,6 +55,9 @@ const (
 	ipInfoSleepDuration     = 1 * time.Second
 )
 
+// pause between bags checks, weak providers may be overloaded
+var bagChecksPause = 500 * time.Millisecond
+
 type providers interface {
 	GetAllProvidersPubkeys(ctx context.Context) (pubkeys []string, err error)
 	GetAllProvidersWallets(ctx context.Context) (wallets []db.ProviderWallet, err error)
@@ -61,16 +65,22 @@ type providers interface {
 	AddStorageContracts(ctx context.Context, contracts []db.StorageContract) (err error)
 	GetStorageContracts(ctx context.Context) (contracts []db.ContractToProviderRelation, err error)
 	UpdateRejectedStorageContracts(ctx context.Context, storageContracts []db.ContractToProviderRelation) (err error)
+	UpdateContractsBalances(ctx context.Context, balances []db.ContractBalance) (err error)
 	AddProviders(ctx context.Context, providers []db.ProviderCreate) (err error)
 	UpdateProvidersIPs(ctx context.Context, ips []db.ProviderIP) (err error)
-	UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (err error)
-	AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (err error)
+	UpdateProviders(ctx context.Context, providers []db.ProviderUpdate) (changed []db.ProviderPriceChange, err error)
+	AddStatuses(ctx context.Context, providers []db.ProviderStatusUpdate) (changed []db.ProviderStatusUpdate, err error)
 	UpdateContractProofsChecks(ctx context.Context, contractsProofs []db.ContractProofsCheck) (err error)
-	UpdateStatuses(ctx context.Context) (err error)
+	UpdateStatuses(ctx context.Context) (changed []db.ProviderStatusChange, err error)
 	UpdateUptime(ctx context.Context) (err error)
-	UpdateRating(ctx context.Context) (err error)
+	UpdateRetrievalLatency(ctx context.Context) (err error)
+	UpdateStatusRTT(ctx context.Context) (err error)
+	GetRatingInputs(ctx context.Context, uptimeWindow string, pubkeys []string) (inputs []db.RatingInputs, err error)
+	UpdateRating(ctx context.Context, ratings []db.ProviderRating) (err error)
 	GetProvidersIPs(ctx context.Context) (ips []db.ProviderIP, err error)
 	UpdateProvidersIPInfo(ctx context.Context, ips []db.ProviderIPInfo) (err error)
+	TakeAudit(ctx context.Context, staleAfter time.Duration) (audit *db.Audit, err error)
+	FinishAudit(ctx context.Context, result db.AuditResult) (err error)
 }
 
 type system interface {
@@ -84,21 +94,48 @@ type ton interface {
 	GetProvidersInfo(ctx context.Context, addrs []string) (contractsProviders []tonclient.StorageContractProviders, err error)
 }
 
+type webhooks interface {
+	AddEvents(ctx context.Context, events []db.WebhookEvent) (err error)
+}
+
 type ipclient interface {
 	GetIPInfo(ctx context.Context, ip string) (conf *ifconfig.Info, err error)
 }
 
+// providerClient queries storage providers over their ADNL channel
+type providerClient interface {
+	GetStorageRates(ctx context.Context, provider []byte, size uint64) (rates *transport.StorageRatesResponse, err error)
+	VerifyStorageADNLProof(ctx context.Context, provider []byte, contract *address.Address) (proof []byte, err error)
+}
+
+// dhtClient looks up providers and storages addresses in TON DHT
+type dhtClient interface {
+	FindValue(ctx context.Context, key *dht.Key, continuation ...*dht.Continuation) (value *dht.Value, cont *dht.Continuation, err error)
+	FindAddresses(ctx context.Context, key []byte) (list *adnlAddress.List, pub ed25519.PublicKey, err error)
+	FindOverlayNodes(ctx context.Context, overlayKey []byte, continuation ...*dht.Continuation) (nodes *overlay.NodesList, cont *dht.Continuation, err error)
+}
+
 type providersMasterWorker struct {
 	providers      providers
 	system         system
 	ton            ton
 	ipinfo         ipclient
+	webhooks       webhooks
 	prv            ed25519.PrivateKey
-	providerClient *transport.Client
-	dhtClient      *dht.Client
+	providerClient providerClient
+	storage        storageConnector
+	dhtClient      dhtClient
 	masterAddr     string
 	batchSize      uint32
-	logger         *slog.Logger
+	sampling       Sampling
+	audit          Audit
+	scorer         rating.Scorer
+	// uptime window used in rating, one of constants.UptimeWindowsMap keys
+	ratingUptimeWindow string
+	responseTimes      *responseTimes
+	ratesPollDuration  prometheus.Histogram
+	ratesTimeouts      prometheus.Counter
+	logger             *slog.Logger
 }
 
 type Worker interface {
@@ -109,6 +146,7 @@ type Worker interface {
 	UpdateUptime(ctx context.Context) (interval time.Duration, err error)
 	UpdateRating(ctx context.Context) (interval time.Duration, err error)
 	UpdateIPInfo(ctx context.Context) (interval time.Duration, err error)
+	RunAudits(ctx context.Context) (interval time.Duration, err error)
 }
 
 func (w *providersMasterWorker) CollectNewProviders(ctx context.Context) (interval time.Duration, err error) {
@@ -242,58 +280,92 @@ func (w *providersMasterWorker) UpdateKnownProviders(ctx context.Context) (inter
 		return
 	}
 
-	providersInfo := make([]db.ProviderUpdate, 0, len(p))
-	providersStatuses := make([]db.ProviderStatusUpdate, 0, len(p))
-	for _, pubkey := range p {
-		select {
-		case <-ctx.Done():
-			log.Info("context done, stopping provider check")
-			return
-		default:
+	results := w.pollRates(ctx, p, log)
+	if ctx.Err() != nil {
+		log.Info("context done, stopping provider check")
+		return
+	}
+
+	providersInfo := make([]db.ProviderUpdate, 0, len(results))
+	providersStatuses := make([]db.ProviderStatusUpdate, 0, len(results))
+	for _, r := range results {
+		status := db.ProviderStatusUpdate{
+			Pubkey: r.pubkey,
+			// provider with invalid rates is still reachable
+			IsOnline: r.err == nil || r.errClass == constants.RatesInvalidResponse,
 		}
-		d, err := hex.DecodeString(pubkey)
-		if err != nil || len(d) != 32 {
-			continue
+		if status.IsOnline {
+			rtt := r.rtt.Milliseconds()
+			status.RTTMs = &rtt
 		}
+		if r.errClass != "" {
+			status.ErrorClass = &r.errClass
+		}
+		providersStatuses = append(providersStatuses, status)
 
-		timeoutCtx, cancel := context.WithTimeout(ctx, providerResponseTimeout)
-		rates, err := w.providerClient.GetStorageRates(timeoutCtx, d, fakeSize)
-		cancel()
-		if err != nil {
-			providersStatuses = append(providersStatuses, db.ProviderStatusUpdate{
-				Pubkey:   pubkey,
-				IsOnline: false,
-			})
+		if r.err != nil {
+			log.Debug("failed to get storage rates", "provider_pubkey", r.pubkey, "class", r.errClass, "error", r.err)
 			continue
 		}
 
-		providersStatuses = append(providersStatuses, db.ProviderStatusUpdate{
-			Pubkey:   pubkey,
-			IsOnline: true,
-		})
-
 		providersInfo = append(providersInfo, db.ProviderUpdate{
-			Pubkey:       pubkey,
-			RatePerMBDay: new(big.Int).SetBytes(rates.RatePerMBDay).Int64(),
-			MinBounty:    new(big.Int).SetBytes(rates.MinBounty).Int64(),
-			MinSpan:      rates.MinSpan,
-			MaxSpan:      rates.MaxSpan,
+			Pubkey:       r.pubkey,
+			RatePerMBDay: new(big.Int).SetBytes(r.rates.RatePerMBDay).Int64(),
+			MinBounty:    new(big.Int).SetBytes(r.rates.MinBounty).Int64(),
+			MinSpan:      r.rates.MinSpan,
+			MaxSpan:      r.rates.MaxSpan,
 		})
 	}
 
-	err = w.providers.AddStatuses(ctx, providersStatuses)
+	changedStatuses, err := w.providers.AddStatuses(ctx, providersStatuses)
 	if err != nil {
 		interval = failureInterval
 		return
 	}
 
-	err = w.providers.UpdateProviders(ctx, providersInfo)
+	err = w.providers.UpdateStatusRTT(ctx)
 	if err != nil {
+		log.Error("failed to update providers rtt", "error", err)
 		interval = failureInterval
 		return
 	}
 
-	log.Info("successfully updated known providers", "active", len(providersInfo))
+	now := time.Now().Unix()
+	events := make([]db.WebhookEvent, 0, len(changedStatuses))
+	for _, s := range changedStatuses {
+		eventType := constants.ProviderOfflineEvent
+		if s.IsOnline {
+			eventType = constants.ProviderOnlineEvent
+		}
+
+		events = append(events, db.WebhookEvent{
+			Type:           eventType,
+			ProviderPubKey: s.Pubkey,
+			IsOnline:       &s.IsOnline,
+			Timestamp:      now,
+		})
+	}
+	w.sendEvents(ctx, events, log)
+
+	changedPrices, err := w.providers.UpdateProviders(ctx, providersInfo)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	events = make([]db.WebhookEvent, 0, len(changedPrices))
+	for _, c := range changedPrices {
+		events = append(events, db.WebhookEvent{
+			Type:           constants.ProviderPriceChangedEvent,
+			ProviderPubKey: c.Pubkey,
+			OldRates:       &c.Old,
+			NewRates:       &c.New,
+			Timestamp:      now,
+		})
+	}
+	w.sendEvents(ctx, events, log)
+
+	log.Info("successfully updated known providers", "active", len(providersInfo), "price_changes", len(changedPrices))
 
 	return
 }
@@ -457,13 +529,33 @@ func (w *providersMasterWorker) StoreProof(ctx context.Context) (interval time.D
 		return
 	}
 
-	err = w.providers.UpdateStatuses(ctx)
+	err = w.providers.UpdateRetrievalLatency(ctx)
+	if err != nil {
+		log.Error("failed to update retrieval latency", "error", err)
+		interval = failureInterval
+		return
+	}
+
+	changedStatuses, err := w.providers.UpdateStatuses(ctx)
 	if err != nil {
 		log.Error("failed to update provider statuses", "error", err)
 		interval = failureInterval
 		return
 	}
 
+	now := time.Now().Unix()
+	events := make([]db.WebhookEvent, 0, len(changedStatuses))
+	for _, s := range changedStatuses {
+		events = append(events, db.WebhookEvent{
+			Type:           constants.ProviderStatusChangedEvent,
+			ProviderPubKey: s.PubKey,
+			OldStatus:      s.OldStatus,
+			NewStatus:      s.NewStatus,
+			Timestamp:      now,
+		})
+	}
+	w.sendEvents(ctx, events, log)
+
 	return
 }
 
@@ -498,7 +590,22 @@ func (w *providersMasterWorker) UpdateRating(ctx context.Context) (interval time
 
 	interval = successInterval
 
-	err = w.providers.UpdateRating(ctx)
+	inputs, err := w.providers.GetRatingInputs(ctx, w.ratingUptimeWindow, nil)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	now := time.Now()
+	ratings := make([]db.ProviderRating, 0, len(inputs))
+	for _, in := range inputs {
+		ratings = append(ratings, db.ProviderRating{
+			PubKey: in.PubKey,
+			Rating: w.scorer.Score(in, now).Score,
+		})
+	}
+
+	err = w.providers.UpdateRating(ctx, ratings)
 	if err != nil {
 		interval = failureInterval
 		return
@@ -577,14 +684,44 @@ func (w *providersMasterWorker) UpdateIPInfo(ctx context.Context) (interval time
 func (w *providersMasterWorker) updateActiveContracts(ctx context.Context, storageContracts []db.ContractToProviderRelation, availableProvidersIPs map[string]db.ProviderIP) (err error) {
 	log := w.logger.With(slog.String("worker", "StoreProof"), slog.String("function", "updateActiveContracts"))
 
+	contractProofsChecks := w.checkContracts(ctx, storageContracts, availableProvidersIPs, log)
+
+	valid := 0
+	for _, proof := range contractProofsChecks {
+		if proof.Reason == constants.ValidStorageProof {
+			valid++
+		}
+	}
+
+	// checks failed because of stopped job must not be saved, the new leader checks contracts again
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	err = w.providers.UpdateContractProofsChecks(ctx, contractProofsChecks)
+	if err != nil {
+		log.Error("failed to update contract proofs checks", "error", err)
+		return
+	}
+
+	log.Info("successfully updated contract proofs checks", "count", len(contractProofsChecks), "valid", valid)
+
+	return nil
+}
+
+// checkContracts checks storage proofs of all contracts, providers are checked concurrently
+func (w *providersMasterWorker) checkContracts(ctx context.Context, storageContracts []db.ContractToProviderRelation, availableProvidersIPs map[string]db.ProviderIP, log *slog.Logger) []db.ContractProofsCheck {
 	providersContracts := make(map[string][]db.ContractToProviderRelation)
 	for _, sc := range storageContracts {
 		providersContracts[sc.ProviderPublicKey] = append(providersContracts[sc.ProviderPublicKey], sc)
 	}
 
+	smp := newSampler(w.sampling)
+	log.Debug("checking storage proofs", "seed", smp.seed)
+
 	wg := sync.WaitGroup{}
 	semaphore := make(chan struct{}, maxConcurrentBagChecks)
-	var bagsStatuses sync.Map
+	checks := newChecksCollector(len(storageContracts))
 
 	for pubkey, contracts := range providersContracts {
 		wg.Add(1)
@@ -594,57 +731,22 @@ func (w *providersMasterWorker) updateActiveContracts(ctx context.Context, stora
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			gw := adnl.NewGateway(w.prv)
-			defer gw.Close()
-
-			if sErr := gw.StartClient(); sErr != nil {
-				log.Error("failed to start ADNL gateway", "error", sErr)
-				return
-			}
-
 			ip, ok := availableProvidersIPs[pubkey]
 			if !ok {
-				fillStatuses(&bagsStatuses, contracts, constants.IPNotFound)
+				checks.fill(contracts, constants.IPNotFound)
 				return
 			}
 
-			checkProviderFiles(ctx, gw, ip, contracts, &bagsStatuses, log)
+			checkProviderFiles(ctx, w.storage, ip, contracts, smp, checks, log)
 		}(pubkey, contracts)
 	}
 
 	wg.Wait()
 
-	valid := 0
-	contractProofsChecks := make([]db.ContractProofsCheck, 0, len(storageContracts))
-	bagsStatuses.Range(func(_, value any) (resp bool) {
-		resp = true
-
-		proof, ok := value.(db.ContractProofsCheck)
-		if !ok {
-			return
-		}
-
-		contractProofsChecks = append(contractProofsChecks, proof)
-
-		if proof.Reason == constants.ValidStorageProof {
-			valid++
-		}
-
-		return
-	})
-
-	err = w.providers.UpdateContractProofsChecks(ctx, contractProofsChecks)
-	if err != nil {
-		log.Error("failed to update contract proofs checks", "error", err)
-		return
-	}
-
-	log.Info("successfully updated contract proofs checks", "count", len(contractProofsChecks), "valid", valid)
-
-	return nil
+	return checks.results()
 }
 
-func checkProviderFiles(ctx context.Context, gw *adnl.Gateway, ip db.ProviderIP, storageContracts []db.ContractToProviderRelation, bagsStatuses *sync.Map, log *slog.Logger) {
+func checkProviderFiles(ctx context.Context, connector storageConnector, ip db.ProviderIP, storageContracts []db.ContractToProviderRelation, smp sampler, checks *checksCollector, log *slog.Logger) {
 	log = log.With(slog.String("provider_pubkey", ip.PublicKey))
 	log.Debug("Start checking provider files")
 	s := time.Now()
@@ -654,34 +756,29 @@ func checkProviderFiles(ctx context.Context, gw *adnl.Gateway, ip db.ProviderIP,
 
 	stats := make(map[constants.ReasonCode]int)
 	// to skip dead providers and save time
-	maxFailureThreshold := uint32(len(storageContracts) / 100 * 20)
+	maxFailureThreshold := uint32(len(storageContracts) * 20 / 100)
 	var failsInARow uint32
 
-	addr := ip.Storage.IP + ":" + strconv.Itoa(int(ip.Storage.Port))
-	peer, rErr := gw.RegisterClient(addr, ip.Storage.PublicKey)
-	if rErr != nil {
-		log.Debug("failed to create ADNL peer", "error", rErr)
-		fillStatuses(bagsStatuses, storageContracts, constants.CantCreatePeer)
+	conn, reason, err := connectStorage(ctx, connector, ip.Storage, log)
+	if err != nil {
+		// not a provider problem, contracts keep their last check result
+		log.Error("failed to connect to provider storage", "error", err)
 		return
 	}
-
-	pingCtx, pingCancel := context.WithTimeout(ctx, pingTimeout)
-	_, pErr := peer.Ping(pingCtx)
-	pingCancel()
-	if pErr != nil {
-		log.Debug("initial provider ping failed", "error", pErr)
-		fillStatuses(bagsStatuses, storageContracts, constants.FailedInitialPing)
+	if reason != constants.ValidStorageProof {
+		checks.fill(storageContracts, reason)
 		return
 	}
-
-	rl := rldp.NewClientV2(peer)
-	defer rl.Close()
+	defer conn.Close()
 
 	for _, sc := range storageContracts {
-		statusKey := getKey(sc.BagID, ip.Storage.IP, ip.Storage.Port)
+		// leader changed or instance is stopping, the rest contracts keep their last check result
+		if ctx.Err() != nil {
+			return
+		}
 
 		if failsInARow > maxFailureThreshold {
-			bagsStatuses.Store(statusKey, db.ContractProofsCheck{
+			checks.add(db.ContractProofsCheck{
 				ContractAddress: sc.Address,
 				ProviderAddress: sc.ProviderAddress,
 				Reason:          constants.UnavailableProvider,
@@ -690,23 +787,18 @@ func checkProviderFiles(ctx context.Context, gw *adnl.Gateway, ip db.ProviderIP,
 			continue
 		}
 
-		reason := checkPiece(ctx, rl, sc.BagID, log)
-		bagsStatuses.Store(statusKey, db.ContractProofsCheck{
-			ContractAddress: sc.Address,
-			ProviderAddress: sc.ProviderAddress,
-			Reason:          reason,
-		})
+		res := checkBag(ctx, conn, sc.BagID, smp, log)
+		checks.add(res.toDB(sc, smp.seed))
 
-		stats[reason]++
+		stats[res.reason]++
 
-		if reason == constants.ValidStorageProof {
+		if res.reason == constants.ValidStorageProof {
 			failsInARow = 0
 		} else {
 			failsInARow++
 		}
 
-		// weak providers may be overloaded
-		time.Sleep(500 * time.Millisecond)
+		time.Sleep(bagChecksPause)
 	}
 
 	for reason, count := range stats {
@@ -714,55 +806,181 @@ func checkProviderFiles(ctx context.Context, gw *adnl.Gateway, ip db.ProviderIP,
 	}
 }
 
-func checkPiece(ctx context.Context, rl *rldp.RLDP, bagID string, log *slog.Logger) (reason constants.ReasonCode) {
-	log = log.With(slog.String("bag_id", bagID))
+// connectStorage connects to provider storage, reason is ValidStorageProof if provider responds.
+// err is set only for local problems
+func connectStorage(ctx context.Context, connector storageConnector, storageIP db.IPInfo, log *slog.Logger) (conn storageConn, reason constants.ReasonCode, err error) {
+	conn, cErr := connector.Connect(ctx, storageIP)
+	if cErr != nil {
+		if errors.Is(cErr, errGateway) {
+			err = cErr
+			return
+		}
 
-	reason = constants.NotFound
+		log.Debug("failed to create ADNL peer", "error", cErr)
+		reason = constants.CantCreatePeer
+		return
+	}
 
-	peer, ok := rl.GetADNL().(adnl.Peer)
-	if !ok {
-		log.Error("failed to get ADNL peer")
-		reason = constants.UnknownPeer
+	pingCtx, pingCancel := context.WithTimeout(ctx, pingTimeout)
+	pErr := conn.Ping(pingCtx)
+	pingCancel()
+	if pErr != nil {
+		log.Debug("initial provider ping failed", "error", pErr)
+		conn.Close()
+		conn = nil
+		reason = constants.FailedInitialPing
 		return
 	}
 
+	reason = constants.ValidStorageProof
+
+	return
+}
+
+// bagCheck is the result of checkBag, durations are empty for phases which were not reached
+type bagCheck struct {
+	reason constants.ReasonCode
+	// failed piece or the last checked one
+	pieceID       *int32
+	pieceSize     *int32
+	piecesChecked int32
+	piecesValid   int32
+	confidence    float64
+	total         time.Duration
+	ping          *time.Duration
+	torrentInfo   *time.Duration
+	// mean time of piece request
+	piece         *time.Duration
+	bytesReceived int64
+}
+
+func (c bagCheck) toDB(sc db.ContractToProviderRelation, seed int64) db.ContractProofsCheck {
+	latency := c.total.Milliseconds()
+
+	return db.ContractProofsCheck{
+		ContractAddress: sc.Address,
+		ProviderAddress: sc.ProviderAddress,
+		Reason:          c.reason,
+		PieceID:         c.pieceID,
+		LatencyMs:       &latency,
+		PingMs:          milliseconds(c.ping),
+		InfoMs:          milliseconds(c.torrentInfo),
+		PieceMs:         milliseconds(c.piece),
+		PieceSize:       c.pieceSize,
+		BytesReceived:   c.bytesReceived,
+		PiecesChecked:   c.piecesChecked,
+		PiecesValid:     c.piecesValid,
+		Confidence:      c.confidence,
+		SampleSeed:      seed,
+	}
+}
+
+// checkBag requests sampled pieces of the bag with proofs and measures each phase,
+// stops on the first failed piece
+func checkBag(ctx context.Context, conn storageConn, bagID string, smp sampler, log *slog.Logger) (res bagCheck) {
+	log = log.With(slog.String("bag_id", bagID))
+
+	start := time.Now()
+	defer func() {
+		res.total = time.Since(start)
+	}()
+
+	res.reason = constants.NotFound
+
 	// in case connection was lost
-	peer.Reinit()
+	conn.Reinit()
 	// peer can be closed after some time, so for extra stability we reinit before each operation if needed
 	est := time.Now()
 
 	pingCtx, pc := context.WithTimeout(ctx, pingTimeout)
-	_, err := peer.Ping(pingCtx)
+	err := conn.Ping(pingCtx)
 	pc()
+	res.ping = since(est)
 	if err != nil {
 		log.Debug("ping to provider failed", "error", err)
-		reason = constants.PingFailed
+		res.reason = constants.PingFailed
 		return
 	}
 
-	bag, dErr := hex.DecodeString(bagID)
-	if dErr != nil {
-		log.Error("failed to decode bag ID", "error", dErr)
-		reason = constants.InvalidBagID
+	if time.Since(est) > 5*time.Second {
+		conn.Reinit()
+		est = time.Now()
+	}
+
+	infoStart := time.Now()
+	info, over, received, reason := getTorrentInfo(ctx, conn, bagID, log)
+	res.torrentInfo = since(infoStart)
+	res.bytesReceived += received
+	if reason != constants.ValidStorageProof {
+		res.reason = reason
 		return
 	}
 
-	over, err := tl.Hash(keys.PublicKeyOverlay{Key: bag})
+	piecesNum := piecesCount(info)
+	pieceSize := int32(info.PieceSize)
+	res.pieceSize = &pieceSize
+
+	ids := smp.pieces(bagID, info.FileSize, piecesNum)
+	if len(ids) == 0 {
+		log.Debug("bag has no pieces", "file_size", info.FileSize, "piece_size", info.PieceSize)
+		res.reason = constants.InvalidHeader
+		return
+	}
+
+	var piecesTime time.Duration
+	defer func() {
+		if res.piecesChecked > 0 {
+			res.piece = new(time.Duration)
+			*res.piece = piecesTime / time.Duration(res.piecesChecked)
+		}
+		res.confidence = confidence(int(res.piecesValid), len(ids), piecesNum)
+	}()
+
+	for _, id := range ids {
+		if time.Since(est) > 5*time.Second {
+			conn.Reinit()
+			est = time.Now()
+		}
+
+		res.pieceID = &id
+		res.piecesChecked++
+
+		pieceStart := time.Now()
+		reason, received := checkPiece(ctx, conn, over, id, info.RootHash, log)
+		piecesTime += time.Since(pieceStart)
+		res.bytesReceived += received
+
+		if reason != constants.ValidStorageProof {
+			res.reason = reason
+			return
+		}
+
+		res.piecesValid++
+	}
+
+	res.reason = constants.ValidStorageProof
+	return
+}
+
+// getTorrentInfo requests bag info from provider and checks it matches the bag id,
+// reason is ValidStorageProof on success
+func getTorrentInfo(ctx context.Context, conn storageConn, bagID string, log *slog.Logger) (info storage.TorrentInfo, over []byte, received int64, reason constants.ReasonCode) {
+	bag, err := hex.DecodeString(bagID)
 	if err != nil {
-		log.Debug("failed to hash overlay key", "error", err)
+		log.Error("failed to decode bag ID", "error", err)
 		reason = constants.InvalidBagID
 		return
 	}
 
-	if time.Since(est) > 5*time.Second {
-		peer.Reinit()
-		est = time.Now()
+	over, err = tl.Hash(keys.PublicKeyOverlay{Key: bag})
+	if err != nil {
+		log.Debug("failed to hash overlay key", "error", err)
+		reason = constants.InvalidBagID
+		return
 	}
 
-	// get torrent info
-	var res storage.TorrentInfoContainer
 	rlCtx, rlc := context.WithTimeout(ctx, rlQueryTimeout)
-	err = rl.DoQuery(rlCtx, 32<<20, overlay.WrapQuery(over, &storage.GetTorrentInfo{}), &res)
+	container, err := conn.GetTorrentInfo(rlCtx, over)
 	rlc()
 	if err != nil {
 		log.Debug("failed to get torrent info from provider", "error", err)
@@ -770,7 +988,9 @@ func checkPiece(ctx context.Context, rl *rldp.RLDP, bagID string, log *slog.Logg
 		return
 	}
 
-	cl, err := cell.FromBOC(res.Data)
+	received = int64(len(container.Data))
+
+	cl, err := cell.FromBOC(container.Data)
 	if err != nil {
 		log.Debug("failed to parse BoC of torrent info", "error", err)
 		reason = constants.InvalidHeader
@@ -783,7 +1003,6 @@ func checkPiece(ctx context.Context, rl *rldp.RLDP, bagID string, log *slog.Logg
 		return
 	}
 
-	var info storage.TorrentInfo
 	err = tlb.LoadFromCell(&info, cl.BeginParse())
 	if err != nil {
 		log.Debug("failed to load torrent info from cell", "error", err)
@@ -791,31 +1010,26 @@ func checkPiece(ctx context.Context, rl *rldp.RLDP, bagID string, log *slog.Logg
 		return
 	}
 
-	pieceID := int32(1)
-	var p int32
-	if info.PieceSize != 0 {
-		p = int32(info.FileSize / uint64(info.PieceSize))
-	}
-	if p != 0 {
-		pieceID = rand.Int31n(p)
-	}
+	reason = constants.ValidStorageProof
 
-	if time.Since(est) > 5*time.Second {
-		peer.Reinit()
-	}
+	return
+}
 
-	// get piece proof and validate
-	var piece storage.Piece
-	rl2Ctx, rl2c := context.WithTimeout(ctx, rlQueryTimeout)
-	err = rl.DoQuery(rl2Ctx, 32<<20, overlay.WrapQuery(over, &storage.GetPiece{PieceID: pieceID}), &piece)
-	rl2c()
+// checkPiece requests the piece with proof and validates it against the bag root hash
+func checkPiece(ctx context.Context, conn storageConn, over []byte, id int32, rootHash []byte, log *slog.Logger) (reason constants.ReasonCode, received int64) {
+	log = log.With(slog.Int("piece_id", int(id)))
 
+	rlCtx, rlc := context.WithTimeout(ctx, rlQueryTimeout)
+	piece, err := conn.GetPiece(rlCtx, over, id)
+	rlc()
 	if err != nil {
 		log.Debug("failed to get piece from provider", "error", err)
 		reason = constants.CantGetPiece
 		return
 	}
 
+	received = int64(len(piece.Data) + len(piece.Proof))
+
 	proof, err := cell.FromBOC(piece.Proof)
 	if err != nil {
 		log.Debug("failed to parse BoC of piece", "error", err)
@@ -823,7 +1037,7 @@ func checkPiece(ctx context.Context, rl *rldp.RLDP, bagID string, log *slog.Logg
 		return
 	}
 
-	err = cell.CheckProof(proof, info.RootHash)
+	err = cell.CheckProof(proof, rootHash)
 	if err != nil {
 		log.Debug("proof check failed", "error", err)
 		reason = constants.ProofCheckFailed
@@ -871,7 +1085,7 @@ func (w *providersMasterWorker) updateRejectedContracts(ctx context.Context, sto
 		contractProviders := make(map[string]struct{}, len(contract.Providers))
 		for _, provider := range contract.Providers {
 			providerPublicKey := fmt.Sprintf("%x", provider.Key)
-			if isRemovedByLowBalance(new(big.Int).SetUint64(uniqueContractAddresses[contract.Address]), provider, contract) {
+			if isRemovedByLowBalance(uniqueContractAddresses[contract.Address], provider, contract) {
 				log.Warn("storage contract has not enough balance for too long, will be removed",
 					"provider", providerPublicKey,
 					"address", contract.Address,
@@ -915,6 +1129,24 @@ func (w *providersMasterWorker) updateRejectedContracts(ctx context.Context, sto
 		return nil, err
 	}
 
+	// balances are only shown to contracts owners, failure doesn't stop proofs checks
+	if bErr := w.providers.UpdateContractsBalances(ctx, contractsBalances(contractsProvidersList, uniqueContractAddresses)); bErr != nil {
+		log.Error("failed to update storage contracts balances", "error", bErr)
+	}
+
+	now := time.Now().Unix()
+	events := make([]db.WebhookEvent, 0, len(closedContracts))
+	for _, sc := range closedContracts {
+		events = append(events, db.WebhookEvent{
+			Type:            constants.ContractRejectedEvent,
+			ProviderPubKey:  sc.ProviderPublicKey,
+			ContractAddress: sc.Address,
+			BagID:           sc.BagID,
+			Timestamp:       now,
+		})
+	}
+	w.sendEvents(ctx, events, log)
+
 	log.Info("successfully updated rejected storage contracts",
 		"closed_count", len(closedContracts),
 		"active_count", len(activeContracts))
@@ -922,6 +1154,17 @@ func (w *providersMasterWorker) updateRejectedContracts(ctx context.Context, sto
 	return
 }
 
+// sendEvents queues webhooks, failure is only logged as the state is already saved
+func (w *providersMasterWorker) sendEvents(ctx context.Context, events []db.WebhookEvent, log *slog.Logger) {
+	if len(events) == 0 {
+		return
+	}
+
+	if err := w.webhooks.AddEvents(ctx, events); err != nil {
+		log.Error("failed to add webhook events", slog.Int("count", len(events)), slog.String("error", err.Error()))
+	}
+}
+
 func (w *providersMasterWorker) updateProvidersIPs(ctx context.Context, storageContracts []db.ContractToProviderRelation) (availableProvidersIPs map[string]db.ProviderIP, err error) {
 	log := w.logger.With(slog.String("worker", "StoreProof"), slog.String("function", "updateProvidersIPs"))
 
@@ -955,11 +1198,11 @@ func (w *providersMasterWorker) updateProvidersIPs(ctx context.Context, storageC
 			defer func() { <-semaphore }()
 
 			providerIPs, pErr := w.findProviderIPs(ctx, contract, log)
+
+			mu.Lock()
 			if pErr != nil {
 				notFoundIPs = append(notFoundIPs, contract.ProviderPublicKey)
 			}
-
-			mu.Lock()
 			availableProvidersIPs[contract.ProviderPublicKey] = providerIPs
 			mu.Unlock()
 		}(sc)
@@ -1266,11 +1509,18 @@ func NewWorker(
 	providers providers,
 	system system,
 	ton ton,
-	providerClient *transport.Client,
-	dhtClient *dht.Client,
+	providerClient providerClient,
+	dhtClient dhtClient,
 	ipinfo ipclient,
+	webhooks webhooks,
 	masterAddr string,
 	batchSize uint32,
+	sampling Sampling,
+	audit Audit,
+	scorer rating.Scorer,
+	ratingUptimeWindow string,
+	ratesPollDuration prometheus.Histogram,
+	ratesTimeouts prometheus.Counter,
 	logger *slog.Logger,
 ) Worker {
 	_, prv, err := ed25519.GenerateKey(nil)
@@ -1280,15 +1530,24 @@ func NewWorker(
 	}
 
 	return &providersMasterWorker{
-		providers:      providers,
-		system:         system,
-		ton:            ton,
-		prv:            prv,
-		providerClient: providerClient,
-		dhtClient:      dhtClient,
-		ipinfo:         ipinfo,
-		masterAddr:     masterAddr,
-		batchSize:      batchSize,
-		logger:         logger,
+		providers:          providers,
+		system:             system,
+		ton:                ton,
+		prv:                prv,
+		providerClient:     providerClient,
+		storage:            &adnlConnector{prv: prv},
+		dhtClient:          dhtClient,
+		ipinfo:             ipinfo,
+		webhooks:           webhooks,
+		masterAddr:         masterAddr,
+		batchSize:          batchSize,
+		sampling:           sampling,
+		audit:              audit,
+		scorer:             scorer,
+		ratingUptimeWindow: ratingUptimeWindow,
+		responseTimes:      newResponseTimes(),
+		ratesPollDuration:  ratesPollDuration,
+		ratesTimeouts:      ratesTimeouts,
+		logger:             logger,
 	}
 }
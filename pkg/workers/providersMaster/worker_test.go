@@ -0,0 +1,267 @@
+package providersmaster
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	tonclient "mytonprovider-backend/pkg/clients/ton"
+	"mytonprovider-backend/pkg/constants"
+	"mytonprovider-backend/pkg/models/db"
+	"mytonprovider-backend/pkg/pricing"
+)
+
+func newTestWorker(network *fakeNetwork, repo *fakeProviders, ton *fakeTon) *providersMasterWorker {
+	return &providersMasterWorker{
+		providers:         repo,
+		ton:               ton,
+		webhooks:          &fakeWebhooks{},
+		providerClient:    network,
+		dhtClient:         network,
+		storage:           network,
+		sampling:          Sampling{MinPieces: 2, MaxPieces: 4},
+		responseTimes:     newResponseTimes(),
+		ratesPollDuration: prometheus.NewHistogram(prometheus.HistogramOpts{Name: "rates_poll_duration"}),
+		ratesTimeouts:     prometheus.NewCounter(prometheus.CounterOpts{Name: "rates_timeouts_count"}),
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func Test_UpdateKnownProviders(t *testing.T) {
+	spread := ratesPollSpread
+	ratesPollSpread = 0
+	t.Cleanup(func() { ratesPollSpread = spread })
+
+	network := newFakeNetwork()
+	online := network.addProvider(t, "10.0.0.1", newFakeStorage(nil))
+	offline := network.addProvider(t, "10.0.0.2", newFakeStorage(nil))
+	offline.rates = nil
+	broken := network.addProvider(t, "10.0.0.3", newFakeStorage(nil))
+	broken.rates.MinSpan = broken.rates.MaxSpan + 1
+
+	repo := &fakeProviders{
+		pubkeys: []string{online.pubkey(), offline.pubkey(), broken.pubkey(), "not a key"},
+		rates: map[string]db.ProviderRates{
+			online.pubkey(): {RatePerMBDay: 500, MinBounty: 50, MinSpan: 3600, MaxSpan: 86400},
+		},
+	}
+	w := newTestWorker(network, repo, nil)
+
+	if _, err := w.UpdateKnownProviders(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statuses := make(map[string]db.ProviderStatusUpdate, len(repo.statuses))
+	for _, s := range repo.statuses {
+		statuses[s.Pubkey] = s
+	}
+
+	if len(statuses) != 3 {
+		t.Fatalf("unexpected statuses: %+v", repo.statuses)
+	}
+
+	if s := statuses[online.pubkey()]; !s.IsOnline || s.RTTMs == nil || s.ErrorClass != nil {
+		t.Errorf("unexpected status of online provider: %+v", s)
+	}
+
+	if s := statuses[offline.pubkey()]; s.IsOnline || s.RTTMs != nil || s.ErrorClass == nil || *s.ErrorClass != constants.RatesUnknownError {
+		t.Errorf("unexpected status of offline provider: %+v", s)
+	}
+
+	if s := statuses[broken.pubkey()]; !s.IsOnline || s.RTTMs == nil || s.ErrorClass == nil || *s.ErrorClass != constants.RatesInvalidResponse {
+		t.Errorf("unexpected status of provider with invalid rates: %+v", s)
+	}
+
+	if len(repo.updates) != 1 {
+		t.Fatalf("got %d providers updates, want 1", len(repo.updates))
+	}
+
+	u := repo.updates[0]
+	if u.Pubkey != online.pubkey() || u.RatePerMBDay != 1000 || u.MinBounty != 50 || u.MinSpan != 3600 || u.MaxSpan != 86400 {
+		t.Fatalf("unexpected provider update: %+v", u)
+	}
+
+	events := w.webhooks.(*fakeWebhooks).events
+	if len(events) != 1 || events[0].Type != constants.ProviderPriceChangedEvent || events[0].ProviderPubKey != online.pubkey() {
+		t.Fatalf("unexpected webhook events: %+v", events)
+	}
+
+	if events[0].OldRates.RatePerMBDay != 500 || events[0].NewRates.RatePerMBDay != 1000 {
+		t.Fatalf("unexpected price change: %+v -> %+v", events[0].OldRates, events[0].NewRates)
+	}
+
+	if w.responseTimes.timeout(online.pubkey()) != ratesMinTimeout {
+		t.Fatalf("timeout of fast provider = %s, want %s", w.responseTimes.timeout(online.pubkey()), ratesMinTimeout)
+	}
+}
+
+func Test_StoreProof(t *testing.T) {
+	pause := bagChecksPause
+	bagChecksPause = 0
+	t.Cleanup(func() { bagChecksPause = pause })
+
+	stored := newFakeBag(t, 1, 8)
+	lost := newFakeBag(t, 2, 8)
+	other := newFakeBag(t, 3, 8)
+
+	network := newFakeNetwork()
+	// storage is found by its ADNL proof
+	direct := network.addProvider(t, "10.0.0.1", newFakeStorage(map[fakeBag]fakeBagMode{
+		stored: bagStored,
+		lost:   bagNoPieces,
+	}))
+	// storage is found via overlay DHT of its bags
+	overlayOnly := network.addProvider(t, "10.0.0.2", newFakeStorage(map[fakeBag]fakeBagMode{
+		stored: bagStored,
+		other:  bagBadProof,
+	}))
+	overlayOnly.noStorageProof = true
+	// provider is not in DHT at all
+	unknown := &fakeProvider{key: newFakeKey(t)}
+
+	relation := func(p *fakeProvider, n byte, bag fakeBag) db.ContractToProviderRelation {
+		return db.ContractToProviderRelation{
+			ProviderPublicKey: p.pubkey(),
+			ProviderAddress:   "provider-" + p.pubkey()[:8],
+			Address:           newFakeContractAddress(n),
+			BagID:             bag.id,
+			Size:              8 * fakePieceSize,
+		}
+	}
+
+	contracts := []db.ContractToProviderRelation{
+		relation(direct, 1, stored),
+		relation(direct, 2, lost),
+		relation(overlayOnly, 1, stored),
+		relation(overlayOnly, 3, other),
+		relation(unknown, 4, stored),
+		// provider left the contract on chain
+		relation(direct, 5, other),
+	}
+
+	ton := &fakeTon{providers: map[string][]string{
+		contracts[0].Address: {direct.pubkey(), overlayOnly.pubkey()},
+		contracts[1].Address: {direct.pubkey()},
+		contracts[3].Address: {overlayOnly.pubkey()},
+		contracts[4].Address: {unknown.pubkey()},
+		contracts[5].Address: {},
+	}}
+
+	repo := &fakeProviders{contracts: contracts}
+	w := newTestWorker(network, repo, ton)
+
+	if _, err := w.StoreProof(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.rejected) != 1 || repo.rejected[0] != contracts[5] {
+		t.Fatalf("unexpected rejected contracts: %+v", repo.rejected)
+	}
+
+	// balances of all contracts read from chain, including the rejected one
+	if len(repo.balances) != 5 {
+		t.Fatalf("got %d contracts balances, want 5", len(repo.balances))
+	}
+
+	ips := make(map[string]db.ProviderIP, len(repo.ips))
+	for _, ip := range repo.ips {
+		ips[ip.PublicKey] = ip
+	}
+
+	if len(ips) != 2 {
+		t.Fatalf("got %d providers ips, want 2", len(ips))
+	}
+
+	for _, p := range []*fakeProvider{direct, overlayOnly} {
+		ip := ips[p.pubkey()]
+		if ip.Provider.IP != p.ip || ip.Storage.IP != p.ip || ip.Storage.Port != fakeStoragePort {
+			t.Errorf("unexpected ips of %s: %+v", p.pubkey(), ip)
+		}
+	}
+
+	want := map[contractKey]constants.ReasonCode{
+		{contract: contracts[0].Address, provider: contracts[0].ProviderAddress}: constants.ValidStorageProof,
+		{contract: contracts[1].Address, provider: contracts[1].ProviderAddress}: constants.CantGetPiece,
+		{contract: contracts[2].Address, provider: contracts[2].ProviderAddress}: constants.ValidStorageProof,
+		{contract: contracts[3].Address, provider: contracts[3].ProviderAddress}: constants.ProofCheckFailed,
+		{contract: contracts[4].Address, provider: contracts[4].ProviderAddress}: constants.IPNotFound,
+	}
+
+	if len(repo.checks) != len(want) {
+		t.Fatalf("got %d checks, want %d", len(repo.checks), len(want))
+	}
+
+	for _, check := range repo.checks {
+		k := contractKey{contract: check.ContractAddress, provider: check.ProviderAddress}
+		if reason, ok := want[k]; !ok || check.Reason != reason {
+			t.Errorf("%+v: reason = %d, want %d", k, check.Reason, reason)
+		}
+
+		if check.Reason == constants.ValidStorageProof && (check.PiecesChecked == 0 || check.PiecesValid != check.PiecesChecked) {
+			t.Errorf("%+v: %d of %d pieces are valid", k, check.PiecesValid, check.PiecesChecked)
+		}
+	}
+}
+
+func Test_ContractsBalances(t *testing.T) {
+	const (
+		size = 1024 * 1024
+		rate = 1_000_000 // NanoTON per day for 1 MB bag
+	)
+
+	paid := []tonclient.Provider{{RatePerMBDay: rate}, {RatePerMBDay: rate}}
+	free := []tonclient.Provider{{RatePerMBDay: 0}}
+
+	onChain := []tonclient.StorageContractProviders{
+		{Address: "paid", Balance: pricing.StorageFee + 10*rate, Providers: paid},
+		{Address: "spent", Balance: pricing.StorageFee / 2, Providers: paid},
+		{Address: "free", Balance: 1_000_000_000, Providers: free},
+		{Address: "empty", Balance: 1_000_000_000},
+		{Address: "unavailable", LiteServerError: true},
+	}
+
+	sizes := map[string]uint64{"paid": size, "spent": size, "free": size, "empty": size, "unavailable": size}
+
+	balances := contractsBalances(onChain, sizes)
+
+	got := make(map[string]db.ContractBalance, len(balances))
+	for _, b := range balances {
+		got[b.Address] = b
+	}
+
+	if _, ok := got["unavailable"]; ok || len(got) != 4 {
+		t.Fatalf("unexpected balances: %+v", balances)
+	}
+
+	five, zero := 5.0, 0.0
+
+	tests := []struct {
+		address  string
+		balance  uint64
+		daysLeft *float64
+	}{
+		{address: "paid", balance: pricing.StorageFee + 10*rate, daysLeft: &five},
+		{address: "spent", balance: pricing.StorageFee / 2, daysLeft: &zero},
+		{address: "free", balance: 1_000_000_000},
+		{address: "empty", balance: 1_000_000_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			b := got[tt.address]
+			if b.Balance != tt.balance {
+				t.Errorf("balance = %d, want %d", b.Balance, tt.balance)
+			}
+
+			switch {
+			case tt.daysLeft == nil && b.DaysLeft != nil:
+				t.Errorf("days left = %f, want none", *b.DaysLeft)
+			case tt.daysLeft != nil && (b.DaysLeft == nil || *b.DaysLeft != *tt.daysLeft):
+				t.Errorf("days left = %v, want %f", b.DaysLeft, *tt.daysLeft)
+			}
+		})
+	}
+}
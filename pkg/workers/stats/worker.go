@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+type providers interface {
+	GetNetworkStats(ctx context.Context) (stats db.NetworkStats, err error)
+}
+
+type snapshots interface {
+	HasNetworkDailySnapshot(ctx context.Context, day time.Time) (exists bool, err error)
+	AddNetworkDailySnapshot(ctx context.Context, snapshot db.NetworkDailySnapshot) (err error)
+}
+
+type statsWorker struct {
+	providers providers
+	snapshots snapshots
+	logger    *slog.Logger
+}
+
+type Worker interface {
+	SnapshotNetworkStats(ctx context.Context) (interval time.Duration, err error)
+}
+
+// SnapshotNetworkStats saves network stats once a UTC day. It's checked hourly,
+// so the snapshot is taken soon after the day starts even if the service was down at midnight
+func (w *statsWorker) SnapshotNetworkStats(ctx context.Context) (interval time.Duration, err error) {
+	const (
+		failureInterval = 1 * time.Minute
+		successInterval = 1 * time.Hour
+	)
+
+	log := w.logger.With(slog.String("worker", "SnapshotNetworkStats"))
+	log.Debug("checking network stats snapshot")
+
+	interval = successInterval
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	exists, err := w.snapshots.HasNetworkDailySnapshot(ctx, day)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	if exists {
+		return
+	}
+
+	stats, err := w.providers.GetNetworkStats(ctx)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	err = w.snapshots.AddNetworkDailySnapshot(ctx, db.NetworkDailySnapshot{
+		Day:          day,
+		NetworkStats: stats,
+	})
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	log.Info("saved network stats snapshot", slog.Time("day", day))
+
+	return
+}
+
+func NewWorker(providers providers, snapshots snapshots, logger *slog.Logger) Worker {
+	return &statsWorker{
+		providers: providers,
+		snapshots: snapshots,
+		logger:    logger,
+	}
+}
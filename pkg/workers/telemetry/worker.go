@@ -114,6 +114,7 @@ func (w *telemetryWorker) UpdateTelemetry(ctx context.Context) (interval time.Du
 			DisksLoadPercent:   telemetryItem.DisksLoadPercent,
 			IOPS:               telemetryItem.IOPS,
 			PPS:                telemetryItem.PPS,
+			IsSigned:           telemetryItem.IsSigned,
 		})
 
 		if len(telemetryItem.NetLoad) > 0 {
@@ -206,6 +207,7 @@ func (w *telemetryWorker) UpdateBenchmarks(ctx context.Context) (interval time.D
 			BenchmarkTimestamp: timestamp.Format(time.RFC3339),
 			Country:            country,
 			ISP:                benchmarkItem.Network.Client.ISP,
+			IsSigned:           benchmarkItem.IsSigned,
 		})
 	}
 
@@ -0,0 +1,196 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const (
+	deliveriesBatchSize      = 100
+	maxConcurrentDeliveries  = 10
+	maxResponseBodyToDiscard = 64 * 1024
+	leaseMargin              = time.Minute
+)
+
+type repository interface {
+	GetPendingDeliveries(ctx context.Context, limit int, lease time.Duration) (deliveries []db.WebhookDelivery, err error)
+	UpdateDeliveries(ctx context.Context, results []db.WebhookDeliveryResult) (err error)
+}
+
+type webhooksWorker struct {
+	repo           repository
+	client         *http.Client
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	logger         *slog.Logger
+}
+
+type Worker interface {
+	DeliverWebhooks(ctx context.Context) (interval time.Duration, err error)
+}
+
+func (w *webhooksWorker) DeliverWebhooks(ctx context.Context) (interval time.Duration, err error) {
+	const (
+		successInterval = 5 * time.Second
+		failureInterval = 15 * time.Second
+	)
+
+	log := w.logger.With(slog.String("worker", "DeliverWebhooks"))
+
+	interval = successInterval
+
+	// claimed deliveries are not taken by other instances until the whole batch can time out
+	lease := w.client.Timeout*(deliveriesBatchSize/maxConcurrentDeliveries+1) + leaseMargin
+	deliveries, err := w.repo.GetPendingDeliveries(ctx, deliveriesBatchSize, lease)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	log.Debug("delivering webhooks", slog.Int("count", len(deliveries)))
+
+	results := make([]db.WebhookDeliveryResult, len(deliveries))
+	semaphore := make(chan struct{}, maxConcurrentDeliveries)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i] = w.deliver(ctx, deliveries[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var delivered, failed int
+	for _, r := range results {
+		if r.Delivered {
+			delivered++
+		} else if r.Failed {
+			failed++
+		}
+	}
+
+	err = w.repo.UpdateDeliveries(ctx, results)
+	if err != nil {
+		interval = failureInterval
+		return
+	}
+
+	log.Info("webhooks delivered", slog.Int("delivered", delivered), slog.Int("failed", failed), slog.Int("total", len(deliveries)))
+
+	// there may be more pending deliveries
+	if len(deliveries) == deliveriesBatchSize {
+		interval = 0
+	}
+
+	return
+}
+
+func (w *webhooksWorker) deliver(ctx context.Context, d db.WebhookDelivery) (result db.WebhookDeliveryResult) {
+	result.ID = d.ID
+
+	err := w.send(ctx, d)
+	if err == nil {
+		result.Delivered = true
+		return
+	}
+
+	result.Error = err.Error()
+	attempt := d.Attempts + 1
+	if attempt >= w.maxAttempts {
+		result.Failed = true
+		return
+	}
+
+	result.NextAttemptAt = time.Now().Add(w.retryDelay(attempt))
+
+	return
+}
+
+func (w *webhooksWorker) send(ctx context.Context, d db.WebhookDelivery) (err error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+sign(d.Secret, ts, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// drain body to reuse connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodyToDiscard))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// retryDelay grows exponentially: base, base*2, base*4, ... up to max
+func (w *webhooksWorker) retryDelay(attempt int) time.Duration {
+	delay := w.retryBaseDelay
+	for i := 1; i < attempt && delay < w.retryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, w.retryMaxDelay)
+}
+
+func sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewWorker(
+	repo repository,
+	timeout time.Duration,
+	maxAttempts int,
+	retryBaseDelay time.Duration,
+	retryMaxDelay time.Duration,
+	logger *slog.Logger,
+) Worker {
+	return &webhooksWorker{
+		repo:           repo,
+		client:         &http.Client{Timeout: timeout},
+		maxAttempts:    maxAttempts,
+		retryBaseDelay: retryBaseDelay,
+		retryMaxDelay:  retryMaxDelay,
+		logger:         logger,
+	}
+}
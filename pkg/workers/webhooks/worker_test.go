@@ -0,0 +1,164 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+type fakeRepository struct {
+	mu         sync.Mutex
+	deliveries []db.WebhookDelivery
+	lease      time.Duration
+	results    []db.WebhookDeliveryResult
+}
+
+func (f *fakeRepository) GetPendingDeliveries(_ context.Context, limit int, lease time.Duration) ([]db.WebhookDelivery, error) {
+	f.lease = lease
+	return f.deliveries[:min(limit, len(f.deliveries))], nil
+}
+
+func (f *fakeRepository) UpdateDeliveries(_ context.Context, results []db.WebhookDeliveryResult) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, results...)
+	return nil
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver is webhook endpoint which answers with status and records requests
+func newReceiver(t *testing.T, status int) (*httptest.Server, chan receivedRequest) {
+	t.Helper()
+
+	received := make(chan receivedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, received
+}
+
+func newTestWorker(repo repository) *webhooksWorker {
+	return NewWorker(
+		repo,
+		time.Second,
+		3,
+		time.Minute,
+		10*time.Minute,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	).(*webhooksWorker)
+}
+
+func Test_DeliverSignature(t *testing.T) {
+	srv, received := newReceiver(t, http.StatusOK)
+
+	d := db.WebhookDelivery{
+		ID:        42,
+		URL:       srv.URL,
+		Secret:    "secret",
+		EventType: "provider.offline",
+		Payload:   []byte(`{"type":"provider.offline"}`),
+	}
+
+	repo := &fakeRepository{deliveries: []db.WebhookDelivery{d}}
+	w := newTestWorker(repo)
+
+	if _, err := w.DeliverWebhooks(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repo.results) != 1 || !repo.results[0].Delivered || repo.results[0].ID != d.ID {
+		t.Fatalf("unexpected results: %+v", repo.results)
+	}
+
+	if repo.lease <= w.client.Timeout {
+		t.Fatalf("lease %s is shorter than delivery timeout", repo.lease)
+	}
+
+	r := <-received
+	if string(r.body) != string(d.Payload) {
+		t.Fatalf("body = %s, want %s", r.body, d.Payload)
+	}
+
+	if r.header.Get("X-Webhook-Event") != d.EventType || r.header.Get("X-Webhook-Delivery") != strconv.FormatInt(d.ID, 10) {
+		t.Fatalf("unexpected headers: %v", r.header)
+	}
+
+	mac := hmac.New(sha256.New, []byte(d.Secret))
+	mac.Write([]byte(r.header.Get("X-Webhook-Timestamp") + "." + string(d.Payload)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.header.Get("X-Webhook-Signature") != want {
+		t.Fatalf("signature = %s, want %s", r.header.Get("X-Webhook-Signature"), want)
+	}
+}
+
+func Test_DeliverRetries(t *testing.T) {
+	srv, _ := newReceiver(t, http.StatusInternalServerError)
+	w := newTestWorker(&fakeRepository{})
+
+	tests := []struct {
+		name      string
+		attempts  int
+		failed    bool
+		wantDelay time.Duration
+	}{
+		{name: "first failure", attempts: 0, wantDelay: time.Minute},
+		{name: "second failure", attempts: 1, wantDelay: 2 * time.Minute},
+		{name: "last attempt", attempts: 2, failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			r := w.deliver(context.Background(), db.WebhookDelivery{ID: 1, URL: srv.URL, Attempts: tt.attempts})
+
+			if r.Delivered || r.Failed != tt.failed || r.Error == "" {
+				t.Fatalf("unexpected result: %+v", r)
+			}
+
+			if tt.failed {
+				if !r.NextAttemptAt.IsZero() {
+					t.Fatalf("next attempt is scheduled after the last one: %s", r.NextAttemptAt)
+				}
+				return
+			}
+
+			if delay := r.NextAttemptAt.Sub(start); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Fatalf("next attempt in %s, want %s", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func Test_RetryDelay(t *testing.T) {
+	w := newTestWorker(&fakeRepository{})
+
+	for attempt, want := range map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		5:  10 * time.Minute,
+		30: 10 * time.Minute,
+	} {
+		if got := w.retryDelay(attempt); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
@@ -3,79 +3,482 @@ package workers
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mytonprovider-backend/pkg/models/db"
 	"mytonprovider-backend/pkg/workers/cleaner"
 	providersmaster "mytonprovider-backend/pkg/workers/providersMaster"
+	"mytonprovider-backend/pkg/workers/stats"
 	"mytonprovider-backend/pkg/workers/telemetry"
+	"mytonprovider-backend/pkg/workers/webhooks"
+)
+
+const (
+	leaderLeaseKey = "workersLeader"
+	// how many times lease is renewed during its ttl
+	leaseRenewsPerTTL = 6
+	releaseTimeout    = 5 * time.Second
+	// run result is saved even if the job was stopped
+	finishRunTimeout = 5 * time.Second
 )
 
 type workerFunc = func(ctx context.Context) (interval time.Duration, err error)
 
+type jobs interface {
+	StartJobRun(ctx context.Context, name string, instanceID string, manual bool) (id int64, err error)
+	FinishJobRun(ctx context.Context, id int64, runErr string, nextRunAt time.Time) (err error)
+	CloseInstanceJobRuns(ctx context.Context, instanceID string) (closed int, err error)
+	CloseOtherInstancesJobRuns(ctx context.Context, names []string, instanceID string) (closed int, err error)
+	AddJobTrigger(ctx context.Context, name string) (err error)
+	TakeJobTriggers(ctx context.Context, names []string) (taken []string, err error)
+	GetJobTriggers(ctx context.Context, names []string, since time.Time) (triggers []db.JobTrigger, err error)
+	AcquireLease(ctx context.Context, key string, holder string, ttl time.Duration) (acquired bool, err error)
+	ReleaseLease(ctx context.Context, key string, holder string) (err error)
+	GetParam(ctx context.Context, key string) (value string, err error)
+}
+
+type job struct {
+	name string
+	f    workerFunc
+	// overrides interval returned by successful run, 0 - use worker interval
+	interval time.Duration
+	trigger  chan struct{}
+	// job works with instance local data and runs on every instance
+	local bool
+}
+
+// worker runs jobs only while the instance holds the leader lease,
+// so several instances can share one database
 type worker struct {
-	telemetry       telemetry.Worker
-	providersMaster providersmaster.Worker
-	cleaner         cleaner.Worker
-	logger          *slog.Logger
+	jobs       []*job
+	jobsMap    map[string]*job
+	repo       jobs
+	instanceID string
+	leaseTTL   time.Duration
+	isLeader   *prometheus.GaugeVec
+	logger     *slog.Logger
 }
 
 type Workers interface {
 	Start(ctx context.Context) (err error)
+	Jobs() (names []string)
+	RunJob(ctx context.Context, name string) (found bool, err error)
+	Leader(ctx context.Context) (instanceID string, err error)
 }
 
 func (w *worker) Start(ctx context.Context) (err error) {
-	go w.run(ctx, "UpdateTelemetry", w.telemetry.UpdateTelemetry)
-	go w.run(ctx, "UpdateBenchmarks", w.telemetry.UpdateBenchmarks)
+	w.isLeader.WithLabelValues(w.instanceID).Set(0)
 
-	go w.run(ctx, "CollectNewProviders", w.providersMaster.CollectNewProviders)
-	go w.run(ctx, "UpdateKnownProviders", w.providersMaster.UpdateKnownProviders)
-	go w.run(ctx, "CollectProvidersNewStorageContracts", w.providersMaster.CollectProvidersNewStorageContracts)
-	go w.run(ctx, "StoreProof", w.providersMaster.StoreProof)
-	go w.run(ctx, "UpdateUptime", w.providersMaster.UpdateUptime)
-	go w.run(ctx, "UpdateRating", w.providersMaster.UpdateRating)
-	go w.run(ctx, "UpdateIPInfo", w.providersMaster.UpdateIPInfo)
+	// runs left by the previous process with the same instance id will never finish
+	if closed, cErr := w.repo.CloseInstanceJobRuns(ctx, w.instanceID); cErr != nil {
+		w.logger.Error("failed to close interrupted job runs", slog.String("error", cErr.Error()))
+	} else if closed > 0 {
+		w.logger.Warn("closed interrupted job runs", slog.Int("count", closed))
+	}
 
-	go w.run(ctx, "CleanupOldData", w.cleaner.CleanupOldData)
+	for _, j := range w.jobs {
+		if j.local {
+			go w.run(ctx, j)
+		}
+	}
+
+	go w.elect(ctx)
 
 	return nil
 }
 
-func (w *worker) run(ctx context.Context, name string, f workerFunc) {
-	logger := w.logger.With(slog.String("run_worker", name))
+// leaderTerm is a period of holding the leader lease. Its jobs are stopped by the fence timer
+// if the lease is not renewed for half of ttl, so they stop before another instance can take the lease
+type leaderTerm struct {
+	ctx   context.Context
+	stop  context.CancelFunc
+	fence *time.Timer
+	// closed when all jobs of the term returned
+	done chan struct{}
+}
+
+func (t *leaderTerm) end() {
+	t.fence.Stop()
+	t.stop()
+}
+
+func (t *leaderTerm) finished() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// elect periodically takes or renews the leader lease, starts jobs on becoming leader
+// and stops them when the lease is lost. Manual runs of local jobs are polled on every instance
+func (w *worker) elect(ctx context.Context) {
+	logger := w.logger.With(slog.String("instance", w.instanceID))
+
+	renewInterval := w.leaseTTL / leaseRenewsPerTTL
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	var (
+		// nil if the instance is not leading
+		term *leaderTerm
+		// the last ended term, its jobs may still be returning
+		previous *leaderTerm
+		triggers = newLocalTriggers(w.jobs)
+	)
+
+	stepDown := func() {
+		term.end()
+		previous, term = term, nil
+		w.isLeader.WithLabelValues(w.instanceID).Set(0)
+	}
+
+	for {
+		start := time.Now()
+		acquireCtx, cancel := context.WithTimeout(ctx, renewInterval)
+		acquired, err := w.repo.AcquireLease(acquireCtx, leaderLeaseKey, w.instanceID, w.leaseTTL)
+		cancel()
+
+		// fence fired, lease was not renewed in time
+		if term != nil && term.ctx.Err() != nil && ctx.Err() == nil {
+			logger.Warn("leader lease is not renewed for too long, stopping jobs")
+			stepDown()
+		}
+
+		switch {
+		case err != nil:
+			// keep working on short db problems, fence stops jobs before other instances can take the lease
+			logger.Error("failed to acquire leader lease", slog.String("error", err.Error()))
+		case acquired && term != nil:
+			// lease ttl is counted from the time it was renewed in db, which is after the start
+			term.fence.Reset(w.leaseTTL/2 - time.Since(start))
+			w.takeTriggers(ctx, logger)
+		case acquired && previous != nil && !previous.finished():
+			logger.Info("waiting for jobs of the previous leader term to return")
+		case acquired:
+			logger.Info("became leader, starting jobs")
+			w.closeStaleRuns(ctx, logger)
+			term = w.startLeaderJobs(ctx, w.leaseTTL/2-time.Since(start))
+			w.isLeader.WithLabelValues(w.instanceID).Set(1)
+			w.takeTriggers(ctx, logger)
+		case term != nil:
+			logger.Warn("leader lease is taken by another instance, stopping jobs")
+			stepDown()
+		}
+
+		triggers.poll(ctx, w.repo, logger)
+
+		select {
+		case <-ctx.Done():
+			if term != nil {
+				term.end()
+				w.isLeader.WithLabelValues(w.instanceID).Set(0)
+
+				// jobs which don't return until the fence time may still write, lease expires by ttl then
+				select {
+				case <-term.done:
+				case <-time.After(w.leaseTTL / 2):
+					logger.Warn("leader jobs didn't return on shutdown, leaving lease to expire")
+					return
+				}
+
+				// release lease to let other instances take over without waiting for ttl
+				releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+				if rErr := w.repo.ReleaseLease(releaseCtx, leaderLeaseKey, w.instanceID); rErr != nil {
+					logger.Error("failed to release leader lease", slog.String("error", rErr.Error()))
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startLeaderJobs runs all not local jobs until the term ends or its fence fires
+func (w *worker) startLeaderJobs(ctx context.Context, fence time.Duration) *leaderTerm {
+	ctx, stop := context.WithCancel(ctx)
+	t := &leaderTerm{
+		ctx:   ctx,
+		stop:  stop,
+		fence: time.AfterFunc(fence, stop),
+		done:  make(chan struct{}),
+	}
+
+	var wg sync.WaitGroup
+	for _, j := range w.jobs {
+		if j.local {
+			continue
+		}
+
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			w.run(ctx, j)
+		}(j)
+	}
+
+	go func() {
+		wg.Wait()
+		close(t.done)
+	}()
+
+	return t
+}
+
+// closeStaleRuns closes runs of leader jobs left unfinished by previous leaders
+func (w *worker) closeStaleRuns(ctx context.Context, logger *slog.Logger) {
+	names := make([]string, 0, len(w.jobs))
+	for _, j := range w.jobs {
+		if !j.local {
+			names = append(names, j.name)
+		}
+	}
+
+	closed, err := w.repo.CloseOtherInstancesJobRuns(ctx, names, w.instanceID)
+	if err != nil {
+		logger.Error("failed to close runs of previous leader", slog.String("error", err.Error()))
+		return
+	}
+
+	if closed > 0 {
+		logger.Warn("closed runs of previous leader", slog.Int("count", closed))
+	}
+}
+
+// takeTriggers passes manual runs of leader jobs requested on any instance to the jobs
+func (w *worker) takeTriggers(ctx context.Context, logger *slog.Logger) {
+	names := make([]string, 0, len(w.jobs))
+	for _, j := range w.jobs {
+		if !j.local {
+			names = append(names, j.name)
+		}
+	}
+
+	taken, err := w.repo.TakeJobTriggers(ctx, names)
+	if err != nil {
+		logger.Error("failed to get job triggers", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, name := range taken {
+		if j, ok := w.jobsMap[name]; ok {
+			j.triggerRun()
+		}
+	}
+}
+
+// localTriggers delivers manual runs of local jobs. Triggers are not removed, so every instance
+// gets them, the instance remembers the last seen request time
+type localTriggers struct {
+	jobs   map[string]*job
+	names  []string
+	since  time.Time
+	synced bool
+}
+
+func newLocalTriggers(jobs []*job) *localTriggers {
+	t := &localTriggers{
+		jobs: make(map[string]*job),
+	}
+
+	for _, j := range jobs {
+		if j.local {
+			t.jobs[j.name] = j
+			t.names = append(t.names, j.name)
+		}
+	}
+
+	return t
+}
 
+// poll runs jobs triggered since the last poll, triggers requested before the first poll are skipped
+func (t *localTriggers) poll(ctx context.Context, repo jobs, logger *slog.Logger) {
+	if len(t.names) == 0 {
+		return
+	}
+
+	triggers, err := repo.GetJobTriggers(ctx, t.names, t.since)
+	if err != nil {
+		logger.Error("failed to get local job triggers", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, tr := range triggers {
+		if tr.RequestedAt.After(t.since) {
+			t.since = tr.RequestedAt
+		}
+
+		if j, ok := t.jobs[tr.Name]; ok && t.synced {
+			j.triggerRun()
+		}
+	}
+
+	t.synced = true
+}
+
+func (w *worker) Jobs() (names []string) {
+	names = make([]string, 0, len(w.jobs))
+	for _, j := range w.jobs {
+		names = append(names, j.name)
+	}
+
+	return
+}
+
+// RunJob asks instances running the job to start it right after the current run, or immediately if it is waiting.
+// Leader jobs are run by the leader, local jobs by every instance
+func (w *worker) RunJob(ctx context.Context, name string) (found bool, err error) {
+	if _, found = w.jobsMap[name]; !found {
+		return
+	}
+
+	err = w.repo.AddJobTrigger(ctx, name)
+
+	return
+}
+
+func (w *worker) Leader(ctx context.Context) (instanceID string, err error) {
+	return w.repo.GetParam(ctx, leaderLeaseKey)
+}
+
+func (w *worker) run(ctx context.Context, j *job) {
+	logger := w.logger.With(slog.String("run_worker", j.name))
+
+	manual := false
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		default:
-			interval, err := f(ctx)
-			if err != nil {
-				logger.Error(err.Error())
-			}
-			if interval <= 0 {
-				interval = time.Second
-			}
+			interval := w.runOnce(ctx, j, manual, logger)
 			t := time.NewTimer(interval)
 			select {
 			case <-ctx.Done():
 				t.Stop()
 				return
+			case <-j.trigger:
+				t.Stop()
+				manual = true
 			case <-t.C:
+				manual = false
 			}
 		}
 	}
 }
 
+// runOnce runs the job and saves the run to db, db errors don't stop the job
+func (w *worker) runOnce(ctx context.Context, j *job, manual bool, logger *slog.Logger) (interval time.Duration) {
+	id, dbErr := w.repo.StartJobRun(ctx, j.name, w.instanceID, manual)
+	if dbErr != nil {
+		logger.Error("failed to save job run", slog.String("error", dbErr.Error()))
+	}
+
+	interval, err := j.f(ctx)
+
+	var runErr string
+	if err != nil {
+		logger.Error(err.Error())
+		runErr = err.Error()
+	} else if j.interval > 0 && interval > 0 {
+		interval = j.interval
+	}
+
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	if dbErr == nil {
+		finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finishRunTimeout)
+		dbErr = w.repo.FinishJobRun(finishCtx, id, runErr, time.Now().Add(interval))
+		cancel()
+		if dbErr != nil {
+			logger.Error("failed to save job run result", slog.String("error", dbErr.Error()))
+		}
+	}
+
+	return
+}
+
 func NewWorkers(
 	telemetry telemetry.Worker,
 	providersMaster providersmaster.Worker,
 	cleaner cleaner.Worker,
+	webhooks webhooks.Worker,
+	stats stats.Worker,
+	repo jobs,
+	intervals map[string]time.Duration,
+	instanceID string,
+	leaseTTL time.Duration,
+	isLeader *prometheus.GaugeVec,
 	logger *slog.Logger,
 ) Workers {
-	return &worker{
-		telemetry:       telemetry,
-		providersMaster: providersMaster,
-		cleaner:         cleaner,
-		logger:          logger,
+	w := &worker{
+		repo:       repo,
+		instanceID: instanceID,
+		leaseTTL:   leaseTTL,
+		isLeader:   isLeader,
+		logger:     logger,
+	}
+
+	// telemetry is buffered in memory of the instance which received it
+	w.addLocal("UpdateTelemetry", telemetry.UpdateTelemetry)
+	w.addLocal("UpdateBenchmarks", telemetry.UpdateBenchmarks)
+
+	w.add("CollectNewProviders", providersMaster.CollectNewProviders)
+	w.add("UpdateKnownProviders", providersMaster.UpdateKnownProviders)
+	w.add("CollectProvidersNewStorageContracts", providersMaster.CollectProvidersNewStorageContracts)
+	w.add("StoreProof", providersMaster.StoreProof)
+	w.add("UpdateUptime", providersMaster.UpdateUptime)
+	w.add("UpdateRating", providersMaster.UpdateRating)
+	w.add("UpdateIPInfo", providersMaster.UpdateIPInfo)
+	w.add("RunAudits", providersMaster.RunAudits)
+
+	w.add("CleanupOldData", cleaner.CleanupOldData)
+
+	w.add("DeliverWebhooks", webhooks.DeliverWebhooks)
+
+	w.add("SnapshotNetworkStats", stats.SnapshotNetworkStats)
+
+	w.jobsMap = make(map[string]*job, len(w.jobs))
+	for _, j := range w.jobs {
+		w.jobsMap[j.name] = j
+	}
+
+	for name, interval := range intervals {
+		j, ok := w.jobsMap[name]
+		if !ok {
+			logger.Warn("interval is set for unknown job", slog.String("job", name))
+			continue
+		}
+		j.interval = interval
 	}
+
+	return w
+}
+
+// triggerRun starts the job immediately if it's waiting for the next run
+func (j *job) triggerRun() {
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+		// already triggered
+	}
+}
+
+func (w *worker) add(name string, f workerFunc) {
+	w.jobs = append(w.jobs, &job{
+		name:    name,
+		f:       f,
+		trigger: make(chan struct{}, 1),
+	})
+}
+
+func (w *worker) addLocal(name string, f workerFunc) {
+	w.add(name, f)
+	w.jobs[len(w.jobs)-1].local = true
 }
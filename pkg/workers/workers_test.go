@@ -0,0 +1,320 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"mytonprovider-backend/pkg/models/db"
+)
+
+const testLeaseTTL = 300 * time.Millisecond
+
+// fakeJobs keeps leader lease and job triggers in memory
+type fakeJobs struct {
+	mu         sync.Mutex
+	acquired   bool
+	acquireErr error
+	triggers   map[string]time.Time
+	releasedAt time.Time
+}
+
+func newFakeJobs() *fakeJobs {
+	return &fakeJobs{triggers: make(map[string]time.Time)}
+}
+
+func (f *fakeJobs) setLease(acquired bool, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acquired, f.acquireErr = acquired, err
+}
+
+func (f *fakeJobs) hasTrigger(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.triggers[name]
+	return ok
+}
+
+func (f *fakeJobs) StartJobRun(context.Context, string, string, bool) (int64, error) { return 1, nil }
+
+func (f *fakeJobs) FinishJobRun(context.Context, int64, string, time.Time) error { return nil }
+
+func (f *fakeJobs) CloseInstanceJobRuns(context.Context, string) (int, error) { return 0, nil }
+
+func (f *fakeJobs) CloseOtherInstancesJobRuns(context.Context, []string, string) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeJobs) AddJobTrigger(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.triggers[name] = time.Now()
+	return nil
+}
+
+func (f *fakeJobs) TakeJobTriggers(_ context.Context, names []string) (taken []string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, name := range names {
+		if _, ok := f.triggers[name]; ok {
+			delete(f.triggers, name)
+			taken = append(taken, name)
+		}
+	}
+	return
+}
+
+func (f *fakeJobs) GetJobTriggers(_ context.Context, names []string, since time.Time) (triggers []db.JobTrigger, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, name := range names {
+		if at, ok := f.triggers[name]; ok && at.After(since) {
+			triggers = append(triggers, db.JobTrigger{Name: name, RequestedAt: at})
+		}
+	}
+	return
+}
+
+func (f *fakeJobs) AcquireLease(context.Context, string, string, time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acquired, f.acquireErr
+}
+
+func (f *fakeJobs) ReleaseLease(context.Context, string, string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acquired, f.releasedAt = false, time.Now()
+	return nil
+}
+
+func (f *fakeJobs) released() (at time.Time, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.releasedAt, !f.releasedAt.IsZero()
+}
+
+func (f *fakeJobs) GetParam(context.Context, string) (string, error) { return "", nil }
+
+// fakeJob runs until its context is cancelled and counts runs
+type fakeJob struct {
+	runs      atomic.Int32
+	running   atomic.Bool
+	stoppedAt atomic.Int64
+	// if set, the job returns only after it is closed, as if it finishes a write after cancellation
+	hold chan struct{}
+}
+
+func (j *fakeJob) run(ctx context.Context) (time.Duration, error) {
+	j.runs.Add(1)
+	j.running.Store(true)
+	<-ctx.Done()
+	if j.hold != nil {
+		<-j.hold
+	}
+	j.running.Store(false)
+	j.stoppedAt.Store(time.Now().UnixNano())
+	return time.Hour, nil
+}
+
+// fakeLocalJob returns immediately and waits for the next run for an hour
+type fakeLocalJob struct {
+	runs atomic.Int32
+}
+
+func (j *fakeLocalJob) run(context.Context) (time.Duration, error) {
+	j.runs.Add(1)
+	return time.Hour, nil
+}
+
+func newTestWorkers(repo jobs, leader *fakeJob, local *fakeLocalJob) *worker {
+	w := &worker{
+		repo:       repo,
+		instanceID: "test",
+		leaseTTL:   testLeaseTTL,
+		isLeader:   prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "is_leader"}, []string{"instance"}),
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	w.addLocal("Local", local.run)
+	w.add("Leader", leader.run)
+
+	w.jobsMap = make(map[string]*job, len(w.jobs))
+	for _, j := range w.jobs {
+		w.jobsMap[j.name] = j
+	}
+
+	return w
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * testLeaseTTL)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_ElectLeaseGainedAndLost(t *testing.T) {
+	repo := newFakeJobs()
+	leader, local := &fakeJob{}, &fakeLocalJob{}
+	w := newTestWorkers(repo, leader, local)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	eventually(t, "local job run", func() bool { return local.runs.Load() == 1 })
+	time.Sleep(testLeaseTTL / 2)
+	if leader.runs.Load() != 0 {
+		t.Fatal("leader job runs without lease")
+	}
+
+	repo.setLease(true, nil)
+	eventually(t, "leader job run", func() bool { return leader.running.Load() })
+	if testutil.ToFloat64(w.isLeader.WithLabelValues("test")) != 1 {
+		t.Fatal("instance is not reported as leader")
+	}
+
+	repo.setLease(false, nil)
+	eventually(t, "leader job stop", func() bool { return !leader.running.Load() })
+	eventually(t, "leader gauge reset", func() bool { return testutil.ToFloat64(w.isLeader.WithLabelValues("test")) == 0 })
+
+	repo.setLease(true, nil)
+	eventually(t, "leader job run in the new term", func() bool { return leader.runs.Load() == 2 && leader.running.Load() })
+
+	if local.runs.Load() != 1 {
+		t.Fatalf("local job runs = %d, want 1", local.runs.Load())
+	}
+}
+
+func Test_ElectLeaseRenewalFails(t *testing.T) {
+	repo := newFakeJobs()
+	repo.setLease(true, nil)
+	leader := &fakeJob{}
+	w := newTestWorkers(repo, leader, &fakeLocalJob{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eventually(t, "leader job run", func() bool { return leader.running.Load() })
+
+	failedAt := time.Now()
+	repo.setLease(false, errors.New("connection refused"))
+	eventually(t, "leader job stop", func() bool { return !leader.running.Load() })
+
+	// jobs keep working on short db problems, but stop before other instance can take the lease
+	stoppedAfter := time.Unix(0, leader.stoppedAt.Load()).Sub(failedAt)
+	if stoppedAfter < testLeaseTTL/3 || stoppedAfter >= testLeaseTTL {
+		t.Fatalf("leader job stopped %s after renewal failure, lease ttl is %s", stoppedAfter, testLeaseTTL)
+	}
+
+	eventually(t, "leader gauge reset", func() bool { return testutil.ToFloat64(w.isLeader.WithLabelValues("test")) == 0 })
+}
+
+func Test_ElectTriggers(t *testing.T) {
+	repo := newFakeJobs()
+	// requested before the instance started, it's not run again
+	repo.triggers["Local"] = time.Now().Add(-time.Minute)
+
+	leader, local := &fakeJob{}, &fakeLocalJob{}
+	w := newTestWorkers(repo, leader, local)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := w.Start(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eventually(t, "local job run", func() bool { return local.runs.Load() == 1 })
+
+	// local jobs are triggered on instances which don't lead
+	if _, err := w.RunJob(ctx, "Local"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eventually(t, "triggered local job run", func() bool { return local.runs.Load() == 2 })
+
+	// leader job trigger waits for the leader
+	if _, err := w.RunJob(ctx, "Leader"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(testLeaseTTL / 2)
+	if !repo.hasTrigger("Leader") || leader.runs.Load() != 0 {
+		t.Fatal("leader job trigger is taken by not leading instance")
+	}
+
+	repo.setLease(true, nil)
+	eventually(t, "leader trigger is taken", func() bool { return !repo.hasTrigger("Leader") })
+
+	if found, _ := w.RunJob(ctx, "Unknown"); found {
+		t.Fatal("unknown job is found")
+	}
+
+	if local.runs.Load() != 2 {
+		t.Fatalf("local job runs = %d, want 2", local.runs.Load())
+	}
+}
+
+func Test_ElectShutdownWaitsForJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		returns time.Duration
+		release bool
+	}{
+		{name: "jobs return before fence", returns: testLeaseTTL / 6, release: true},
+		{name: "jobs don't return in time", returns: testLeaseTTL, release: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobs()
+			repo.setLease(true, nil)
+			leader := &fakeJob{hold: make(chan struct{})}
+			w := newTestWorkers(repo, leader, &fakeLocalJob{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := w.Start(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			eventually(t, "leader job run", func() bool { return leader.running.Load() })
+
+			cancel()
+			time.AfterFunc(tt.returns, func() { close(leader.hold) })
+
+			if !tt.release {
+				time.Sleep(2 * testLeaseTTL)
+				if _, ok := repo.released(); ok {
+					t.Fatal("lease is released while leader job is still running")
+				}
+				return
+			}
+
+			eventually(t, "lease release", func() bool { _, ok := repo.released(); return ok })
+			releasedAt, _ := repo.released()
+			if releasedAt.Before(time.Unix(0, leader.stoppedAt.Load())) {
+				t.Fatal("lease is released before leader job returned")
+			}
+		})
+	}
+}
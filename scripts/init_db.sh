@@ -1,28 +1,34 @@
 #!/bin/bash
 
-# This script initializes the database with tables, schemas, functions and triggers from db/init.sql
+# This script initializes the database with tables, schemas, functions and triggers
+# by applying migrations embedded in the built backend binary. The backend also applies them on start.
 
 set -e
 
-SQL_FILE="../db/init.sql"
-
 if [[ -z "$PG_USER" || -z "$PG_PASSWORD" || -z "$PG_DB" ]]; then
     echo "❌ Missing required environment variables"
     echo ""
     echo "Usage:"
-    echo "PG_HOST=<host> PG_PORT=<port> PG_USER=<username> PG_PASSWORD=<password> PG_DB=<database> bash init_db.sh"
+    echo "PG_HOST=<host> PG_PORT=<port> PG_USER=<username> PG_PASSWORD=<password> PG_DB=<database> BINARY=<backend binary> bash init_db.sh"
     echo "Example:"
-    echo "PG_HOST=127.0.0.1 PG_PORT=5432 PG_USER=pguser PG_PASSWORD=secret PG_DB=providerdb bash init_db.sh"
+    echo "PG_HOST=127.0.0.1 PG_PORT=5432 PG_USER=pguser PG_PASSWORD=secret PG_DB=providerdb BINARY=/opt/provider/mtpo-backend bash init_db.sh"
     echo ""
-    echo "PG_HOST and PG_PORT are optional"
+    echo "PG_HOST, PG_PORT and BINARY are optional"
     exit 1
 fi
 
 PG_HOST="${PG_HOST:-127.0.0.1}"
 PG_PORT="${PG_PORT:-5432}"
+BINARY="${BINARY:-/opt/provider/mtpo-backend}"
+
+if [[ ! -x "$BINARY" ]]; then
+    echo "❌ Backend binary not found: $BINARY"
+    echo "Build it with build_backend.sh or set BINARY to its path"
+    exit 1
+fi
 
-echo "Initializing database from $SQL_FILE..."
-if PGPASSWORD="$PG_PASSWORD" psql -h "$PG_HOST" -p "$PG_PORT" -U "$PG_USER" -d "$PG_DB" -f "$SQL_FILE"; then
+echo "Applying database migrations..."
+if DB_HOST="$PG_HOST" DB_PORT="$PG_PORT" DB_USER="$PG_USER" DB_PASSWORD="$PG_PASSWORD" DB_NAME="$PG_DB" "$BINARY" migrate; then
     echo "✅ Database initialization completed successfully"
 else
     echo "❌ Database initialization failed"
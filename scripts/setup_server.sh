@@ -196,22 +196,23 @@ main() {
     print_status "Step 3: Disabling postgres user remote access..."
     execute_script "ib_disable_postgres_user.sh"
     
-    print_status "Step 4: Initializing database..."
-    execute_script "init_db.sh"
-    
-    print_status "Step 5: Setting up Nginx..."
+    print_status "Step 4: Setting up Nginx..."
     execute_script "setup_nginx.sh"
     
-    print_status "Step 6: Setting up log rotation..."
+    print_status "Step 5: Setting up log rotation..."
     execute_script "logs_rotation.sh"
     
-    print_status "Step 7: Securing the server..."
+    print_status "Step 6: Securing the server..."
     export PASSWORD="$NEWUSER_PASSWORD"  # secure_server.sh expects PASSWORD env var
     execute_script "secure_server.sh"
     
-    print_status "Step 8: Building backend application..."
+    print_status "Step 7: Building backend application..."
     execute_script "build_backend.sh"
     
+    # migrations are applied by the built backend binary
+    print_status "Step 8: Initializing database..."
+    execute_script "init_db.sh"
+    
     print_status "Step 9: Running the backend application..."
     su - "$NEWSUDOUSER" -c "cd $WORK_DIR/mytonprovider-backend/scripts && bash run.sh"
 